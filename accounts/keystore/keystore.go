@@ -0,0 +1,105 @@
+// Package keystore unlocks the keys of a directory of Web3 Secret Storage key
+// files, as written by the go-ethereum keystore, to sign transactions with them.
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// ErrUnknownAccount is returned for accounts that have no key in the keystore.
+var ErrUnknownAccount = errors.New("unknown account")
+
+// KeyStore holds the unlocked keys of a keystore directory.
+type KeyStore struct {
+	keys map[common.Address]*Key
+}
+
+// Open reads the key files of the directory and unlocks each of them with the
+// first of the passwords that decrypts it.
+func Open(dir string, passwords []string) (*KeyStore, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ks := &KeyStore{keys: make(map[common.Address]*Key)}
+	for _, file := range files {
+		// Skip editor backups, hidden files and directories
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		keyjson, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := unlock(keyjson, passwords)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ks.keys[key.Address] = key
+		log.Info("Unlocked account", "address", key.Address, "file", path)
+	}
+	return ks, nil
+}
+
+func unlock(keyjson []byte, passwords []string) (*Key, error) {
+	for _, password := range passwords {
+		key, err := DecryptKey(keyjson, password)
+		if errors.Is(err, ErrDecrypt) {
+			continue
+		}
+		return key, err
+	}
+	return nil, ErrDecrypt
+}
+
+// ReadPasswordFile returns the passwords of the file, one per line.
+func ReadPasswordFile(path string) ([]string, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(bytes.TrimRight(text, "\r\n")), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines, nil
+}
+
+// Accounts returns the addresses of the unlocked keys, in ascending order.
+func (ks *KeyStore) Accounts() []common.Address {
+	res := make([]common.Address, 0, len(ks.keys))
+	for addr := range ks.keys {
+		res = append(res, addr)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i][:], res[j][:]) < 0
+	})
+	return res
+}
+
+// HasAddress reports whether the keystore holds the key of the account.
+func (ks *KeyStore) HasAddress(addr common.Address) bool {
+	_, ok := ks.keys[addr]
+	return ok
+}
+
+// SignTx signs the transaction with the key of the account.
+func (ks *KeyStore) SignTx(addr common.Address, tx types.Transaction, signer types.Signer) (types.Transaction, error) {
+	key, ok := ks.keys[addr]
+	if !ok {
+		return nil, fmt.Errorf("%w: %x", ErrUnknownAccount, addr)
+	}
+	return types.SignTx(tx, signer, key.PrivateKey)
+}
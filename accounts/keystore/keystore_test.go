@@ -0,0 +1,115 @@
+package keystore
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+// pbkdf2KeyJSON is the PBKDF2 test vector of the Web3 Secret Storage definition.
+const pbkdf2KeyJSON = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+		"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+		"kdf": "pbkdf2",
+		"kdfparams": {"c": 262144, "dklen": 32, "prf": "hmac-sha256", "salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},
+		"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+// scryptKeyJSON is the scrypt test vector of the Web3 Secret Storage definition.
+const scryptKeyJSON = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+		"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+		"kdf": "scrypt",
+		"kdfparams": {"dklen": 32, "n": 262144, "r": 1, "p": 8, "salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},
+		"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+func TestDecryptScrypt(t *testing.T) {
+	key, err := DecryptKey([]byte(scryptKeyJSON), "testpassword")
+	require.NoError(t, err)
+	require.Equal(t, common.FromHex("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"), crypto.FromECDSA(key.PrivateKey))
+
+	_, err = DecryptKey([]byte(scryptKeyJSON), "wrong")
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestDecryptPBKDF2(t *testing.T) {
+	key, err := DecryptKey([]byte(pbkdf2KeyJSON), "testpassword")
+	require.NoError(t, err)
+	require.Equal(t, common.FromHex("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"), crypto.FromECDSA(key.PrivateKey))
+
+	_, err = DecryptKey([]byte(pbkdf2KeyJSON), "wrong")
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	keyjson, err := EncryptKey(privateKey, "foo", LightScryptN, LightScryptP)
+	require.NoError(t, err)
+
+	key, err := DecryptKey(keyjson, "foo")
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), key.Address)
+	require.Equal(t, crypto.FromECDSA(privateKey), crypto.FromECDSA(key.PrivateKey))
+
+	_, err = DecryptKey(keyjson, "bar")
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestOpenAndSign(t *testing.T) {
+	dir := t.TempDir()
+	var addrs []common.Address
+	for i, password := range []string{"first", "second"} {
+		privateKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keyjson, err := EncryptKey(privateKey, password, LightScryptN, LightScryptP)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "key"+string(rune('0'+i))), keyjson, 0600))
+		addrs = append(addrs, crypto.PubkeyToAddress(privateKey.PublicKey))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("not a key"), 0600))
+	passwordFile := filepath.Join(t.TempDir(), "passwords")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("second\r\nfirst\n"), 0600))
+
+	passwords, err := ReadPasswordFile(passwordFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{"second", "first"}, passwords)
+
+	_, err = Open(dir, passwords[:1])
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	ks, err := Open(dir, passwords)
+	require.NoError(t, err)
+	require.ElementsMatch(t, addrs, ks.Accounts())
+
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+	tx := types.NewTransaction(0, common.Address{1}, uint256.NewInt(1), 21000, uint256.NewInt(1), nil)
+	signed, err := ks.SignTx(addrs[1], tx, *signer)
+	require.NoError(t, err)
+	sender, err := signed.Sender(*signer)
+	require.NoError(t, err)
+	require.Equal(t, addrs[1], sender)
+
+	require.True(t, ks.HasAddress(addrs[0]))
+	_, err = ks.SignTx(common.Address{1}, tx, *signer)
+	require.ErrorIs(t, err, ErrUnknownAccount)
+}
@@ -0,0 +1,38 @@
+package downloader
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSegmentFile(t *testing.T, dir, name string) {
+	c, err := compress.NewCompressor(context.Background(), "test", filepath.Join(dir, name), dir, 100, 1, log.LvlDebug)
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.AddWord([]byte{1}))
+	require.NoError(t, c.Compress())
+}
+
+func TestBuildTorrentFilesOfVerkleSegments(t *testing.T) {
+	dir := t.TempDir()
+	verkleName := snap.VerkleFileName(2_000)
+	createTestSegmentFile(t, dir, verkleName)
+	createTestSegmentFile(t, dir, snap.SegmentFileName(0, 1_000, snap.Headers)) // too small to be seeded
+
+	files, err := seedableSegmentFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{verkleName}, files)
+
+	require.NoError(t, BuildTorrentFilesIfNeed(context.Background(), dir))
+	require.True(t, common.FileExist(filepath.Join(dir, verkleName+".torrent")))
+	torrents, err := AllTorrentFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{verkleName + ".torrent"}, torrents)
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"math/big"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
@@ -102,6 +103,16 @@ func MakePreState(chainRules *params.Rules, tx kv.RwTx, accounts core.GenesisAll
 	return stateReader, stateWriter
 }
 
+// MakeVerklePreState builds the pre-state as a verkle tree in memory
+func MakeVerklePreState(accounts core.GenesisAlloc) (verkle.VerkleNode, error) {
+	root := verkle.New()
+	if err := core.WriteVerkleAlloc(root, nil, nil, accounts); err != nil {
+		return nil, err
+	}
+	root.ComputeCommitment()
+	return root, nil
+}
+
 // calcDifficulty is based on ethash.CalcDifficulty. This method is used in case
 // the caller does not provide an explicit difficulty, but instead provides only
 // parent timestamp + difficulty.
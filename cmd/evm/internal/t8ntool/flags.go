@@ -68,6 +68,14 @@ var (
 			"\t<file> - into the file <file> ",
 		Value: "result.json",
 	}
+	OutputWitnessFlag = cli.StringFlag{
+		Name: "output.witness",
+		Usage: "Determines where to put the verkle `witness` of the transactions, with --state.verkle.\n" +
+			"\t`stdout` - into the stdout output\n" +
+			"\t`stderr` - into the stderr output\n" +
+			"\t<file> - into the file <file> ",
+		Value: "witness.json",
+	}
 	InputAllocFlag = cli.StringFlag{
 		Name:  "input.alloc",
 		Usage: "`stdin` or file name of where to find the prestate alloc to use.",
@@ -100,6 +108,10 @@ var (
 			strings.Join(vm.ActivateableEips(), ", ")),
 		Value: "ArrowGlacier",
 	}
+	VerkleFlag = cli.BoolFlag{
+		Name:  "state.verkle",
+		Usage: "Keep the state in a verkle tree, the state root is the root of the verkle tree",
+	}
 	VerbosityFlag = cli.IntFlag{
 		Name:  "verbosity",
 		Usage: "sets the verbosity level",
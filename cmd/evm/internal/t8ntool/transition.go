@@ -27,6 +27,7 @@ import (
 	"path"
 	"path/filepath"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
@@ -44,6 +45,7 @@ import (
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/tests"
 	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
 	"github.com/ledgerwatch/log/v3"
 
 	"github.com/urfave/cli"
@@ -280,9 +282,29 @@ func Main(ctx *cli.Context) error {
 	defer tx.Rollback()
 
 	reader, writer := MakePreState(chainConfig.Rules(0), tx, prestate.Pre)
+	var (
+		stateReader  state.StateReader          = reader
+		stateWriter  state.WriterWithChangeSets = writer
+		preTree      verkle.VerkleNode
+		keyRecorder  *state.VerkleKeyRecorder
+		verkleWriter *state.VerkleStateWriter
+	)
+	if ctx.Bool(VerkleFlag.Name) {
+		// The witness is proven against the pre-state, so the changes are written into a tree of their own
+		if preTree, err = MakeVerklePreState(prestate.Pre); err != nil {
+			return err
+		}
+		postTree, err1 := MakeVerklePreState(prestate.Pre)
+		if err1 != nil {
+			return err1
+		}
+		keyRecorder = state.NewVerkleKeyRecorder(reader)
+		verkleWriter = state.NewVerkleStateWriter(postTree, nil)
+		stateReader, stateWriter = keyRecorder, state.NewVerkleTeeWriter(writer, verkleWriter)
+	}
 	engine := ethash.NewFaker()
 
-	result, err := core.ExecuteBlockEphemerally(chainConfig, &vmConfig, getHash, engine, block, reader, writer, nil, nil, true, getTracer)
+	result, err := core.ExecuteBlockEphemerally(chainConfig, &vmConfig, getHash, engine, block, stateReader, stateWriter, nil, nil, true, getTracer)
 
 	if hashError != nil {
 		return NewError(ErrorMissingBlockhash, fmt.Errorf("blockhash error: %v", err))
@@ -293,18 +315,58 @@ func Main(ctx *cli.Context) error {
 	}
 
 	// state root calculation
-	root, err := CalculateStateRoot(tx)
-	if err != nil {
-		return err
+	var witness *verkleWitness
+	if verkleWriter != nil {
+		result.StateRoot = verkleWriter.Root()
+		if witness, err = makeVerkleWitness(preTree, keyRecorder.Keys()); err != nil {
+			return err
+		}
+	} else {
+		root, err := CalculateStateRoot(tx)
+		if err != nil {
+			return err
+		}
+		result.StateRoot = *root
 	}
-	result.StateRoot = *root
 
 	// Dump the execution result
 	body, _ := rlp.EncodeToBytes(txs)
 	collector := make(Alloc)
 	dumper := state.NewDumper(tx, prestate.Env.Number)
 	dumper.DumpToCollector(collector, false, false, common.Address{}, 0)
-	return dispatchOutput(ctx, baseDir, result, collector, body)
+	return dispatchOutput(ctx, baseDir, result, collector, body, witness)
+}
+
+// verkleWitness holds the leaves of the pre-state read by the transactions, absent leaves have no value,
+// and their multiproof against the verkle root of the pre-state
+type verkleWitness struct {
+	ParentStateRoot common.Hash         `json:"parentStateRoot"`
+	KeyVals         []verkleWitnessLeaf `json:"keyVals"`
+	Proof           hexutil.Bytes       `json:"proof"`
+}
+
+type verkleWitnessLeaf struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value,omitempty"`
+}
+
+func makeVerkleWitness(preTree verkle.VerkleNode, keys [][]byte) (*verkleWitness, error) {
+	witness := &verkleWitness{
+		ParentStateRoot: preTree.ComputeCommitment().Bytes(),
+		KeyVals:         []verkleWitnessLeaf{},
+	}
+	if len(keys) == 0 {
+		return witness, nil
+	}
+	proof, keyVals, err := vtree.MakeVerkleProof(preTree, keys, nil)
+	if err != nil {
+		return nil, fmt.Errorf("verkle witness: %w", err)
+	}
+	witness.Proof = proof
+	for _, pair := range keyVals {
+		witness.KeyVals = append(witness.KeyVals, verkleWitnessLeaf{Key: pair.Key, Value: pair.Value})
+	}
+	return witness, nil
 }
 
 // txWithKey is a helper-struct, to allow us to use the types.Transaction along with
@@ -510,7 +572,7 @@ func saveFile(baseDir, filename string, data interface{}) error {
 
 // dispatchOutput writes the output data to either stderr or stdout, or to the specified
 // files
-func dispatchOutput(ctx *cli.Context, baseDir string, result *core.EphemeralExecResult, alloc Alloc, body hexutil.Bytes) error {
+func dispatchOutput(ctx *cli.Context, baseDir string, result *core.EphemeralExecResult, alloc Alloc, body hexutil.Bytes, witness *verkleWitness) error {
 	stdOutObject := make(map[string]interface{})
 	stdErrObject := make(map[string]interface{})
 	dispatch := func(baseDir, fName, name string, obj interface{}) error {
@@ -537,6 +599,11 @@ func dispatchOutput(ctx *cli.Context, baseDir string, result *core.EphemeralExec
 	if err := dispatch(baseDir, ctx.String(OutputBodyFlag.Name), "body", body); err != nil {
 		return err
 	}
+	if witness != nil {
+		if err := dispatch(baseDir, ctx.String(OutputWitnessFlag.Name), "witness", witness); err != nil {
+			return err
+		}
+	}
 	if len(stdOutObject) > 0 {
 		b, err := json.MarshalIndent(stdOutObject, "", " ")
 		if err != nil {
@@ -150,6 +150,8 @@ var stateTransitionCommand = cli.Command{
 		t8ntool.InputTxsFlag,
 		t8ntool.ForknameFlag,
 		t8ntool.ChainIDFlag,
+		t8ntool.VerkleFlag,
+		t8ntool.OutputWitnessFlag,
 		t8ntool.VerbosityFlag,
 	},
 }
@@ -179,6 +181,7 @@ func init() {
 		DisableStackFlag,
 		DisableStorageFlag,
 		DisableReturnDataFlag,
+		t8ntool.VerkleFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
@@ -24,6 +24,7 @@ import (
 	"os"
 
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/cmd/evm/internal/t8ntool"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/vm"
@@ -130,11 +131,17 @@ func aggregateResultsFromStateTests(
 			var root common.Hash
 			var calcRootErr error
 
-			statedb, err := test.Run(&params.Rules{}, tx, st, cfg)
-			// print state root for evmlab tracing
-			root, calcRootErr = trie.CalcRoot("", tx)
-			if err == nil && calcRootErr != nil {
-				err = calcRootErr
+			var statedb *state.IntraBlockState
+			var err error
+			if ctx.GlobalBool(t8ntool.VerkleFlag.Name) {
+				statedb, root, err = test.RunVerkle(&params.Rules{}, tx, st, cfg)
+			} else {
+				statedb, err = test.Run(&params.Rules{}, tx, st, cfg)
+				// print state root for evmlab tracing
+				root, calcRootErr = trie.CalcRoot("", tx)
+				if err == nil && calcRootErr != nil {
+					err = calcRootErr
+				}
 			}
 			if err != nil {
 				// Test failed, mark as so and dump any state to aid debugging
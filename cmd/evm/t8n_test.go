@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/gballet/go-verkle"
 	"github.com/ledgerwatch/erigon/internal/cmdtest"
 )
 
@@ -60,9 +61,10 @@ func (args *t8nInput) get(base string) []string {
 }
 
 type t8nOutput struct {
-	alloc  bool
-	result bool
-	body   bool
+	alloc   bool
+	result  bool
+	body    bool
+	witness bool
 }
 
 func (args *t8nOutput) get() (out []string) {
@@ -81,6 +83,9 @@ func (args *t8nOutput) get() (out []string) {
 	} else {
 		out = append(out, "--output.alloc", "")
 	}
+	if args.witness {
+		out = append(out, "--output.witness", "stdout")
+	}
 	return out
 }
 
@@ -90,6 +95,7 @@ func TestT8n(t *testing.T) {
 	for i, tc := range []struct {
 		base        string
 		input       t8nInput
+		verkle      bool
 		output      t8nOutput
 		expExitCode int
 		expOut      string
@@ -196,11 +202,28 @@ func TestT8n(t *testing.T) {
 			expOut: "exp_arrowglacier.json",
 			output: t8nOutput{alloc: true, result: true},
 		},
+		{ // Verkle state root and witness
+			base: "./testdata/20",
+			input: t8nInput{
+				"alloc.json", "txs.json", "env.json", "Martin",
+			},
+			verkle: true,
+			expOut: "exp.json",
+			output: t8nOutput{alloc: true, result: true, witness: true},
+		},
 	} {
 
 		args := []string{"t8n"}
 		args = append(args, tc.output.get()...)
 		args = append(args, tc.input.get(tc.base)...)
+		if tc.verkle {
+			// Computing the points of the verkle config takes longer than the child process is given,
+			// the parent leaves them precomputed in the working directory
+			if _, err := verkle.GetConfig(); err != nil {
+				t.Fatalf("test %d: verkle config: %v", i, err)
+			}
+			args = append(args, "--state.verkle")
+		}
 		var qArgs []string // quoted args for debugging purposes
 		for _, arg := range args {
 			if len(arg) == 0 {
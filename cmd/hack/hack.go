@@ -45,6 +45,7 @@ import (
 	"github.com/ledgerwatch/erigon/internal/debug"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -64,7 +65,7 @@ var (
 )
 
 func dbSlice(chaindata string, bucket string, prefix []byte) {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	if err := db.View(context.Background(), func(tx kv.Tx) error {
 		c, err := tx.Cursor(bucket)
@@ -85,7 +86,7 @@ func dbSlice(chaindata string, bucket string, prefix []byte) {
 
 // Searches 1000 blocks from the given one to try to find the one with the given state root hash
 func testBlockHashes(chaindata string, block int, stateRoot common.Hash) {
-	ethDb := mdbx.MustOpen(chaindata)
+	ethDb := verkledb.MustOpen(chaindata)
 	defer ethDb.Close()
 	tool.Check(ethDb.View(context.Background(), func(tx kv.Tx) error {
 		blocksToSearch := 10000000
@@ -115,7 +116,7 @@ func getCurrentBlockNumber(tx kv.Tx) *uint64 {
 }
 
 func printCurrentBlockNumber(chaindata string) {
-	ethDb := mdbx.MustOpen(chaindata)
+	ethDb := verkledb.MustOpen(chaindata)
 	defer ethDb.Close()
 	ethDb.View(context.Background(), func(tx kv.Tx) error {
 		if number := getCurrentBlockNumber(tx); number != nil {
@@ -128,7 +129,7 @@ func printCurrentBlockNumber(chaindata string) {
 }
 
 func printTxHashes(chaindata string, block uint64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	if err := db.View(context.Background(), func(tx kv.Tx) error {
 		for b := block; b < block+1; b++ {
@@ -152,7 +153,7 @@ func printTxHashes(chaindata string, block uint64) error {
 }
 
 func readAccount(chaindata string, account common.Address) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
 	tx, txErr := db.BeginRo(context.Background())
@@ -202,7 +203,7 @@ func readAccount(chaindata string, account common.Address) error {
 }
 
 func nextIncarnation(chaindata string, addrHash common.Hash) {
-	ethDb := mdbx.MustOpen(chaindata)
+	ethDb := verkledb.MustOpen(chaindata)
 	defer ethDb.Close()
 	var found bool
 	var incarnationBytes [common.IncarnationLength]byte
@@ -230,7 +231,7 @@ func nextIncarnation(chaindata string, addrHash common.Hash) {
 }
 
 func repairCurrent() {
-	historyDb := mdbx.MustOpen("/Volumes/tb4/erigon/ropsten/geth/chaindata")
+	historyDb := verkledb.MustOpen("/Volumes/tb4/erigon/ropsten/geth/chaindata")
 	defer historyDb.Close()
 	currentDb := mdbx.MustOpen("statedb")
 	defer currentDb.Close()
@@ -267,7 +268,7 @@ func repairCurrent() {
 }
 
 func dumpStorage() {
-	db := mdbx.MustOpen(paths.DefaultDataDir() + "/geth/chaindata")
+	db := verkledb.MustOpen(paths.DefaultDataDir() + "/geth/chaindata")
 	defer db.Close()
 	if err := db.View(context.Background(), func(tx kv.Tx) error {
 		return tx.ForEach(kv.StorageHistory, nil, func(k, v []byte) error {
@@ -280,7 +281,7 @@ func dumpStorage() {
 }
 
 func printBucket(chaindata string) {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	f, err := os.Create("bucket.txt")
 	tool.Check(err)
@@ -306,7 +307,7 @@ func printBucket(chaindata string) {
 
 func searchChangeSet(chaindata string, key []byte, block uint64) error {
 	fmt.Printf("Searching changesets\n")
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err1 := db.BeginRw(context.Background())
 	if err1 != nil {
@@ -327,7 +328,7 @@ func searchChangeSet(chaindata string, key []byte, block uint64) error {
 
 func searchStorageChangeSet(chaindata string, key []byte, block uint64) error {
 	fmt.Printf("Searching storage changesets\n")
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err1 := db.BeginRw(context.Background())
 	if err1 != nil {
@@ -347,7 +348,7 @@ func searchStorageChangeSet(chaindata string, key []byte, block uint64) error {
 }
 
 func extractCode(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	var contractCount int
 	if err1 := db.View(context.Background(), func(tx kv.Tx) error {
@@ -372,7 +373,7 @@ func extractCode(chaindata string) error {
 }
 
 func iterateOverCode(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	hashes := make(map[common.Hash][]byte)
 	if err1 := db.View(context.Background(), func(tx kv.Tx) error {
@@ -418,7 +419,7 @@ func getBlockTotal(tx kv.Tx, blockFrom uint64, blockTotalOrOffset int64) uint64
 }
 
 func extractHashes(chaindata string, blockStep uint64, blockTotalOrOffset int64, name string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
 	f, err := os.Create(fmt.Sprintf("preverified_hashes_%s.go", name))
@@ -461,7 +462,7 @@ func extractHashes(chaindata string, blockStep uint64, blockTotalOrOffset int64,
 }
 
 func extractHeaders(chaindata string, block uint64, blockTotalOrOffset int64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRo(context.Background())
 	if err != nil {
@@ -492,7 +493,7 @@ func extractHeaders(chaindata string, block uint64, blockTotalOrOffset int64) er
 }
 
 func extractBodies(chaindata string, block uint64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRo(context.Background())
 	if err != nil {
@@ -523,7 +524,7 @@ func extractBodies(chaindata string, block uint64) error {
 }
 
 func snapSizes(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
 	tx, err := db.BeginRo(context.Background())
@@ -572,7 +573,7 @@ func snapSizes(chaindata string) error {
 }
 
 func readCallTraces(chaindata string, block uint64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -618,7 +619,7 @@ func readCallTraces(chaindata string, block uint64) error {
 }
 
 func fixTd(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -674,7 +675,7 @@ func fixTd(chaindata string) error {
 }
 
 func advanceExec(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -702,7 +703,7 @@ func advanceExec(chaindata string) error {
 }
 
 func backExec(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -730,7 +731,7 @@ func backExec(chaindata string) error {
 }
 
 func fixState(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -776,7 +777,7 @@ func fixState(chaindata string) error {
 }
 
 func trimTxs(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -869,7 +870,7 @@ func trimTxs(chaindata string) error {
 }
 
 func scanTxs(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRo(context.Background())
 	if err != nil {
@@ -907,7 +908,7 @@ func scanTxs(chaindata string) error {
 }
 
 func scanReceipts3(chaindata string, block uint64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -932,7 +933,7 @@ func scanReceipts2(chaindata string) error {
 	defer f.Close()
 	w := bufio.NewWriter(f)
 	defer w.Flush()
-	dbdb := mdbx.MustOpen(chaindata)
+	dbdb := verkledb.MustOpen(chaindata)
 	defer dbdb.Close()
 	tx, err := dbdb.BeginRw(context.Background())
 	if err != nil {
@@ -990,7 +991,7 @@ func scanReceipts2(chaindata string) error {
 }
 
 func devTx(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	tx, err := db.BeginRo(context.Background())
 	if err != nil {
@@ -1071,7 +1072,7 @@ func keybytesToHex(str []byte) []byte {
 }
 
 func findPrefix(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
 	tx, txErr := db.BeginRo(context.Background())
@@ -1111,7 +1112,7 @@ func findPrefix(chaindata string) error {
 }
 
 func findLogs(chaindata string, block uint64, blockTotal uint64) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
 	tx, txErr := db.BeginRo(context.Background())
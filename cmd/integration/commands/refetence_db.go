@@ -14,6 +14,7 @@ import (
 	mdbx2 "github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 	"github.com/torquem-ch/mdbx-go/mdbx"
@@ -129,10 +130,10 @@ func init() {
 }
 
 func compareStates(ctx context.Context, chaindata string, referenceChaindata string) error {
-	db := mdbx2.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
-	refDB := mdbx2.MustOpen(referenceChaindata)
+	refDB := verkledb.MustOpen(referenceChaindata)
 	defer refDB.Close()
 
 	if err := db.View(context.Background(), func(tx kv.Tx) error {
@@ -155,10 +156,10 @@ func compareStates(ctx context.Context, chaindata string, referenceChaindata str
 	return nil
 }
 func compareBucketBetweenDatabases(ctx context.Context, chaindata string, referenceChaindata string, bucket string) error {
-	db := mdbx2.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 
-	refDB := mdbx2.MustOpen(referenceChaindata)
+	refDB := verkledb.MustOpen(referenceChaindata)
 	defer refDB.Close()
 
 	if err := db.View(context.Background(), func(tx kv.Tx) error {
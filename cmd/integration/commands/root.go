@@ -9,6 +9,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/internal/debug"
 	"github.com/ledgerwatch/erigon/migrations"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 	"github.com/torquem-ch/mdbx-go/mdbx"
@@ -38,7 +39,7 @@ func RootCommand() *cobra.Command {
 func dbCfg(label kv.Label, path string) kv2.MdbxOpts {
 	opts := kv2.NewMDBX(log.New()).Path(path).Label(label)
 	if label == kv.ChainDB {
-		opts = opts.MapSize(8 * datasize.TB)
+		opts = opts.MapSize(8 * datasize.TB).WithTableCfg(verkledb.ChaindataTablesCfg)
 	}
 	if databaseVerbosity != -1 {
 		opts = opts.DBVerbosity(kv.DBVerbosityLvl(databaseVerbosity))
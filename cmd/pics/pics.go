@@ -423,5 +423,9 @@ func main() {
 		if err := initialState1(); err != nil {
 			fmt.Printf("%v\n", err)
 		}
+	case "verkle":
+		if err := verkleSubtree(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
 	}
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/erigon/visual"
+	"github.com/ledgerwatch/log/v3"
+)
+
+var (
+	verkleChaindata = flag.String("verkle-chaindata", "", "path to the database with the verkle tree, for -pic verkle")
+	verkleRoot      = flag.String("verkle-root", "", "root of the verkle tree to draw, the latest one by default")
+	verklePrefix    = flag.String("verkle-prefix", "", "key prefix (whole bytes) of the subtree to draw, its siblings are not expanded")
+	verkleAddress   = flag.String("verkle-address", "", "account whose code chunk stems are highlighted")
+)
+
+// maxVerkleNodes limits the expanded nodes below the prefix, so that small prefixes still give readable pictures
+const maxVerkleNodes = 64
+
+type verkleDrawer struct {
+	w         io.Writer
+	tx        kv.Tx
+	prefix    []byte
+	codeStems map[string]struct{}
+	expanded  int
+}
+
+func verkleSubtree() error {
+	if *verkleChaindata == "" {
+		return fmt.Errorf("-verkle-chaindata is required")
+	}
+	db, err := verkledb.Open(*verkleChaindata, log.Root(), true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.BeginRo(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var root common.Hash
+	if *verkleRoot != "" {
+		root = common.HexToHash(*verkleRoot)
+	} else {
+		c, err := tx.Cursor(verkledb.VerkleRoots)
+		if err != nil {
+			return err
+		}
+		_, v, err := c.Last()
+		c.Close()
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return fmt.Errorf("no verkle roots in %s", *verkleChaindata)
+		}
+		root = common.BytesToHash(v)
+	}
+	d := &verkleDrawer{tx: tx, prefix: common.FromHex(*verklePrefix), codeStems: map[string]struct{}{}}
+	if *verkleAddress != "" {
+		if err := d.addCodeStems(root, common.HexToAddress(*verkleAddress)); err != nil {
+			return err
+		}
+	}
+
+	filename := "verkle.dot"
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	d.w = f
+	visual.StartGraph(f, false)
+	if err := d.draw(root[:], 0, "v_root"); err != nil {
+		f.Close()
+		return err
+	}
+	verkleLegend(f)
+	visual.EndGraph(f)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	//nolint:gosec
+	cmd := exec.Command("dot", "-Tpng:gd", "-o"+dot2png(filename), filename)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Printf("error: %v, output: %s\n", err, output)
+	}
+	return nil
+}
+
+// addCodeStems marks the stems of the code chunks past the header stem, which only hold code
+func (d *verkleDrawer) addCodeStems(root common.Hash, address common.Address) error {
+	header, err := verkledb.ReadLeafNode(d.tx, root, vtree.GetTreeStem(address[:], uint256.NewInt(0)))
+	if err != nil || header == nil {
+		return err
+	}
+	codeSize := header.Value(vtree.CodeSizeLeafKey)
+	if len(codeSize) == 0 {
+		return nil
+	}
+	var size uint64
+	for i := len(codeSize) - 1; i >= 0; i-- {
+		size = size<<8 | uint64(codeSize[i])
+	}
+	for chunk := uint64(0); chunk < (size+30)/31; chunk++ {
+		if treeIndex, subIndex := vtree.CodeChunkTreeIndex(uint256.NewInt(chunk)); subIndex == 0 {
+			d.codeStems[string(vtree.GetTreeStem(address[:], treeIndex))] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (d *verkleDrawer) draw(commitment []byte, depth int, name string) error {
+	encoded, err := d.tx.GetOne(verkledb.VerkleTrie, commitment)
+	if err != nil {
+		return err
+	}
+	if len(encoded) == 0 {
+		return fmt.Errorf("verkle node %x not found", commitment)
+	}
+	node, err := verkle.ParseNode(encoded, byte(depth), commitment)
+	if err != nil {
+		return fmt.Errorf("parse verkle node %x: %w", commitment, err)
+	}
+	switch n := node.(type) {
+	case *verkle.LeafNode:
+		visual.VerkleLeaf(d.w, name, n.Key(0)[:vtree.StemLength], commitment, d.suffixes(n))
+		return nil
+	case *verkle.InternalNode:
+		var children []byte
+		for i, child := range n.Children() {
+			if _, ok := child.(verkle.Empty); !ok {
+				children = append(children, byte(i))
+			}
+		}
+		visual.VerkleInternal(d.w, name, depth, commitment, children)
+		for _, i := range children {
+			// Along the prefix only its path is expanded, below it every child up to the limit
+			if depth < len(d.prefix) {
+				if i != d.prefix[depth] {
+					continue
+				}
+			} else if d.expanded >= maxVerkleNodes {
+				break
+			}
+			d.expanded++
+			childName := fmt.Sprintf("%s_%02x", name, i)
+			childCommitment := n.Children()[i].ComputeCommitment().Bytes()
+			if err := d.draw(childCommitment[:], depth+1, childName); err != nil {
+				return err
+			}
+			fmt.Fprintf(d.w,
+				`%s:c%02x -> %s;
+`, name, i, childName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected verkle node type %T", node)
+	}
+}
+
+// suffixes colors the populated suffixes of a leaf after the layout of vtree: a stem holding the version of an account
+// is the header stem, with the account fields, the first storage slots and the first code chunks.
+func (d *verkleDrawer) suffixes(leaf *verkle.LeafNode) []visual.VerkleSuffix {
+	_, isCode := d.codeStems[string(leaf.Key(0)[:vtree.StemLength])]
+	isHeader := leaf.Value(vtree.VersionLeafKey) != nil
+	var suffixes []visual.VerkleSuffix
+	for i := 0; i < verkle.NodeWidth; i++ {
+		if leaf.Value(i) == nil {
+			continue
+		}
+		color := visual.VerkleOtherColor
+		switch {
+		case isCode:
+			color = visual.VerkleCodeColor
+		case isHeader && i <= vtree.CodeSizeLeafKey:
+			color = visual.VerkleAccountColor
+		case isHeader && uint64(i) >= vtree.CodeOffset.Uint64():
+			color = visual.VerkleCodeColor
+		case isHeader && uint64(i) >= vtree.HeaderStorageOffset.Uint64():
+			color = visual.VerkleStorageColor
+		}
+		suffixes = append(suffixes, visual.VerkleSuffix{Index: byte(i), Color: color})
+	}
+	return suffixes
+}
+
+func verkleLegend(w io.Writer) {
+	fmt.Fprintf(w,
+		`
+	legend [label=<
+	<table border="0" color="#000000" cellborder="1" cellspacing="0">
+	<tr><td bgcolor="%s">account fields</td></tr>
+	<tr><td bgcolor="%s">storage slots</td></tr>
+	<tr><td bgcolor="%s">code chunks</td></tr>
+	<tr><td bgcolor="%s">storage past the header stem, or code of other accounts</td></tr>
+	</table>
+	>];
+	`, visual.VerkleAccountColor, visual.VerkleStorageColor, visual.VerkleCodeColor, visual.VerkleOtherColor)
+}
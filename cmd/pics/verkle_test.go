@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/erigon/visual"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerkleDrawer(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+
+	contract := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	other := common.HexToAddress("0x0000000000000000000000000000000000000123")
+	value := func(x uint64) []byte {
+		v := make([]byte, 32)
+		vtree.Int256ToVerkleFormat(uint256.NewInt(x), v)
+		return v
+	}
+	// The code of the contract takes 130 chunks, the last two are past its header stem
+	keys := map[string][]byte{
+		"version":      vtree.GetTreeKeyVersion(contract[:]),
+		"balance":      vtree.GetTreeKeyBalance(contract[:]),
+		"codeSize":     vtree.GetTreeKeyCodeSize(contract[:]),
+		"headerSlot":   vtree.GetTreeKeyStorageSlot(contract[:], uint256.NewInt(0)),
+		"headerChunk":  vtree.GetTreeKeyCodeChunk(contract[:], uint256.NewInt(0)),
+		"codeChunk":    vtree.GetTreeKeyCodeChunk(contract[:], uint256.NewInt(129)),
+		"mainSlot":     vtree.GetTreeKeyStorageSlot(contract[:], uint256.NewInt(1000)),
+		"otherBalance": vtree.GetTreeKeyBalance(other[:]),
+	}
+	for name, key := range keys {
+		v := value(1)
+		if name == "codeSize" {
+			v = value(130 * 31)
+		}
+		require.NoError(t, tree.Insert(key, v))
+	}
+	root, err := tree.Commit()
+	require.NoError(t, err)
+
+	drawVerkle := func(prefix []byte) string {
+		var buf bytes.Buffer
+		d := &verkleDrawer{w: &buf, tx: tx, prefix: prefix, codeStems: map[string]struct{}{}}
+		require.NoError(t, d.addCodeStems(root, contract))
+		require.NoError(t, d.draw(root[:], 0, "v_root"))
+		return buf.String()
+	}
+	stem := func(name string) []byte { return keys[name][:vtree.StemLength] }
+	suffix := func(color, name string) string {
+		return fmt.Sprintf(`<td bgcolor="%s">%02x</td>`, color, keys[name][vtree.StemLength])
+	}
+
+	dot := drawVerkle(nil)
+	// Every child of the root is a port, linked to the node drawn for it
+	require.Contains(t, dot, "v_root [label=<")
+	require.Contains(t, dot, fmt.Sprintf("depth 0: %s", visual.ShortCommitment(root[:])))
+	for _, name := range []string{"version", "codeChunk", "mainSlot", "otherBalance"} {
+		port := fmt.Sprintf("c%02x", stem(name)[0])
+		require.Contains(t, dot, fmt.Sprintf(`<td port="%s">%02x</td>`, port, stem(name)[0]))
+		require.Contains(t, dot, fmt.Sprintf("v_root:%s -> v_root_%02x", port, stem(name)[0]))
+		require.Contains(t, dot, fmt.Sprintf("stem %x", stem(name)))
+	}
+	require.Equal(t, 4, strings.Count(dot, "stem "))
+
+	// The suffixes are colored after the part of the contract they belong to
+	require.Contains(t, dot, suffix(visual.VerkleAccountColor, "version"))
+	require.Contains(t, dot, suffix(visual.VerkleAccountColor, "balance"))
+	require.Contains(t, dot, suffix(visual.VerkleAccountColor, "codeSize"))
+	require.Contains(t, dot, suffix(visual.VerkleStorageColor, "headerSlot"))
+	require.Contains(t, dot, suffix(visual.VerkleCodeColor, "headerChunk"))
+	require.Contains(t, dot, suffix(visual.VerkleCodeColor, "codeChunk"))
+	require.Contains(t, dot, suffix(visual.VerkleOtherColor, "mainSlot"))
+	require.Contains(t, dot, suffix(visual.VerkleOtherColor, "otherBalance"))
+
+	// Only the path of the prefix is expanded, the other children of the root are left as ports
+	prefix := stem("version")[:1]
+	dot = drawVerkle(prefix)
+	var hidden int
+	for _, name := range []string{"version", "codeChunk", "mainSlot", "otherBalance"} {
+		require.Contains(t, dot, fmt.Sprintf(`<td port="c%02x">`, stem(name)[0]))
+		if stem(name)[0] == prefix[0] {
+			require.Contains(t, dot, fmt.Sprintf("stem %x", stem(name)))
+		} else {
+			hidden++
+			require.NotContains(t, dot, fmt.Sprintf("stem %x", stem(name)))
+			require.NotContains(t, dot, fmt.Sprintf("v_root:c%02x ->", stem(name)[0]))
+		}
+	}
+	require.Greater(t, hidden, 0)
+}
@@ -29,6 +29,7 @@ import (
 	kv2 "github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/erigon/accounts/keystore"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcservices"
@@ -47,6 +48,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/semaphore"
@@ -80,6 +82,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, "rpc.accessList", "", "Specify granular (method-by-method) API allowlist")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcRateLimitFilePath, "rpc.ratelimit", "", "Specify per-client rate limits and per-method costs of the API calls")
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, utils.RpcBatchConcurrencyFlag.Name, 2, utils.RpcBatchConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStreamingDisable, utils.RpcStreamingDisableFlag.Name, false, utils.RpcStreamingDisableFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.DBReadConcurrency, utils.DBReadConcurrencyFlag.Name, utils.DBReadConcurrencyFlag.Value, utils.DBReadConcurrencyFlag.Usage)
@@ -96,12 +99,22 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().DurationVar(&cfg.HTTPTimeouts.WriteTimeout, "http.timeouts.write", rpccfg.DefaultHTTPTimeouts.WriteTimeout, "Maximum duration before timing out writes of the response. It is reset whenever a new request's header is read")
 	rootCmd.PersistentFlags().DurationVar(&cfg.HTTPTimeouts.IdleTimeout, "http.timeouts.idle", rpccfg.DefaultHTTPTimeouts.IdleTimeout, "Maximum amount of time to wait for the next request when keep-alives are enabled. If http.timeouts.idle is zero, the value of http.timeouts.read is used")
 
+	rootCmd.PersistentFlags().StringVar(&cfg.KeystoreDir, "keystore", "", "Directory of the key files that eth_sendTransaction signs with")
+	rootCmd.PersistentFlags().StringVar(&cfg.KeystorePasswordFile, "keystore.password", "", "File with the passwords of the key files, one per line")
+	rootCmd.PersistentFlags().BoolVar(&cfg.KeystoreAllowInsecure, "keystore.allowinsecure", false, "Allow unlocking the keystore when the HTTP endpoint listens on a non-loopback address. Anyone who can reach it can spend from the accounts")
+
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
 	}
+	if err := rootCmd.MarkPersistentFlagFilename("rpc.ratelimit", "json"); err != nil {
+		panic(err)
+	}
 	if err := rootCmd.MarkPersistentFlagDirname("datadir"); err != nil {
 		panic(err)
 	}
+	if err := rootCmd.MarkPersistentFlagDirname("keystore"); err != nil {
+		panic(err)
+	}
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		if err := utils.SetupCobra(cmd); err != nil {
@@ -117,6 +130,27 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 		if cfg.TxPoolApiAddr == "" {
 			cfg.TxPoolApiAddr = cfg.PrivateApiAddr
 		}
+		if cfg.KeystoreDir != "" {
+			// Every client of eth_sendTransaction can spend from the unlocked accounts
+			if !isLoopback(cfg.HttpListenAddress) {
+				if !cfg.KeystoreAllowInsecure {
+					return fmt.Errorf("refusing to unlock the keystore with the HTTP endpoint listening on %q, use a loopback address or --keystore.allowinsecure", cfg.HttpListenAddress)
+				}
+				log.Warn("Unlocked keystore accounts can be spent from by anyone who reaches the HTTP endpoint", "http.addr", cfg.HttpListenAddress)
+			}
+			passwords := []string{""}
+			if cfg.KeystorePasswordFile != "" {
+				var err error
+				if passwords, err = keystore.ReadPasswordFile(cfg.KeystorePasswordFile); err != nil {
+					return fmt.Errorf("read keystore passwords: %w", err)
+				}
+			}
+			ks, err := keystore.Open(cfg.KeystoreDir, passwords)
+			if err != nil {
+				return fmt.Errorf("open keystore: %w", err)
+			}
+			cfg.Keystore = ks
+		}
 		return nil
 	}
 	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
@@ -276,7 +310,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		var rwKv kv.RwDB
 		log.Trace("Creating chain db", "path", cfg.Dirs.Chaindata)
 		limiter := semaphore.NewWeighted(int64(cfg.DBReadConcurrency))
-		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Readonly().Open()
+		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Readonly().Open()
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, err
 		}
@@ -439,9 +473,9 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	return db, borDb, eth, txPool, mining, stateCache, blockReader, ff, agg, txNums, err
 }
 
-func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, authAPI []rpc.API) error {
+func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, db kv.RoDB, rpcAPI []rpc.API, authAPI []rpc.API) error {
 	if len(authAPI) > 0 {
-		engineInfo, err := startAuthenticatedRpcServer(cfg, authAPI)
+		engineInfo, err := startAuthenticatedRpcServer(ctx, cfg, db, authAPI)
 		if err != nil {
 			return err
 		}
@@ -449,13 +483,22 @@ func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API,
 	}
 
 	if cfg.Enabled {
-		return startRegularRpcServer(ctx, cfg, rpcAPI)
+		return startRegularRpcServer(ctx, cfg, db, rpcAPI)
 	}
 
 	return nil
 }
 
-func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API) error {
+// isLoopback reports whether the listen address only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, db kv.RoDB, rpcAPI []rpc.API) error {
 	// register apis and create handler stack
 	httpEndpoint := fmt.Sprintf("%s:%d", cfg.HttpListenAddress, cfg.HttpPort)
 
@@ -468,6 +511,12 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 	}
 	srv.SetAllowList(allowListForRPC)
 
+	rateLimiter, err := parseRateLimitForRPC(ctx, cfg.RpcRateLimitFilePath, db)
+	if err != nil {
+		return err
+	}
+	srv.SetRateLimiter(rateLimiter)
+
 	var defaultAPIList []rpc.API
 
 	for _, api := range rpcAPI {
@@ -555,11 +604,11 @@ type engineInfo struct {
 	EngineHttpEndpoint string
 }
 
-func startAuthenticatedRpcServer(cfg httpcfg.HttpCfg, rpcAPI []rpc.API) (*engineInfo, error) {
+func startAuthenticatedRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, db kv.RoDB, rpcAPI []rpc.API) (*engineInfo, error) {
 	log.Trace("TraceRequests = %t\n", cfg.TraceRequests)
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, cfg.RpcStreamingDisable)
 
-	engineListener, engineSrv, engineHttpEndpoint, err := createEngineListener(cfg, rpcAPI)
+	engineListener, engineSrv, engineHttpEndpoint, err := createEngineListener(ctx, cfg, db, rpcAPI)
 	if err != nil {
 		return nil, fmt.Errorf("could not start RPC api for engine: %w", err)
 	}
@@ -630,8 +679,11 @@ func createHandler(cfg httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Hand
 			return
 		}
 
-		if jwtSecret != nil && !rpc.CheckJwtSecret(w, r, jwtSecret) {
-			return
+		if jwtSecret != nil {
+			var ok bool
+			if r, ok = rpc.CheckJwtSecret(w, r, jwtSecret); !ok {
+				return
+			}
 		}
 
 		httpHandler.ServeHTTP(w, r)
@@ -640,7 +692,7 @@ func createHandler(cfg httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Hand
 	return handler, nil
 }
 
-func createEngineListener(cfg httpcfg.HttpCfg, engineApi []rpc.API) (*http.Server, *rpc.Server, string, error) {
+func createEngineListener(ctx context.Context, cfg httpcfg.HttpCfg, db kv.RoDB, engineApi []rpc.API) (*http.Server, *rpc.Server, string, error) {
 	engineHttpEndpoint := fmt.Sprintf("%s:%d", cfg.AuthRpcHTTPListenAddress, cfg.AuthRpcPort)
 
 	engineSrv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, true)
@@ -649,6 +701,14 @@ func createEngineListener(cfg httpcfg.HttpCfg, engineApi []rpc.API) (*http.Serve
 		return nil, nil, "", fmt.Errorf("could not start register RPC engine api: %w", err)
 	}
 
+	// The consensus layer must not be held up by the budgets of the public endpoint, so the
+	// engine endpoint has limits of its own, if any. Its clients are told apart by the subjects of their tokens.
+	rateLimiter, err := parseRateLimitForRPC(ctx, cfg.AuthRpcRateLimitFilePath, db)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	engineSrv.SetRateLimiter(rateLimiter)
+
 	jwtSecret, err := obtainJWTSecret(cfg)
 	if err != nil {
 		return nil, nil, "", err
@@ -2,6 +2,7 @@ package httpcfg
 
 import (
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/accounts/keystore"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
@@ -30,6 +31,8 @@ type HttpCfg struct {
 	WebsocketEnabled         bool
 	WebsocketCompression     bool
 	RpcAllowListFilePath     string
+	RpcRateLimitFilePath     string
+	AuthRpcRateLimitFilePath string // Rate limits of the engine endpoint, unlimited if empty
 	RpcBatchConcurrency      uint
 	RpcStreamingDisable      bool
 	DBReadConcurrency        int
@@ -47,4 +50,8 @@ type HttpCfg struct {
 	TraceRequests            bool   // Always trace requests in INFO level
 	HTTPTimeouts             rpccfg.HTTPTimeouts
 	AuthRpcTimeouts          rpccfg.HTTPTimeouts
+	KeystoreDir              string             // eth_sendTransaction signs with the keys of this directory
+	KeystorePasswordFile     string             // Passwords of the keys, one per line
+	KeystoreAllowInsecure    bool               // Unlock the keys with the HTTP endpoint listening on a non-loopback address
+	Keystore                 *keystore.KeyStore // Unlocked from KeystoreDir at startup
 }
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// parseRateLimitForRPC reads the rate limits of the file, nil if there is none. The
+// block tags of the ranges charged for are resolved against the head block of the db.
+func parseRateLimitForRPC(ctx context.Context, path string, db kv.RoDB) (*rpc.RateLimiter, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg rpc.RateLimitConfig
+	if err = json.Unmarshal(fileContents, &cfg); err != nil {
+		return nil, err
+	}
+
+	rateLimiter, err := rpc.NewRateLimiter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rateLimiter.SetHeadBlock(func() (head uint64, err error) {
+		err = db.View(ctx, func(tx kv.Tx) error {
+			head, err = rpchelper.GetLatestBlockNumber(tx)
+			return err
+		})
+		return head, err
+	})
+	return rateLimiter, nil
+}
@@ -35,6 +35,10 @@ type PrivateDebugAPI interface {
 	GetModifiedAccountsByHash(_ context.Context, startHash common.Hash, endHash *common.Hash) ([]common.Address, error)
 	TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig, stream *jsoniter.Stream) error
 	AccountAt(ctx context.Context, blockHash common.Hash, txIndex uint64, account common.Address) (*AccountResult, error)
+	VerkleNode(ctx context.Context, commitment hexutil.Bytes) (*VerkleNodeResult, error)
+	VerkleKey(ctx context.Context, address common.Address, treeIndex *hexutil.Big, subIndex hexutil.Uint64) (hexutil.Bytes, error)
+	VerkleRoot(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error)
+	VerkleLeaves(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]*VerkleNodeResult, error)
 }
 
 // PrivateDebugAPIImpl is implementation of the PrivateDebugAPI interface based on remote Db access
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+)
+
+// VerkleLeavesMaxSlots is the maximum number of storage slots, whose stems are returned by debug_verkleLeaves
+const VerkleLeavesMaxSlots = 4096
+
+// VerkleNodeResult is a node of the verkle tree
+type VerkleNodeResult struct {
+	Commitment hexutil.Bytes         `json:"commitment"`
+	Type       string                `json:"type"`
+	Children   map[int]hexutil.Bytes `json:"children,omitempty"` // commitments of the non-empty children, by index
+	Stem       hexutil.Bytes         `json:"stem,omitempty"`
+	Values     map[int]hexutil.Bytes `json:"values,omitempty"` // set values of a leaf, by suffix
+}
+
+func newVerkleNodeResult(node verkle.VerkleNode, commitment []byte) (*VerkleNodeResult, error) {
+	result := &VerkleNodeResult{Commitment: commitment}
+	switch n := node.(type) {
+	case *verkle.InternalNode:
+		result.Type = "internal"
+		result.Children = map[int]hexutil.Bytes{}
+		for i, child := range n.Children() {
+			if _, ok := child.(verkle.Empty); ok {
+				continue
+			}
+			childCommitment := child.ComputeCommitment().Bytes()
+			result.Children[i] = common.CopyBytes(childCommitment[:])
+		}
+	case *verkle.LeafNode:
+		result.Type = "leaf"
+		result.Stem = common.CopyBytes(n.Key(0)[:vtree.StemLength])
+		result.Values = map[int]hexutil.Bytes{}
+		for i := 0; i < verkle.NodeWidth; i++ {
+			if value := n.Value(i); value != nil {
+				result.Values[i] = common.CopyBytes(value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected verkle node type %T", node)
+	}
+	return result, nil
+}
+
+// VerkleNode implements debug_verkleNode. Returns the decoded node of the verkle tree with the given commitment.
+func (api *PrivateDebugAPIImpl) VerkleNode(ctx context.Context, commitment hexutil.Bytes) (*VerkleNodeResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	encoded, err := tx.GetOne(verkledb.VerkleTrie, commitment)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	// Internal nodes don't store their depth, it is only used to insert
+	node, err := verkle.ParseNode(encoded, 0, commitment)
+	if err != nil {
+		return nil, fmt.Errorf("parse verkle node %x: %w", commitment, err)
+	}
+	return newVerkleNodeResult(node, commitment)
+}
+
+// VerkleKey implements debug_verkleKey. Returns the tree key of the leaf subIndex under the stem of (address, treeIndex).
+func (api *PrivateDebugAPIImpl) VerkleKey(_ context.Context, address common.Address, treeIndex *hexutil.Big, subIndex hexutil.Uint64) (hexutil.Bytes, error) {
+	if subIndex >= verkle.NodeWidth {
+		return nil, fmt.Errorf("subIndex %d is out of the node width %d", subIndex, verkle.NodeWidth)
+	}
+	index := new(uint256.Int)
+	if treeIndex != nil {
+		if overflow := index.SetFromBig(treeIndex.ToInt()); overflow || treeIndex.ToInt().Sign() < 0 {
+			return nil, fmt.Errorf("treeIndex %s is not a uint256", treeIndex)
+		}
+	}
+	return vtree.GetTreeKey(address[:], index, byte(subIndex)), nil
+}
+
+// VerkleRoot implements debug_verkleRoot. Returns the root of the verkle tree after the given block.
+func (api *PrivateDebugAPIImpl) VerkleRoot(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer tx.Rollback()
+
+	blockNum, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return api.verkleRoot(tx, blockNum)
+}
+
+// VerkleLeaves implements debug_verkleLeaves. Returns the leaves of an account in the verkle tree after the given block:
+// the header leaf, the leaves of the code chunks and the leaves of the first VerkleLeavesMaxSlots storage slots.
+func (api *PrivateDebugAPIImpl) VerkleLeaves(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]*VerkleNodeResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	root, err := api.verkleRoot(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	stems := map[string]struct{}{}
+	headerLeaf, err := verkledb.ReadLeafNode(tx, root, vtree.GetTreeStem(address[:], uint256.NewInt(0)))
+	if err != nil {
+		return nil, err
+	}
+	if headerLeaf == nil {
+		return []*VerkleNodeResult{}, nil
+	}
+	if codeSize := headerLeaf.Value(vtree.CodeSizeLeafKey); len(codeSize) > 0 {
+		chunks := (verkleLeafToBig(codeSize).Uint64() + 30) / 31
+		for chunk := uint64(0); chunk < chunks; chunk++ {
+			if treeIndex, subIndex := vtree.CodeChunkTreeIndex(uint256.NewInt(chunk)); subIndex == 0 {
+				stems[string(vtree.GetTreeStem(address[:], treeIndex))] = struct{}{}
+			}
+		}
+	}
+	stateReader := state.NewPlainState(tx, blockNum+1)
+	if err := stateReader.ForEachStorage(address, common.Hash{}, func(key, _ common.Hash, _ uint256.Int) bool {
+		treeIndex, _ := vtree.StorageSlotTreeIndex(new(uint256.Int).SetBytes(key[:]))
+		stems[string(vtree.GetTreeStem(address[:], treeIndex))] = struct{}{}
+		return true
+	}, VerkleLeavesMaxSlots); err != nil {
+		return nil, fmt.Errorf("error walking over storage: %w", err)
+	}
+
+	headerCommitment := headerLeaf.ComputeCommitment().Bytes()
+	headerResult, err := newVerkleNodeResult(headerLeaf, headerCommitment[:])
+	if err != nil {
+		return nil, err
+	}
+	result := []*VerkleNodeResult{headerResult}
+	delete(stems, string(headerResult.Stem))
+	sorted := make([]string, 0, len(stems))
+	for stem := range stems {
+		sorted = append(sorted, stem)
+	}
+	sort.Strings(sorted)
+	for _, stem := range sorted {
+		leaf, err := verkledb.ReadLeafNode(tx, root, []byte(stem))
+		if err != nil {
+			return nil, err
+		}
+		if leaf == nil {
+			continue
+		}
+		commitment := leaf.ComputeCommitment().Bytes()
+		leafResult, err := newVerkleNodeResult(leaf, commitment[:])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, leafResult)
+	}
+	return result, nil
+}
+
+func (api *PrivateDebugAPIImpl) verkleRoot(tx kv.Tx, blockNum uint64) (common.Hash, error) {
+	root, err := verkledb.ReadVerkleRoot(tx, blockNum)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if root == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("no verkle root for block %d", blockNum)
+	}
+	return root, nil
+}
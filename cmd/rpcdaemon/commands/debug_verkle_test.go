@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugVerkle(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	address := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	slot := common.BigToHash(uint256.NewInt(1000).ToBig())
+	var root common.Hash
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		require.NoError(t, verkledb.InitDB(tx))
+		tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+		require.NoError(t, err)
+		value := make([]byte, 32)
+		vtree.Int256ToVerkleFormat(uint256.NewInt(1), value)
+		require.NoError(t, tree.Insert(vtree.GetTreeKeyBalance(address[:]), value))
+		// 200 chunks, the ones after the first 128 have their own stem
+		codeSize := make([]byte, 32)
+		vtree.Int256ToVerkleFormat(uint256.NewInt(200*31), codeSize)
+		require.NoError(t, tree.Insert(vtree.GetTreeKeyCodeSize(address[:]), codeSize))
+		for i, key := range vtree.GetTreeKeyCodeChunks(address[:], 200) {
+			chunk := make([]byte, 32)
+			chunk[0] = byte(i)
+			require.NoError(t, tree.Insert(key, chunk))
+		}
+		require.NoError(t, tree.Insert(vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(slot[:])), value))
+		root, err = tree.Commit()
+		require.NoError(t, err)
+		require.NoError(t, verkledb.WriteVerkleRoot(tx, 0, root))
+
+		// The storage slots are found in the plain state
+		account := accounts.Account{Incarnation: 1}
+		encoded := make([]byte, account.EncodingLengthForStorage())
+		account.EncodeForStorage(encoded)
+		require.NoError(t, tx.Put(kv.PlainState, address[:], encoded))
+		return tx.Put(kv.PlainState, dbutils.PlainGenerateCompositeStorageKey(address[:], 1, slot[:]), []byte{1})
+	}))
+
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, 0)
+	ctx := context.Background()
+
+	verkleRoot, err := api.VerkleRoot(ctx, rpc.BlockNumberOrHashWithNumber(0))
+	require.NoError(t, err)
+	require.Equal(t, root, verkleRoot)
+	_, err = api.VerkleRoot(ctx, rpc.BlockNumberOrHashWithNumber(1))
+	require.Error(t, err)
+
+	key, err := api.VerkleKey(ctx, address, (*hexutil.Big)(uint256.NewInt(0).ToBig()), vtree.BalanceLeafKey)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Bytes(vtree.GetTreeKeyBalance(address[:])), key)
+	_, err = api.VerkleKey(ctx, address, nil, 256)
+	require.Error(t, err)
+
+	rootNode, err := api.VerkleNode(ctx, root[:])
+	require.NoError(t, err)
+	require.Equal(t, "internal", rootNode.Type)
+	require.Equal(t, 3, len(rootNode.Children))
+
+	leaves, err := api.VerkleLeaves(ctx, address, rpc.BlockNumberOrHashWithNumber(0))
+	require.NoError(t, err)
+	require.Equal(t, 3, len(leaves))
+	header := leaves[0]
+	require.Equal(t, "leaf", header.Type)
+	require.Equal(t, hexutil.Bytes(vtree.GetTreeKeyVersion(address[:])[:vtree.StemLength]), header.Stem)
+	// Balance, code size and the first 128 chunks
+	require.Equal(t, 130, len(header.Values))
+	for _, leaf := range leaves[1:] {
+		if leaf.Stem.String() == hexutil.Bytes(vtree.GetTreeKeyCodeChunk(address[:], uint256.NewInt(199))[:vtree.StemLength]).String() {
+			require.Equal(t, 72, len(leaf.Values))
+		} else {
+			require.Equal(t, 1, len(leaf.Values))
+		}
+		node, err := api.VerkleNode(ctx, leaf.Commitment)
+		require.NoError(t, err)
+		require.Equal(t, leaf, node)
+	}
+}
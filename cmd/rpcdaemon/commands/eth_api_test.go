@@ -3,15 +3,23 @@ package commands
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/accounts/keystore"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
@@ -243,3 +251,51 @@ func TestCall_ByBlockHash_WithRequireCanonicalTrue_NonCanonicalBlock(t *testing.
 		t.Error("error expected")
 	}
 }
+
+func TestSendTransactionSigning(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x0d3ab14bbad3d99f4203bd7a11acb94882050e7e")
+
+	_, err := api.SendTransaction(context.Background(), ethapi.CallArgs{From: &from, To: &to})
+	require.ErrorContains(t, err, "--keystore")
+
+	dir := t.TempDir()
+	keyjson, err := keystore.EncryptKey(key, "", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key"), keyjson, 0600))
+	api.keystore, err = keystore.Open(dir, []string{""})
+	require.NoError(t, err)
+
+	unknown := common.Address{1}
+	_, err = api.SendTransaction(context.Background(), ethapi.CallArgs{From: &unknown, To: &to})
+	require.ErrorIs(t, err, keystore.ErrUnknownAccount)
+
+	// The gas and gas price are filled in, London is not active in the test chain
+	nonce := hexutil.Uint64(7)
+	txn, signer, err := api.newTransaction(context.Background(), ethapi.CallArgs{From: &from, To: &to, Value: (*hexutil.Big)(big.NewInt(1000)), Nonce: &nonce})
+	require.NoError(t, err)
+	require.Equal(t, types.LegacyTxType, int(txn.Type()))
+	require.Equal(t, params.TxGas, txn.GetGas())
+	require.Equal(t, uint64(7), txn.GetNonce())
+	require.Equal(t, uint64(1000), txn.GetValue().Uint64())
+	require.NotNil(t, txn.GetPrice())
+
+	signed, err := api.keystore.SignTx(from, txn, *signer)
+	require.NoError(t, err)
+	require.True(t, signed.Protected())
+	sender, err := signed.Sender(*signer)
+	require.NoError(t, err)
+	require.Equal(t, from, sender)
+
+	gasPrice := (*hexutil.Big)(big.NewInt(params.GWei))
+	_, _, err = api.newTransaction(context.Background(), ethapi.CallArgs{From: &from, To: &to, MaxFeePerGas: gasPrice, Nonce: &nonce})
+	require.Error(t, err)
+	_, _, err = api.newTransaction(context.Background(), ethapi.CallArgs{From: &from, To: &to, GasPrice: gasPrice, MaxFeePerGas: gasPrice, Nonce: &nonce})
+	require.Error(t, err)
+	_, _, err = api.newTransaction(context.Background(), ethapi.CallArgs{From: &from, Nonce: &nonce})
+	require.Error(t, err)
+}
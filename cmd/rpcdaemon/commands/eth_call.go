@@ -2,10 +2,12 @@ package commands
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
@@ -14,18 +16,24 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/transactions"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
 // Call implements eth_call. Executes a new message call immediately without creating a transaction on the block chain.
@@ -274,10 +282,222 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs,
 	return hexutil.Uint64(hi), nil
 }
 
-// GetProof not implemented
-func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*interface{}, error) {
-	var stub interface{}
-	return &stub, fmt.Errorf(NotImplemented, "eth_getProof")
+// GetProof implements eth_getProof. Returns the account and storage values of the specified account including the proofs.
+// While headers commit to the merkle trie, before the Martin fork and until a gradual conversion is complete, merkle proofs
+// are built from the intermediate hashes, which are only available for the latest block.
+// Then a single verkle multiproof covering all account and storage leaves is returned.
+func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*ethapi.AccountResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	blockNr, hash, latest, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]common.Hash, len(storageKeys))
+	for i, key := range storageKeys {
+		keys[i] = common.HexToHash(key)
+	}
+
+	verkleRoot, err := stagedsync.VerkleRootExpected(tx, chainConfig, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if verkleRoot {
+		return getVerkleProof(tx, blockNr, address, storageKeys, keys)
+	}
+
+	if !latest {
+		return nil, fmt.Errorf("merkle proofs are only available for the latest block")
+	}
+	ihProgress, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return nil, err
+	}
+	if ihProgress != blockNr {
+		return nil, fmt.Errorf("intermediate hashes are at block %d, proof requested for block %d", ihProgress, blockNr)
+	}
+	header, err := api._blockReader.Header(ctx, tx, hash, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("header not found: %d", blockNr)
+	}
+	return getMerkleProof(ctx, tx, header.Root, address, storageKeys, keys)
+}
+
+func getMerkleProof(ctx context.Context, tx kv.Tx, root common.Hash, address common.Address, storageKeys []string, keys []common.Hash) (*ethapi.AccountResult, error) {
+	reader := state.NewPlainStateReader(tx)
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrHash, err := common.HashData(address[:])
+	if err != nil {
+		return nil, err
+	}
+	// Loader and proof retainer need separate lists because RetainList keeps position of the last lookup
+	rl, proofRl := trie.NewRetainList(0), trie.NewRetainList(0)
+	rl.AddKey(addrHash[:])
+	proofRl.AddKey(addrHash[:])
+	keyHashes := make([]common.Hash, len(keys))
+	for i, key := range keys {
+		if keyHashes[i], err = common.HashData(key[:]); err != nil {
+			return nil, err
+		}
+		if acc != nil && acc.Incarnation > 0 {
+			storageKey := dbutils.GenerateCompositeStorageKey(addrHash, acc.Incarnation, keyHashes[i])
+			rl.AddKey(storageKey)
+			proofRl.AddKey(storageKey)
+		}
+	}
+
+	loader := trie.NewFlatDBTrieLoader("getProof")
+	if err = loader.Reset(rl, nil, nil, false); err != nil {
+		return nil, err
+	}
+	loader.SetProofRetainer(proofRl)
+	calculatedRoot, err := loader.CalcTrieRoot(tx, nil, ctx.Done())
+	if err != nil {
+		return nil, err
+	}
+	if calculatedRoot != root {
+		return nil, fmt.Errorf("state root mismatch, expected %x, calculated %x", root, calculatedRoot)
+	}
+	tr := trie.New(root)
+	if err = tr.HookSubTries(loader.Result(), [][]byte{nil}); err != nil {
+		return nil, err
+	}
+
+	accountProof, err := tr.Prove(addrHash[:], 0, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &ethapi.AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		CodeHash:     common.Hash(trie.EmptyCodeHash),
+		StorageHash:  trie.EmptyRoot,
+		StorageProof: make([]ethapi.StorageResult, len(keys)),
+	}
+	if acc != nil {
+		result.Balance = (*hexutil.Big)(acc.Balance.ToBig())
+		result.CodeHash = acc.CodeHash
+		result.Nonce = hexutil.Uint64(acc.Nonce)
+		if trieAcc, ok := tr.GetAccount(addrHash[:]); ok {
+			result.StorageHash = trieAcc.Root
+		}
+	}
+	for i, key := range keys {
+		value := new(big.Int)
+		proof := [][]byte{}
+		if acc != nil && acc.Incarnation > 0 {
+			enc, err := reader.ReadAccountStorage(address, acc.Incarnation, &key)
+			if err != nil {
+				return nil, err
+			}
+			value.SetBytes(enc)
+			if proof, err = tr.Prove(append(addrHash[:], keyHashes[i][:]...), 64, true); err != nil {
+				return nil, err
+			}
+		}
+		result.StorageProof[i] = ethapi.StorageResult{Key: storageKeys[i], Value: (*hexutil.Big)(value), Proof: toHexSlice(proof)}
+	}
+	return result, nil
+}
+
+func getVerkleProof(tx kv.Tx, blockNr uint64, address common.Address, storageKeys []string, keys []common.Hash) (*ethapi.AccountResult, error) {
+	root, err := verkledb.ReadVerkleRoot(tx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	encodedRoot, err := tx.GetOne(verkledb.VerkleTrie, root[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(encodedRoot) == 0 {
+		return nil, fmt.Errorf("verkle root %x of block %d not found", root, blockNr)
+	}
+	rootNode, err := verkle.ParseNode(encodedRoot, 0, root[:])
+	if err != nil {
+		return nil, err
+	}
+	resolver := func(key []byte) ([]byte, error) {
+		return tx.GetOne(verkledb.VerkleTrie, key)
+	}
+
+	versionKey := vtree.GetTreeKeyVersion(address[:])
+	treeKeys := make([][]byte, 0, 5+len(keys))
+	for leaf := byte(vtree.VersionLeafKey); leaf <= vtree.CodeSizeLeafKey; leaf++ {
+		leafKey := common.CopyBytes(versionKey)
+		leafKey[31] = leaf
+		treeKeys = append(treeKeys, leafKey)
+	}
+	slotKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		slotKeys[i] = vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(key[:]))
+		treeKeys = append(treeKeys, slotKeys[i])
+	}
+
+	proof, keyVals, err := vtree.MakeVerkleProof(rootNode, treeKeys, resolver)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(keyVals))
+	result := &ethapi.AccountResult{
+		Address:       address,
+		AccountProof:  []string{},
+		Balance:       (*hexutil.Big)(new(big.Int)),
+		CodeHash:      common.Hash(trie.EmptyCodeHash),
+		StorageProof:  make([]ethapi.StorageResult, len(keys)),
+		VerkleProof:   proof,
+		VerkleKeyVals: make([]ethapi.VerkleKeyVal, len(keyVals)),
+	}
+	for i, keyVal := range keyVals {
+		values[string(keyVal.Key)] = keyVal.Value
+		result.VerkleKeyVals[i] = ethapi.VerkleKeyVal{Key: keyVal.Key, Value: keyVal.Value}
+	}
+
+	if balance := values[string(treeKeys[vtree.BalanceLeafKey])]; len(balance) > 0 {
+		result.Balance = (*hexutil.Big)(verkleLeafToBig(balance))
+	}
+	if nonce := values[string(treeKeys[vtree.NonceLeafKey])]; len(nonce) >= 8 {
+		result.Nonce = hexutil.Uint64(binary.LittleEndian.Uint64(nonce))
+	}
+	if codeHash := values[string(treeKeys[vtree.CodeKeccakLeafKey])]; len(codeHash) > 0 {
+		result.CodeHash = common.BytesToHash(codeHash)
+	}
+	for i, slotKey := range slotKeys {
+		result.StorageProof[i] = ethapi.StorageResult{Key: storageKeys[i], Value: (*hexutil.Big)(verkleLeafToBig(values[string(slotKey)])), Proof: []string{}}
+	}
+	return result, nil
+}
+
+// verkleLeafToBig decodes little-endian encoded leaf value
+func verkleLeafToBig(leaf []byte) *big.Int {
+	be := make([]byte, len(leaf))
+	for i, b := range leaf {
+		be[len(leaf)-1-i] = b
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
 }
 
 // accessListResult returns an optional accesslist
@@ -7,12 +7,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -28,6 +30,10 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
 func TestEstimateGas(t *testing.T) {
@@ -378,3 +384,182 @@ func prune(t *testing.T, db kv.RwDB, pruneTo uint64) {
 	err = tx.Commit()
 	assert.NoError(t, err)
 }
+
+func TestGetProof(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+
+	tx, err := db.BeginRo(context.Background())
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	header := rawdb.ReadCurrentHeader(tx)
+	acc, err := state.NewPlainStateReader(tx).ReadAccountData(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"))
+	assert.NoError(t, err)
+
+	// find some contract with storage
+	var contract common.Address
+	var slot common.Hash
+	assert.NoError(t, tx.ForEach(kv.PlainState, nil, func(k, v []byte) error {
+		if len(k) == common.AddressLength+common.IncarnationLength+common.HashLength {
+			contract, slot = common.BytesToAddress(k[:common.AddressLength]), common.BytesToHash(k[common.AddressLength+common.IncarnationLength:])
+		}
+		return nil
+	}))
+	assert.NotEqual(t, common.Address{}, contract)
+
+	addresses := []common.Address{
+		common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), // funded account
+		contract, // contract with storage
+		common.HexToAddress("0x00000000000000000000000000000000000000ff"), // absent
+	}
+	for _, address := range addresses {
+		result, err := api.GetProof(context.Background(), address, []string{slot.Hex(), "0x1"}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+		if err != nil {
+			t.Fatalf("eth_getProof %x: %v", address, err)
+		}
+		assert.NotEmpty(t, result.AccountProof)
+		assert.Equal(t, header.Root, crypto.Keccak256Hash(hexutil.MustDecode(result.AccountProof[0])), "account proof must start with the state root")
+		assert.Equal(t, 2, len(result.StorageProof))
+		if address == contract {
+			assert.NotEqual(t, trie.EmptyRoot, result.StorageHash)
+			assert.NotEqual(t, 0, result.StorageProof[0].Value.ToInt().Sign())
+			for _, storageProof := range result.StorageProof {
+				assert.Equal(t, result.StorageHash, crypto.Keccak256Hash(hexutil.MustDecode(storageProof.Proof[0])), "storage proof must start with the storage root")
+			}
+		}
+	}
+
+	result, err := api.GetProof(context.Background(), addresses[0], nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, acc.Balance.ToBig(), result.Balance.ToInt())
+	assert.Equal(t, acc.Nonce, uint64(result.Nonce))
+
+	if _, err = api.GetProof(context.Background(), addresses[0], nil, rpc.BlockNumberOrHashWithNumber(1)); err == nil {
+		t.Errorf("merkle proof for non-latest block should fail")
+	}
+}
+
+func TestGetProofDuringVerkleConversion(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	tx, err := db.BeginRo(context.Background())
+	assert.NoError(t, err)
+	header := rawdb.ReadCurrentHeader(tx)
+	tx.Rollback()
+	address := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	withConversion := func(stride uint64) *APIImpl {
+		assert.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+			genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+			if err != nil {
+				return err
+			}
+			chainConfig, err := rawdb.ReadChainConfig(tx, genesisHash)
+			if err != nil {
+				return err
+			}
+			chainConfig.MartinBlock = new(big.Int).Sub(header.Number, big.NewInt(1))
+			chainConfig.VerkleConversionStride = stride
+			// Execution works out the conversion when it reaches the fork
+			if err = verkledb.TruncateConversionProgress(tx, 0); err != nil {
+				return err
+			}
+			if err = transition.WriteConversionSchedule(tx, stride, chainConfig.MartinBlock.Uint64()); err != nil {
+				return err
+			}
+			return rawdb.WriteChainConfig(tx, genesisHash, chainConfig)
+		}))
+		stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+		return NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	}
+
+	// The latest block is past Martin with a single account converted: its header still commits to the merkle trie
+	result, err := withConversion(1).GetProof(context.Background(), address, nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Empty(t, result.VerkleProof)
+	if assert.NotEmpty(t, result.AccountProof) {
+		assert.Equal(t, header.Root, crypto.Keccak256Hash(hexutil.MustDecode(result.AccountProof[0])))
+	}
+
+	// Once the conversion is complete, the proof is taken from the verkle tree, which this chain does not have
+	_, err = withConversion(1_000_000).GetProof(context.Background(), address, nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	assert.ErrorContains(t, err, "verkle root")
+}
+
+func TestGetVerkleProof(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	faucet := common.HexToAddress("0xfa")
+	contract := common.HexToAddress("0xc0de")
+	absent := common.HexToAddress("0xff")
+	slot := common.HexToHash("0x01")
+	genesis := core.VerkleDeveloperGenesisBlock(0, faucet)
+	genesis.Alloc = core.GenesisAlloc{
+		faucet:   {Balance: big.NewInt(1_000_000), Nonce: 5},
+		contract: {Balance: big.NewInt(1), Code: common.FromHex("0x600160005500"), Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x2a")}},
+	}
+	_, block, err := core.CommitGenesisBlock(db, genesis)
+	assert.NoError(t, err)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+
+	tx, err := db.BeginRo(context.Background())
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	root, err := verkledb.ReadVerkleRoot(tx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Root(), root)
+
+	// verify checks the multiproof of the result against the root of the block
+	verify := func(result *ethapi.AccountResult) map[string][]byte {
+		keyVals := make([]verkle.KeyValuePair, len(result.VerkleKeyVals))
+		values := make(map[string][]byte, len(keyVals))
+		for i, keyVal := range result.VerkleKeyVals {
+			keyVals[i].Key = keyVal.Key
+			if len(keyVal.Value) > 0 {
+				keyVals[i].Value = keyVal.Value
+			}
+			values[string(keyVal.Key)] = keyVals[i].Value
+		}
+		proof, err := verkle.DeserializeProof(result.VerkleProof, keyVals)
+		assert.NoError(t, err)
+		rootC := new(verkle.Point)
+		assert.NoError(t, rootC.SetBytes(root[:]))
+		tree, err := verkle.TreeFromProof(proof, rootC)
+		assert.NoError(t, err)
+		cfg, err := verkle.GetConfig()
+		assert.NoError(t, err)
+		pe, _, _ := verkle.GetCommitmentsForMultiproof(tree, proof.Keys)
+		assert.True(t, verkle.VerifyVerkleProof(proof, pe.Cis, pe.Zis, pe.Yis, cfg))
+		return values
+	}
+
+	result, err := api.GetProof(context.Background(), faucet, nil, rpc.BlockNumberOrHashWithNumber(0))
+	assert.NoError(t, err)
+	assert.Empty(t, result.AccountProof)
+	assert.Equal(t, big.NewInt(1_000_000), result.Balance.ToInt())
+	assert.Equal(t, hexutil.Uint64(5), result.Nonce)
+	values := verify(result)
+	assert.NotEmpty(t, values[string(vtree.GetTreeKeyBalance(faucet[:]))])
+
+	// Storage slots are covered by the same multiproof as the account
+	result, err = api.GetProof(context.Background(), contract, []string{slot.Hex(), "0x2"}, rpc.BlockNumberOrHashWithNumber(0))
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(common.FromHex("0x600160005500")), result.CodeHash)
+	assert.Equal(t, 2, len(result.StorageProof))
+	assert.Equal(t, big.NewInt(0x2a), result.StorageProof[0].Value.ToInt())
+	assert.Equal(t, 0, result.StorageProof[1].Value.ToInt().Sign())
+	values = verify(result)
+	assert.Contains(t, values, string(vtree.GetTreeKeyStorageSlot(contract[:], uint256.NewInt(1))))
+	assert.Contains(t, values, string(vtree.GetTreeKeyStorageSlot(contract[:], uint256.NewInt(2))))
+
+	// The proof of an absent account shows its leaves are empty
+	result, err = api.GetProof(context.Background(), absent, []string{slot.Hex()}, rpc.BlockNumberOrHashWithNumber(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Balance.ToInt().Sign())
+	assert.Equal(t, hexutil.Uint64(0), result.Nonce)
+	assert.Equal(t, common.Hash(trie.EmptyCodeHash), result.CodeHash)
+	values = verify(result)
+	assert.Equal(t, 6, len(values))
+	for key, value := range values {
+		assert.Empty(t, value, "key %x", key)
+	}
+}
@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/holiman/uint256"
 	txPoolProto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon/accounts/keystore"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -84,8 +88,150 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 }
 
 // SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
-func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
-	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
+// The transaction is signed with the key of the sender from the keystore of the daemon, then sent like eth_sendRawTransaction.
+func (api *APIImpl) SendTransaction(ctx context.Context, args ethapi.CallArgs) (common.Hash, error) {
+	if api.keystore == nil {
+		return common.Hash{}, errors.New("eth_sendTransaction needs the rpcdaemon to be started with --keystore")
+	}
+	if args.From == nil {
+		return common.Hash{}, errors.New("missing sender")
+	}
+	if !api.keystore.HasAddress(*args.From) {
+		return common.Hash{}, fmt.Errorf("%w: %x", keystore.ErrUnknownAccount, *args.From)
+	}
+	txn, signer, err := api.newTransaction(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := api.keystore.SignTx(*args.From, txn, *signer)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var buf bytes.Buffer
+	if err := signed.MarshalBinary(&buf); err != nil {
+		return common.Hash{}, err
+	}
+	return api.SendRawTransaction(ctx, buf.Bytes())
+}
+
+// newTransaction builds the unsigned transaction of eth_sendTransaction, filling in the nonce, fees and gas if they are not given.
+// Dynamic fee transactions are built once London is active, unless a gas price is given.
+func (api *APIImpl) newTransaction(ctx context.Context, args ethapi.CallArgs) (types.Transaction, *types.Signer, error) {
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return nil, nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	var input []byte
+	if args.Data != nil {
+		input = *args.Data
+	}
+	if args.To == nil && len(input) == 0 {
+		return nil, nil, errors.New("contract creation without any data provided")
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if args.ChainID != nil && args.ChainID.ToInt().Cmp(cc.ChainID) != 0 {
+		return nil, nil, fmt.Errorf("invalid chain id, expected: %d got: %d", cc.ChainID, args.ChainID.ToInt())
+	}
+	head := rawdb.ReadCurrentHeader(tx)
+	if head == nil {
+		return nil, nil, errors.New("current header not found")
+	}
+	tx.Rollback()
+
+	if args.Value == nil {
+		args.Value = new(hexutil.Big)
+	}
+	if args.Nonce == nil {
+		if args.Nonce, err = api.GetTransactionCount(ctx, *args.From, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)); err != nil {
+			return nil, nil, err
+		}
+	}
+	london := args.GasPrice == nil && cc.IsLondon(head.Number.Uint64()+1)
+	if !london && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return nil, nil, errors.New("maxFeePerGas and maxPriorityFeePerGas are not supported before London")
+	}
+	if london {
+		if args.MaxPriorityFeePerGas == nil {
+			if args.MaxPriorityFeePerGas, err = api.MaxPriorityFeePerGas(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+		if args.MaxFeePerGas == nil {
+			// Leave room for the base fee to double
+			baseFee := head.BaseFee
+			if baseFee == nil {
+				baseFee = big.NewInt(params.InitialBaseFee)
+			}
+			feeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+			args.MaxFeePerGas = (*hexutil.Big)(feeCap.Add(feeCap, args.MaxPriorityFeePerGas.ToInt()))
+		}
+		if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+			return nil, nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+		}
+	} else if args.GasPrice == nil {
+		if args.GasPrice, err = api.GasPrice(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	if args.Gas == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		gas, err := api.EstimateGas(ctx, &args, &latest)
+		if err != nil {
+			return nil, nil, err
+		}
+		args.Gas = &gas
+	}
+
+	chainID, _ := uint256.FromBig(cc.ChainID)
+	value, overflow := uint256.FromBig(args.Value.ToInt())
+	if overflow {
+		return nil, nil, errors.New("value higher than 2^256-1")
+	}
+	commonTx := types.CommonTx{
+		Nonce: uint64(*args.Nonce),
+		Gas:   uint64(*args.Gas),
+		To:    args.To,
+		Value: value,
+		Data:  input,
+	}
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	var txn types.Transaction
+	switch {
+	case london:
+		tip, overflow := uint256.FromBig(args.MaxPriorityFeePerGas.ToInt())
+		if overflow {
+			return nil, nil, errors.New("maxPriorityFeePerGas higher than 2^256-1")
+		}
+		feeCap, overflow := uint256.FromBig(args.MaxFeePerGas.ToInt())
+		if overflow {
+			return nil, nil, errors.New("maxFeePerGas higher than 2^256-1")
+		}
+		commonTx.ChainID = chainID
+		txn = &types.DynamicFeeTransaction{CommonTx: commonTx, Tip: tip, FeeCap: feeCap, AccessList: accessList}
+	default:
+		gasPrice, overflow := uint256.FromBig(args.GasPrice.ToInt())
+		if overflow {
+			return nil, nil, errors.New("gasPrice higher than 2^256-1")
+		}
+		legacy := types.LegacyTx{CommonTx: commonTx, GasPrice: gasPrice}
+		if args.AccessList == nil {
+			txn = &legacy
+		} else {
+			txn = &types.AccessListTx{LegacyTx: legacy, ChainID: chainID, AccessList: accessList}
+		}
+	}
+	return txn, types.LatestSigner(cc), nil
 }
 
 // checkTxFee is an internal function used to check whether the fee of
@@ -17,10 +17,12 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	math2 "github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/shards"
@@ -1219,8 +1221,62 @@ func (api *TraceAPIImpl) doCallMany(ctx context.Context, dbtx kv.Tx, msgs []type
 	return results, nil
 }
 
-// RawTransaction implements trace_rawTransaction.
-func (api *TraceAPIImpl) RawTransaction(ctx context.Context, txHash common.Hash, traceTypes []string) ([]interface{}, error) {
-	var stub []interface{}
-	return stub, fmt.Errorf(NotImplemented, "trace_rawTransaction")
+// RawTransaction implements trace_rawTransaction. Traces the signed transaction on top of the latest block, like trace_call.
+// The nonce of the transaction has to be the next one of the sender, the message passed to trace_call carries no nonce.
+func (api *TraceAPIImpl) RawTransaction(ctx context.Context, encodedTx hexutil.Bytes, traceTypes []string) (*TraceCallResult, error) {
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.kv.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	blockNum := rawdb.ReadCurrentBlockNumber(tx)
+	if blockNum == nil {
+		return nil, fmt.Errorf("current block number not found")
+	}
+
+	signer := types.MakeSigner(chainConfig, *blockNum)
+	from, err := txn.Sender(*signer)
+	if err != nil {
+		return nil, err
+	}
+	account, err := state.NewPlainStateReader(tx).ReadAccountData(from)
+	if err != nil {
+		return nil, err
+	}
+	tx.Rollback()
+	var stateNonce uint64
+	if account != nil {
+		stateNonce = account.Nonce
+	}
+	if stateNonce < txn.GetNonce() {
+		return nil, fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooHigh, from.Hex(), txn.GetNonce(), stateNonce)
+	} else if stateNonce > txn.GetNonce() {
+		return nil, fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooLow, from.Hex(), txn.GetNonce(), stateNonce)
+	}
+	gas := hexutil.Uint64(txn.GetGas())
+	accessList := txn.GetAccessList()
+	args := TraceCallParam{
+		From:       &from,
+		To:         txn.GetTo(),
+		Gas:        &gas,
+		Value:      (*hexutil.Big)(txn.GetValue().ToBig()),
+		Data:       txn.GetData(),
+		AccessList: &accessList,
+	}
+	if txn.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(txn.GetFeeCap().ToBig())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(txn.GetTip().ToBig())
+	} else {
+		args.GasPrice = (*hexutil.Big)(txn.GetPrice().ToBig())
+	}
+	return api.Call(ctx, args, traceTypes, nil)
 }
@@ -1,17 +1,24 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/stretchr/testify/require"
@@ -106,3 +113,45 @@ func TestReplayBlockTransactions(t *testing.T) {
 	v := addrDiff.Balance.(map[string]*hexutil.Big)["+"].ToInt().Uint64()
 	require.Equal(t, uint64(1_000_000_000_000_000), v)
 }
+
+func TestRawTransaction(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewTraceAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, &httpcfg.HttpCfg{})
+
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	to := common.HexToAddress("0x0000000000000000000000000000000000000bbb")
+	var nonce uint64
+	require.NoError(t, db.View(context.Background(), func(tx kv.Tx) error {
+		account, err := state.NewPlainStateReader(tx).ReadAccountData(crypto.PubkeyToAddress(key.PublicKey))
+		if account != nil {
+			nonce = account.Nonce
+		}
+		return err
+	}))
+	require.Greater(t, nonce, uint64(0))
+	encode := func(nonce uint64) hexutil.Bytes {
+		txn, err := types.SignTx(types.NewTransaction(nonce, to, uint256.NewInt(1000), params.TxGas, uint256.NewInt(10*params.GWei), nil), *types.LatestSigner(params.AllEthashProtocolChanges), key)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, txn.MarshalBinary(&buf))
+		return buf.Bytes()
+	}
+
+	results, err := api.RawTransaction(context.Background(), encode(nonce), []string{"trace", "stateDiff"})
+	require.NoError(t, err)
+	require.Len(t, results.Trace, 1)
+	require.Equal(t, CALL, results.Trace[0].Type)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), results.Trace[0].Action.(*CallTraceAction).From)
+	v := results.StateDiff[to].Balance.(map[string]*hexutil.Big)["+"].ToInt().Uint64()
+	require.Equal(t, uint64(1000), v)
+
+	// The nonce has to be the next one of the sender
+	_, err = api.RawTransaction(context.Background(), encode(nonce-1), []string{"trace"})
+	require.ErrorIs(t, err, core.ErrNonceTooLow)
+	_, err = api.RawTransaction(context.Background(), encode(nonce+1), []string{"trace"})
+	require.ErrorIs(t, err, core.ErrNonceTooHigh)
+
+	_, err = api.RawTransaction(context.Background(), hexutil.Bytes{0x01, 0x02}, []string{"trace"})
+	require.Error(t, err)
+}
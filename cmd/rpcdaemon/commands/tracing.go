@@ -36,6 +36,11 @@ func (api *PrivateDebugAPIImpl) TraceBlockByHash(ctx context.Context, hash commo
 }
 
 func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig, stream *jsoniter.Stream) error {
+	// The errors of the per-transaction traces are not reported, so check the tracer up front
+	if err := transactions.ValidateTraceConfig(config); err != nil {
+		stream.WriteNil()
+		return err
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		stream.WriteNil()
@@ -260,6 +265,10 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 		baseFee            uint256.Int
 	)
 
+	if err := transactions.ValidateTraceConfig(config); err != nil {
+		stream.WriteNil()
+		return err
+	}
 	overrideBlockHash = make(map[uint64]common.Hash)
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
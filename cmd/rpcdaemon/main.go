@@ -27,7 +27,7 @@ func main() {
 		}
 
 		apiList := commands.APIList(db, borDb, backend, txPool, mining, ff, stateCache, blockReader, agg, txNums, *cfg)
-		if err := cli.StartRpcServer(ctx, *cfg, apiList, nil); err != nil {
+		if err := cli.StartRpcServer(ctx, *cfg, db, apiList, nil); err != nil {
 			log.Error(err.Error())
 			return nil
 		}
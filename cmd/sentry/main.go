@@ -85,7 +85,6 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-
 		return sentry.Sentry(cmd.Context(), dirs, sentryAddr, discoveryDNS, p2pConfig, uint(protocol), healthCheck)
 	},
 }
@@ -2,13 +2,16 @@ package sentry
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"sync"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/stages/bodydownload"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
@@ -127,6 +130,91 @@ func (cs *MultiClient) SendHeaderRequest(ctx context.Context, req *headerdownloa
 	return [64]byte{}, false
 }
 
+// FetchVerkleWitness sends the query to a peer with the wit capability and waits for its answer. Only the sentry
+// running in the same process carries wit messages, and only sends them to such peers. The witness is not checked, it's up to the caller to verify the proofs and to
+// penalize the peer: a stateless client reads the stems through state.NewStatelessVerkleReader at the root of the
+// query, and the state of a block through the one of its witness at the root of its parent.
+func (cs *MultiClient) FetchVerkleWitness(ctx context.Context, query *wit.GetVerkleWitnessPacket) (*wit.VerkleWitnessPacket, [64]byte, error) {
+	request := *query
+	request.RequestId = rand.Uint64() // nolint: gosec
+	bytes, err := rlp.EncodeToBytes(&request)
+	if err != nil {
+		return nil, [64]byte{}, fmt.Errorf("encode verkle witness request: %w", err)
+	}
+	replies := cs.witnesses.add(request.RequestId)
+	defer cs.witnesses.remove(request.RequestId)
+
+	var sent bool
+	for i, ok, next := cs.randSentryIndex(); ok && !sent; i, ok = next() {
+		// Standalone sentries do not run the wit capability
+		if _, embedded := cs.sentries[i].(*SentryClientDirect); !embedded || !cs.sentries[i].Ready() {
+			continue
+		}
+		outreq := proto_sentry.SendMessageByMinBlockRequest{
+			Data: &proto_sentry.OutboundMessageData{
+				Id:   wit.MessageId_GET_VERKLE_WITNESS_1,
+				Data: bytes,
+			},
+		}
+		sentPeers, err := cs.sentries[i].SendMessageByMinBlock(ctx, &outreq, &grpc.EmptyCallOption{})
+		if err != nil {
+			return nil, [64]byte{}, fmt.Errorf("send verkle witness request: %w", err)
+		}
+		sent = sentPeers != nil && len(sentPeers.Peers) > 0
+	}
+	if !sent {
+		return nil, [64]byte{}, fmt.Errorf("no peers to request verkle witness from")
+	}
+	select {
+	case reply := <-replies:
+		return reply.witness, reply.peerID, nil
+	case <-ctx.Done():
+		return nil, [64]byte{}, ctx.Err()
+	}
+}
+
+type witnessReply struct {
+	peerID  [64]byte
+	witness *wit.VerkleWitnessPacket
+}
+
+// witnessRequests - verkle witness requests waiting for an answer, by request id
+type witnessRequests struct {
+	lock    sync.Mutex
+	pending map[uint64]chan witnessReply
+}
+
+func newWitnessRequests() *witnessRequests {
+	return &witnessRequests{pending: map[uint64]chan witnessReply{}}
+}
+
+func (w *witnessRequests) add(requestID uint64) <-chan witnessReply {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	replies := make(chan witnessReply, 1)
+	w.pending[requestID] = replies
+	return replies
+}
+
+func (w *witnessRequests) remove(requestID uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.pending, requestID)
+}
+
+// deliver hands the witness over to the request, only the first answer is kept
+func (w *witnessRequests) deliver(requestID uint64, peerID [64]byte, witness *wit.VerkleWitnessPacket) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	replies, ok := w.pending[requestID]
+	if !ok {
+		return false
+	}
+	delete(w.pending, requestID)
+	replies <- witnessReply{peerID: peerID, witness: witness}
+	return true
+}
+
 func (cs *MultiClient) randSentryIndex() (int, bool, func() (int, bool)) {
 	var i int
 	if len(cs.sentries) > 1 {
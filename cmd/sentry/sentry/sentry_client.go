@@ -0,0 +1,136 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/ledgerwatch/erigon-lib/direct"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// The sentry clients of erigon-lib only subscribe to the message ids of the eth protocol. The client of the sentry
+// running in the same process subscribes to the messages of the wit capability too, which only verkle networks run.
+// The wit ids are not a part of the sentry interface of erigon-lib, so they never go over gRPC: the client of a
+// standalone sentry leaves them out of its subscriptions and refuses to send them.
+
+var errWitnessRemoteSentry = errors.New("wit messages are only exchanged with the sentry running in the same process")
+
+// SentryClientRemote is the client of a standalone sentry
+type SentryClientRemote struct {
+	*direct.SentryClientRemote
+}
+
+var _ direct.SentryClient = (*SentryClientRemote)(nil) // compile-time interface check
+var _ direct.SentryClient = (*SentryClientDirect)(nil) // compile-time interface check
+
+func NewSentryClientRemote(client proto_sentry.SentryClient) *SentryClientRemote {
+	return &SentryClientRemote{SentryClientRemote: direct.NewSentryClientRemote(client)}
+}
+
+func (c *SentryClientRemote) Messages(ctx context.Context, in *proto_sentry.MessagesRequest, opts ...grpc.CallOption) (proto_sentry.Sentry_MessagesClient, error) {
+	in.Ids = filterIds(in.Ids, c.Protocol(), false)
+	return c.SentryClientRemote.SentryClient.Messages(ctx, in, opts...)
+}
+
+func (c *SentryClientRemote) SendMessageByMinBlock(ctx context.Context, in *proto_sentry.SendMessageByMinBlockRequest, opts ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	if _, isWit := wit.FromProto[in.Data.Id]; isWit {
+		return nil, errWitnessRemoteSentry
+	}
+	return c.SentryClientRemote.SendMessageByMinBlock(ctx, in, opts...)
+}
+
+func (c *SentryClientRemote) SendMessageById(ctx context.Context, in *proto_sentry.SendMessageByIdRequest, opts ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	if _, isWit := wit.FromProto[in.Data.Id]; isWit {
+		return nil, errWitnessRemoteSentry
+	}
+	return c.SentryClientRemote.SendMessageById(ctx, in, opts...)
+}
+
+// SentryClientDirect is the client of the sentry running in the same process
+type SentryClientDirect struct {
+	*direct.SentryClientDirect
+	server proto_sentry.SentryServer
+}
+
+func NewSentryClientDirect(protocol uint, server proto_sentry.SentryServer) *SentryClientDirect {
+	return &SentryClientDirect{SentryClientDirect: direct.NewSentryClientDirect(protocol, server), server: server}
+}
+
+func (c *SentryClientDirect) Messages(ctx context.Context, in *proto_sentry.MessagesRequest, opts ...grpc.CallOption) (proto_sentry.Sentry_MessagesClient, error) {
+	in.Ids = filterIds(in.Ids, c.Protocol(), true)
+	ch := make(chan *inboundMessageReply, 16384)
+	streamServer := &messagesStreamS{ch: ch, ctx: ctx}
+	go func() {
+		defer close(ch)
+		streamServer.Err(c.server.Messages(in, streamServer))
+	}()
+	return &messagesStreamC{ch: ch, ctx: ctx}, nil
+}
+
+func filterIds(in []proto_sentry.MessageId, protocol uint, withWitness bool) (filtered []proto_sentry.MessageId) {
+	for _, id := range in {
+		_, isEth := direct.ProtoIds[protocol][id]
+		_, isWit := wit.FromProto[id]
+		if isEth || (isWit && withWitness) {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
+
+type inboundMessageReply struct {
+	r   *proto_sentry.InboundMessage
+	err error
+}
+
+// messagesStreamS implements proto_sentry.Sentry_MessagesServer
+type messagesStreamS struct {
+	ch  chan *inboundMessageReply
+	ctx context.Context
+	grpc.ServerStream
+}
+
+func (s *messagesStreamS) Send(m *proto_sentry.InboundMessage) error {
+	s.ch <- &inboundMessageReply{r: m}
+	return nil
+}
+
+func (s *messagesStreamS) Context() context.Context { return s.ctx }
+
+func (s *messagesStreamS) Err(err error) {
+	if err == nil {
+		return
+	}
+	s.ch <- &inboundMessageReply{err: err}
+}
+
+// messagesStreamC implements proto_sentry.Sentry_MessagesClient
+type messagesStreamC struct {
+	ch  chan *inboundMessageReply
+	ctx context.Context
+	grpc.ClientStream
+}
+
+func (c *messagesStreamC) Recv() (*proto_sentry.InboundMessage, error) {
+	m, ok := <-c.ch
+	if !ok || m == nil {
+		return nil, io.EOF
+	}
+	return m.r, m.err
+}
+
+func (c *messagesStreamC) Context() context.Context { return c.ctx }
+
+func (c *messagesStreamC) RecvMsg(anyMessage interface{}) error {
+	m, err := c.Recv()
+	if err != nil {
+		return err
+	}
+	outMessage := anyMessage.(*proto_sentry.InboundMessage)
+	proto.Merge(outMessage, m)
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
 	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
@@ -195,7 +196,7 @@ func ConvertH512ToPeerID(h512 *proto_types.H512) [64]byte {
 func makeP2PServer(
 	p2pConfig p2p.Config,
 	genesisHash common.Hash,
-	protocol p2p.Protocol,
+	protocols []p2p.Protocol,
 ) (*p2p.Server, error) {
 	var urls []string
 	chainConfig := params.ChainConfigByGenesisHash(genesisHash)
@@ -210,7 +211,7 @@ func makeP2PServer(
 		p2pConfig.BootstrapNodes = bootstrapNodes
 		p2pConfig.BootstrapNodesV5 = bootstrapNodes
 	}
-	p2pConfig.Protocols = []p2p.Protocol{protocol}
+	p2pConfig.Protocols = protocols
 	return &p2p.Server{Config: p2pConfig}, nil
 }
 
@@ -555,10 +556,60 @@ func NewGrpcServer(ctx context.Context, dialCandidates enode.Iterator, readNodeI
 		},
 		//Attributes: []enr.Entry{eth.CurrentENREntry(chainConfig, genesisHash, headHeight)},
 	}
+	if cfg.VerkleWitness {
+		ss.witnessProtocol = &p2p.Protocol{
+			Name:    wit.ProtocolName,
+			Version: wit.WIT1,
+			Length:  wit.ProtocolLength,
+			Run:     ss.runWitnessPeer,
+		}
+	}
 
 	return ss
 }
 
+var errNoWitnessCapability = errors.New("sentry does not run the wit capability")
+
+// runWitnessPeer passes the wit messages of the peer on to the clients, once it has passed the eth handshake
+func (ss *GrpcServer) runWitnessPeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peerID := peer.Pubkey()
+	ss.witnessPeers.Store(peerID, rw)
+	defer ss.witnessPeers.Delete(peerID)
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+		if msg.Size > wit.ProtocolMaxMsgSize {
+			msg.Discard()
+			return fmt.Errorf("message is too large %d, limit %d", msg.Size, wit.ProtocolMaxMsgSize)
+		}
+		msgID, ok := wit.ToProto[msg.Code]
+		if !ok {
+			msg.Discard()
+			return fmt.Errorf("unknown wit message code: %d", msg.Code)
+		}
+		if ss.getPeer(peerID) == nil || !ss.hasSubscribers(msgID) {
+			msg.Discard()
+			continue
+		}
+		b := make([]byte, msg.Size)
+		if _, err := io.ReadFull(msg.Payload, b); err != nil {
+			log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
+		}
+		ss.send(msgID, peerID, b)
+		msg.Discard()
+	}
+}
+
+// witnessPeer returns the wit capability of the peer, nil if the peer does not run it
+func (ss *GrpcServer) witnessPeer(peerID [64]byte) p2p.MsgReadWriter {
+	if value, ok := ss.witnessPeers.Load(peerID); ok {
+		return value.(p2p.MsgReadWriter)
+	}
+	return nil
+}
+
 // Sentry creates and runs standalone sentry
 func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discoveryDNS []string, cfg *p2p.Config, protocolVersion uint, healthCheck bool) error {
 	dir.MustExist(dirs.DataDir)
@@ -580,6 +631,8 @@ type GrpcServer struct {
 	proto_sentry.UnimplementedSentryServer
 	ctx                  context.Context
 	Protocol             p2p.Protocol
+	witnessProtocol      *p2p.Protocol // the wit capability, only run by verkle networks
+	witnessPeers         sync.Map      // p2p.MsgReadWriter of the wit capability, by peer id
 	discoveryDNS         []string
 	GoodPeers            sync.Map
 	statusData           *proto_sentry.StatusData
@@ -624,8 +677,13 @@ func (ss *GrpcServer) removePeer(peerID [64]byte) {
 }
 
 func (ss *GrpcServer) writePeer(logPrefix string, peerInfo *PeerInfo, msgcode uint64, data []byte, ttl time.Duration) {
+	ss.writePeerCapability(logPrefix, peerInfo, peerInfo.rw, msgcode, data, ttl)
+}
+
+// writePeerCapability writes the message to the peer over the capability rw, which is either eth or wit
+func (ss *GrpcServer) writePeerCapability(logPrefix string, peerInfo *PeerInfo, rw p2p.MsgReadWriter, msgcode uint64, data []byte, ttl time.Duration) {
 	peerInfo.Async(func() {
-		err := peerInfo.rw.WriteMsg(p2p.Msg{Code: msgcode, Size: uint32(len(data)), Payload: bytes.NewReader(data)})
+		err := rw.WriteMsg(p2p.Msg{Code: msgcode, Size: uint32(len(data)), Payload: bytes.NewReader(data)})
 		if err != nil {
 			peerInfo.Remove()
 			ss.GoodPeers.Delete(peerInfo.ID())
@@ -683,12 +741,15 @@ func (ss *GrpcServer) PeerMinBlock(_ context.Context, req *proto_sentry.PeerMinB
 	return &emptypb.Empty{}, nil
 }
 
-func (ss *GrpcServer) findPeer(minBlock uint64) (*PeerInfo, bool) {
+func (ss *GrpcServer) findPeer(minBlock uint64, witness bool) (*PeerInfo, bool) {
 	// Choose a peer that we can send this request to, with maximum number of permits
 	var foundPeerInfo *PeerInfo
 	var maxPermits int
 	now := time.Now()
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
+		if witness && ss.witnessPeer(peerInfo.ID()) == nil {
+			return true
+		}
 		if peerInfo.Height() >= minBlock {
 			deadlines := peerInfo.ClearDeadlines(now, false /* givePermit */)
 			//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
@@ -706,6 +767,9 @@ func (ss *GrpcServer) findPeer(minBlock uint64) (*PeerInfo, bool) {
 }
 
 func (ss *GrpcServer) SendMessageByMinBlock(_ context.Context, inreq *proto_sentry.SendMessageByMinBlockRequest) (*proto_sentry.SentPeers, error) {
+	if inreq.Data.Id == wit.MessageId_GET_VERKLE_WITNESS_1 {
+		return ss.sendWitnessByMinBlock(inreq)
+	}
 	reply := &proto_sentry.SentPeers{}
 	msgcode := eth.FromProto[ss.Protocol.Version][inreq.Data.Id]
 	if msgcode != eth.GetBlockHeadersMsg &&
@@ -714,7 +778,7 @@ func (ss *GrpcServer) SendMessageByMinBlock(_ context.Context, inreq *proto_sent
 		return reply, fmt.Errorf("sendMessageByMinBlock not implemented for message Id: %s", inreq.Data.Id)
 	}
 
-	peerInfo, found := ss.findPeer(inreq.MinBlock)
+	peerInfo, found := ss.findPeer(inreq.MinBlock, false)
 	if found {
 		ss.writePeer("sendMessageByMinBlock", peerInfo, msgcode, inreq.Data.Data, 30*time.Second)
 		reply.Peers = []*proto_types.H512{gointerfaces.ConvertHashToH512(peerInfo.ID())}
@@ -732,7 +796,38 @@ func (ss *GrpcServer) SendMessageByMinBlock(_ context.Context, inreq *proto_sent
 	return reply, nil
 }
 
+// sendWitnessByMinBlock sends the wit request to a peer with the capability, if none has the block, to any two of them
+func (ss *GrpcServer) sendWitnessByMinBlock(inreq *proto_sentry.SendMessageByMinBlockRequest) (*proto_sentry.SentPeers, error) {
+	reply := &proto_sentry.SentPeers{}
+	if ss.witnessProtocol == nil {
+		return reply, errNoWitnessCapability
+	}
+	msgcode := wit.FromProto[inreq.Data.Id]
+	peerInfo, found := ss.findPeer(inreq.MinBlock, true)
+	if found {
+		ss.writePeerCapability("sendMessageByMinBlock", peerInfo, ss.witnessPeer(peerInfo.ID()), msgcode, inreq.Data.Data, 30*time.Second)
+		reply.Peers = []*proto_types.H512{gointerfaces.ConvertHashToH512(peerInfo.ID())}
+		return reply, nil
+	}
+	i := 0
+	sendToAmount := 2
+	ss.rangePeers(func(peerInfo *PeerInfo) bool {
+		rw := ss.witnessPeer(peerInfo.ID())
+		if rw == nil {
+			return true
+		}
+		ss.writePeerCapability("sendMessageByMinBlock", peerInfo, rw, msgcode, inreq.Data.Data, 0)
+		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(peerInfo.ID()))
+		i++
+		return i < sendToAmount
+	})
+	return reply, nil
+}
+
 func (ss *GrpcServer) SendMessageById(_ context.Context, inreq *proto_sentry.SendMessageByIdRequest) (*proto_sentry.SentPeers, error) {
+	if inreq.Data.Id == wit.MessageId_VERKLE_WITNESS_1 {
+		return ss.sendWitnessById(inreq)
+	}
 	reply := &proto_sentry.SentPeers{}
 	msgcode := eth.FromProto[ss.Protocol.Version][inreq.Data.Id]
 	if msgcode != eth.GetBlockHeadersMsg &&
@@ -759,6 +854,23 @@ func (ss *GrpcServer) SendMessageById(_ context.Context, inreq *proto_sentry.Sen
 	return reply, nil
 }
 
+// sendWitnessById answers a wit request of the peer, which is only possible if the peer runs the capability
+func (ss *GrpcServer) sendWitnessById(inreq *proto_sentry.SendMessageByIdRequest) (*proto_sentry.SentPeers, error) {
+	reply := &proto_sentry.SentPeers{}
+	if ss.witnessProtocol == nil {
+		return reply, errNoWitnessCapability
+	}
+	peerID := ConvertH512ToPeerID(inreq.PeerId)
+	peerInfo := ss.getPeer(peerID)
+	rw := ss.witnessPeer(peerID)
+	if peerInfo == nil || rw == nil {
+		return reply, nil
+	}
+	ss.writePeerCapability("sendMessageById", peerInfo, rw, wit.FromProto[inreq.Data.Id], inreq.Data.Data, 0)
+	reply.Peers = []*proto_types.H512{inreq.PeerId}
+	return reply, nil
+}
+
 func (ss *GrpcServer) SendMessageToRandomPeers(ctx context.Context, req *proto_sentry.SendMessageToRandomPeersRequest) (*proto_sentry.SentPeers, error) {
 	reply := &proto_sentry.SentPeers{}
 
@@ -843,7 +955,11 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 			}
 		}
 
-		srv, err := makeP2PServer(*ss.p2p, genesisHash, ss.Protocol)
+		protocols := []p2p.Protocol{ss.Protocol}
+		if ss.witnessProtocol != nil {
+			protocols = append(protocols, *ss.witnessProtocol)
+		}
+		srv, err := makeP2PServer(*ss.p2p, genesisHash, protocols)
 		if err != nil {
 			return reply, err
 		}
@@ -9,6 +9,7 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	proto_types "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon/common"
@@ -16,7 +17,9 @@ import (
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/stretchr/testify/require"
 )
@@ -176,3 +179,58 @@ func TestSentryServerImpl_SetStatusInitPanic(t *testing.T) {
 		t.Fatalf("error expected")
 	}
 }
+
+// Tests that wit messages only go to the peers running the wit capability.
+func TestSendWitnessToCapablePeers(t *testing.T) {
+	ctx := context.Background()
+	ss := &GrpcServer{ctx: ctx, witnessProtocol: &p2p.Protocol{Name: wit.ProtocolName, Version: wit.WIT1}}
+
+	plainRw, _ := p2p.MsgPipe()
+	capableRw, _ := p2p.MsgPipe()
+	witRw, witRemote := p2p.MsgPipe()
+	defer witRw.Close()
+	plain := NewPeerInfo(p2p.NewPeer(enode.ID{1}, [64]byte{1}, "plain", nil), plainRw)
+	defer plain.Close()
+	capable := NewPeerInfo(p2p.NewPeer(enode.ID{2}, [64]byte{2}, "capable", []p2p.Cap{{Name: wit.ProtocolName, Version: wit.WIT1}}), capableRw)
+	defer capable.Close()
+	ss.GoodPeers.Store(plain.ID(), plain)
+	ss.GoodPeers.Store(capable.ID(), capable)
+	ss.witnessPeers.Store(capable.ID(), witRw)
+
+	for i := 0; i < 4; i++ {
+		sent, err := ss.SendMessageByMinBlock(ctx, &proto_sentry.SendMessageByMinBlockRequest{
+			Data: &proto_sentry.OutboundMessageData{Id: wit.MessageId_GET_VERKLE_WITNESS_1, Data: []byte{0xc0}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*proto_types.H512{gointerfaces.ConvertHashToH512(capable.ID())}, sent.Peers)
+		require.NoError(t, p2p.ExpectMsg(witRemote, wit.GetVerkleWitnessMsg, nil))
+	}
+
+	sent, err := ss.SendMessageById(ctx, &proto_sentry.SendMessageByIdRequest{
+		PeerId: gointerfaces.ConvertHashToH512(plain.ID()),
+		Data:   &proto_sentry.OutboundMessageData{Id: wit.MessageId_VERKLE_WITNESS_1, Data: []byte{0xc0}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, sent.Peers)
+	sent, err = ss.SendMessageById(ctx, &proto_sentry.SendMessageByIdRequest{
+		PeerId: gointerfaces.ConvertHashToH512(capable.ID()),
+		Data:   &proto_sentry.OutboundMessageData{Id: wit.MessageId_VERKLE_WITNESS_1, Data: []byte{0xc0}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(sent.Peers))
+	require.NoError(t, p2p.ExpectMsg(witRemote, wit.VerkleWitnessMsg, nil))
+
+	// Standalone sentries do not run the capability, and wit ids never go over gRPC
+	_, err = (&GrpcServer{ctx: ctx}).SendMessageById(ctx, &proto_sentry.SendMessageByIdRequest{
+		PeerId: gointerfaces.ConvertHashToH512(capable.ID()),
+		Data:   &proto_sentry.OutboundMessageData{Id: wit.MessageId_VERKLE_WITNESS_1, Data: []byte{0xc0}},
+	})
+	require.ErrorIs(t, err, errNoWitnessCapability)
+	_, err = NewSentryClientRemote(nil).SendMessageByMinBlock(ctx, &proto_sentry.SendMessageByMinBlockRequest{
+		Data: &proto_sentry.OutboundMessageData{Id: wit.MessageId_GET_VERKLE_WITNESS_1, Data: []byte{0xc0}},
+	})
+	require.ErrorIs(t, err, errWitnessRemoteSentry)
+	ids := []proto_sentry.MessageId{eth.ToProto[eth.ETH66][eth.BlockHeadersMsg], wit.MessageId_VERKLE_WITNESS_1}
+	require.Equal(t, ids[:1], filterIds(ids, eth.ETH66, false))
+	require.Equal(t, ids, filterIds(ids, eth.ETH66, true))
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
@@ -70,6 +71,7 @@ func (cs *MultiClient) RecvUploadMessageLoop(
 	ids := []proto_sentry.MessageId{
 		eth.ToProto[eth.ETH66][eth.GetBlockBodiesMsg],
 		eth.ToProto[eth.ETH66][eth.GetReceiptsMsg],
+		wit.ToProto[wit.GetVerkleWitnessMsg],
 	}
 	streamFactory := func(streamCtx context.Context, sentry direct.SentryClient) (sentryMessageStream, error) {
 		return sentry.Messages(streamCtx, &proto_sentry.MessagesRequest{Ids: ids}, grpc.WaitForReady(true))
@@ -103,6 +105,7 @@ func (cs *MultiClient) RecvMessageLoop(
 		eth.ToProto[eth.ETH66][eth.BlockBodiesMsg],
 		eth.ToProto[eth.ETH66][eth.NewBlockHashesMsg],
 		eth.ToProto[eth.ETH66][eth.NewBlockMsg],
+		wit.ToProto[wit.VerkleWitnessMsg],
 	}
 	streamFactory := func(streamCtx context.Context, sentry direct.SentryClient) (sentryMessageStream, error) {
 		return sentry.Messages(streamCtx, &proto_sentry.MessagesRequest{Ids: ids}, grpc.WaitForReady(true))
@@ -262,6 +265,7 @@ type MultiClient struct {
 	Engine        consensus.Engine
 	blockReader   services.HeaderAndCanonicalReader
 	logPeerInfo   bool
+	witnesses     *witnessRequests
 
 	historyV2 bool
 }
@@ -303,6 +307,7 @@ func NewMultiClient(
 		blockReader:   blockReader,
 		logPeerInfo:   logPeerInfo,
 		forkValidator: forkValidator,
+		witnesses:     newWitnessRequests(),
 		historyV2:     historyV2,
 	}
 	cs.ChainConfig = chainConfig
@@ -662,6 +667,53 @@ func (cs *MultiClient) getReceipts66(ctx context.Context, inreq *proto_sentry.In
 	return nil
 }
 
+func (cs *MultiClient) getVerkleWitness(ctx context.Context, inreq *proto_sentry.InboundMessage, sentry direct.SentryClient) error {
+	var query wit.GetVerkleWitnessPacket
+	if err := rlp.DecodeBytes(inreq.Data, &query); err != nil {
+		return fmt.Errorf("decoding getVerkleWitness: %w, data: %x", err, inreq.Data)
+	}
+	tx, err := cs.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	response, err := wit.AnswerGetVerkleWitnessQuery(tx, &query)
+	if err != nil {
+		return err
+	}
+	tx.Rollback()
+	b, err := rlp.EncodeToBytes(response)
+	if err != nil {
+		return fmt.Errorf("encode verkle witness response: %w", err)
+	}
+	outreq := proto_sentry.SendMessageByIdRequest{
+		PeerId: inreq.PeerId,
+		Data: &proto_sentry.OutboundMessageData{
+			Id:   wit.MessageId_VERKLE_WITNESS_1,
+			Data: b,
+		},
+	}
+	_, err = sentry.SendMessageById(ctx, &outreq, &grpc.EmptyCallOption{})
+	if err != nil {
+		if isPeerNotFoundErr(err) {
+			return nil
+		}
+		return fmt.Errorf("send verkle witness response: %w", err)
+	}
+	return nil
+}
+
+func (cs *MultiClient) verkleWitness(_ context.Context, inreq *proto_sentry.InboundMessage, _ direct.SentryClient) error {
+	var response wit.VerkleWitnessPacket
+	if err := rlp.DecodeBytes(inreq.Data, &response); err != nil {
+		return fmt.Errorf("decode VerkleWitnessPacket: %w", err)
+	}
+	if !cs.witnesses.deliver(response.RequestId, ConvertH512ToPeerID(inreq.PeerId), &response) {
+		log.Trace("Unexpected verkle witness", "requestId", response.RequestId, "peer", ConvertH512ToPeerID(inreq.PeerId))
+	}
+	return nil
+}
+
 func makeInboundMessage() *proto_sentry.InboundMessage {
 	return new(proto_sentry.InboundMessage)
 }
@@ -709,6 +761,10 @@ func (cs *MultiClient) handleInboundMessage(ctx context.Context, inreq *proto_se
 		return cs.receipts66(ctx, inreq, sentry)
 	case proto_sentry.MessageId_GET_RECEIPTS_66:
 		return cs.getReceipts66(ctx, inreq, sentry)
+	case wit.MessageId_GET_VERKLE_WITNESS_1:
+		return cs.getVerkleWitness(ctx, inreq, sentry)
+	case wit.MessageId_VERKLE_WITNESS_1:
+		return cs.verkleWitness(ctx, inreq, sentry)
 	default:
 		return fmt.Errorf("not implemented for message Id: %s", inreq.Id)
 	}
@@ -758,7 +814,7 @@ func (cs *MultiClient) makeStatusData() *proto_sentry.StatusData {
 	}
 }
 
-func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRemote, error) {
+func GrpcClient(ctx context.Context, sentryAddr string) (*SentryClientRemote, error) {
 	// creating grpc client connection
 	var dialOpts []grpc.DialOption
 
@@ -776,5 +832,5 @@ func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRem
 	if err != nil {
 		return nil, fmt.Errorf("creating client connection to sentry P2P: %w", err)
 	}
-	return direct.NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), nil
+	return NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), nil
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 )
@@ -68,7 +69,7 @@ func CheckChangeSets(genesis *core.Genesis, logger log.Logger, blockNum uint64,
 		interruptCh <- true
 	}()
 
-	db, err := kv2.NewMDBX(logger).Path(chaindata).Open()
+	db, err := kv2.NewMDBX(logger).Path(chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Open()
 	if err != nil {
 		return err
 	}
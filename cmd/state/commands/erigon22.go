@@ -26,6 +26,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/semaphore"
@@ -59,7 +60,7 @@ func Erigon22(execCtx context.Context, genesis *core.Genesis, logger log.Logger)
 	dirs := datadir2.New(datadir)
 
 	limiter := semaphore.NewWeighted(int64(runtime.NumCPU() + 1))
-	db, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).RoTxsLimiter(limiter).Open()
+	db, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).RoTxsLimiter(limiter).Open()
 	if err != nil {
 		return err
 	}
@@ -114,7 +115,7 @@ func Erigon22(execCtx context.Context, genesis *core.Genesis, logger log.Logger)
 	}
 	defer agg.Close()
 
-	stagedSync, err := stages2.NewStagedSync(context.Background(), db, p2p.Config{}, &cfg, sentryControlServer, &stagedsync.Notifications{}, nil, allSnapshots, nil, txNums, agg, nil)
+	stagedSync, err := stages2.NewStagedSync(context.Background(), db, p2p.Config{}, &cfg, sentryControlServer, &stagedsync.Notifications{}, nil, allSnapshots, nil, txNums, agg, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -29,6 +29,7 @@ import (
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 )
@@ -60,7 +61,7 @@ func History22(genesis *core.Genesis, logger log.Logger) error {
 		interruptCh <- true
 	}()
 	dirs := datadir2.New(datadir)
-	historyDb, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).Open()
+	historyDb, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Open()
 	if err != nil {
 		return fmt.Errorf("opening chaindata as read only: %v", err)
 	}
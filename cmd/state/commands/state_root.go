@@ -22,6 +22,7 @@ import (
 	datadir2 "github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 )
@@ -51,7 +52,7 @@ func StateRoot(genesis *core.Genesis, logger log.Logger, blockNum uint64, datadi
 		interruptCh <- true
 	}()
 	dirs := datadir2.New(datadir)
-	historyDb, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).Open()
+	historyDb, err := kv2.NewMDBX(logger).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Open()
 	if err != nil {
 		return err
 	}
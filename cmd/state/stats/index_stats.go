@@ -15,12 +15,12 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/length"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
 func IndexStats(chaindata string, indexBucket string, statsFile string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	startTime := time.Now()
 	lenOfKey := length.Addr
 	if strings.HasPrefix(indexBucket, kv.StorageHistory) {
@@ -10,8 +10,8 @@ import (
 	"time"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -21,7 +21,7 @@ type Walker interface {
 }
 
 func CheckEnc(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	defer db.Close()
 	var (
 		currentSize uint64
@@ -11,13 +11,13 @@ import (
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
 func ValidateTxLookups(chaindata string) error {
-	db := mdbx.MustOpen(chaindata)
+	db := verkledb.MustOpen(chaindata)
 	tx, err := db.BeginRo(context.Background())
 	if err != nil {
 		return err
@@ -362,6 +362,10 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(nodecfg.DefaultConfig.HTTPVirtualHosts, ","),
 	}
+	AuthRpcRateLimitFlag = cli.StringFlag{
+		Name:  "authrpc.ratelimit",
+		Usage: "Specify per-client rate limits and per-method costs of the Engine API calls (unlimited by default)",
+	}
 	AuthRpcVirtualHostsFlag = cli.StringFlag{
 		Name:  "authrpc.vhosts",
 		Usage: "Comma separated list of virtual hostnames from which to accept Engine API requests (server enforced). Accepts '*' wildcard.",
@@ -984,7 +988,7 @@ func setEtherbase(ctx *cli.Context, cfg *ethconfig.Config) {
 		}
 	}
 
-	if ctx.GlobalString(ChainFlag.Name) == networkname.DevChainName || ctx.GlobalString(ChainFlag.Name) == networkname.BorDevnetChainName {
+	if chain := ctx.GlobalString(ChainFlag.Name); chain == networkname.DevChainName || chain == networkname.VerkleDevChainName || chain == networkname.BorDevnetChainName {
 		if etherbase == "" {
 			cfg.Miner.SigKey = core.DevnetSignPrivateKey
 			cfg.Miner.Etherbase = core.DevnetEtherbase
@@ -1046,7 +1050,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config, nodeName, datadir string) {
 		cfg.NetRestrict = list
 	}
 
-	if ctx.GlobalString(ChainFlag.Name) == networkname.DevChainName {
+	if chain := ctx.GlobalString(ChainFlag.Name); chain == networkname.DevChainName || chain == networkname.VerkleDevChainName {
 		// --dev mode can't use p2p networking.
 		//cfg.MaxPeers = 0 // It can have peers otherwise local sync is not possible
 		if !ctx.GlobalIsSet(ListenPortFlag.Name) {
@@ -1080,7 +1084,7 @@ func DataDirForNetwork(datadir string, network string) string {
 	}
 
 	switch network {
-	case networkname.DevChainName:
+	case networkname.DevChainName, networkname.VerkleDevChainName:
 		return "" // unless explicitly requested, use memory databases
 	case networkname.RinkebyChainName:
 		return networkDataDirCheckingLegacy(datadir, "rinkeby")
@@ -1532,9 +1536,9 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		if cfg.NetworkID == 1 {
 			SetDNSDiscoveryDefaults(cfg, params.MainnetGenesisHash)
 		}
-	case networkname.DevChainName:
+	case networkname.DevChainName, networkname.VerkleDevChainName:
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
-			cfg.NetworkID = 1337
+			cfg.NetworkID = params.NetworkIDByChainName(chain)
 		}
 
 		// Create new developer account or reuse existing one
@@ -1545,7 +1549,11 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		log.Info("Using developer account", "address", developer)
 
 		// Create a new developer genesis block or reuse existing one
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer)
+		if chain == networkname.VerkleDevChainName {
+			cfg.Genesis = core.VerkleDeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer)
+		}
 		log.Info("Using custom developer period", "seconds", cfg.Genesis.Config.Clique.Period)
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
@@ -4,14 +4,22 @@ import (
 	"context"
 	"flag"
 
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	"github.com/ledgerwatch/erigon/cmd/verkle-transition/verkle"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
-	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
+type optionsCfg struct {
+	Ctx             context.Context
+	VerkleDb        string
+	StateDb         string
+	WorkersCount    uint
+	Tmpdir          string
+	DisabledLookups bool
+}
+
 func main() {
 	ctx := context.Background()
 	mainDb := flag.String("state-chaindata", "chaindata", "path to the chaindata database file")
@@ -19,11 +27,13 @@ func main() {
 	workersCount := flag.Uint("workers", 5, "amount of goroutines")
 	tmpdir := flag.String("tmpdir", "/tmp/etl-temp", "amount of goroutines")
 	disableLookups := flag.Bool("disable-lookups", false, "disable lookups generation (more compact database)")
+	treeKeyCacheSize := flag.Int("treekey-cache", vtree.DefaultTreeKeyCacheSize, "amount of tree key stems kept in memory")
 
 	flag.Parse()
+	vtree.SetTreeKeyCacheSize(*treeKeyCacheSize)
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(3), log.StderrHandler))
 
-	cfg := verkle.OptionsCfg{
+	cfg := optionsCfg{
 		Ctx:             ctx,
 		StateDb:         *mainDb,
 		VerkleDb:        *verkleDb,
@@ -31,14 +41,14 @@ func main() {
 		Tmpdir:          *tmpdir,
 		DisabledLookups: *disableLookups,
 	}
-	db, err := mdbx.Open(cfg.StateDb, log.Root(), true)
+	db, err := verkledb.Open(cfg.StateDb, log.Root(), true)
 	if err != nil {
 		log.Error("Error while opening database", "err", err.Error())
 		return
 	}
 	defer db.Close()
 
-	vDb, err := mdbx.Open(cfg.VerkleDb, log.Root(), false)
+	vDb, err := verkledb.Open(cfg.VerkleDb, log.Root(), false)
 	if err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return
@@ -70,19 +80,25 @@ func main() {
 	}
 
 	root, _ := verkledb.ReadVerkleRoot(vTx, from)
-	verkleTree := verkle.NewVerkleTree(vTx, root)
-	var accRoot common.Hash
-	var storageRoot common.Hash
+	verkleTree, err := verkledb.NewVerkleTree(vTx, root)
+	if err != nil {
+		log.Error("Error while opening verkle tree", "err", err.Error())
+		return
+	}
 
-	if accRoot, err = verkle.ProcessAccounts(tx, vTx, verkleTree, from); err != nil {
+	if _, err = transition.ProcessAccounts(tx, vTx, verkleTree, from); err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return
 	}
-	if storageRoot, err = verkle.ProcessStorage(tx, vTx, verkleTree, from, accRoot); err != nil {
+	storageRoot, err := transition.ProcessStorage(tx, vTx, verkleTree, from)
+	if err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return
 	}
 	log.Info("Generated", "root", storageRoot)
+	cacheHits, cacheMisses := vtree.TreeKeyCacheStats()
+	indexHits, indexMisses := verkledb.StemIndexStats()
+	log.Info("Tree key derivation", "cacheHits", cacheHits, "cacheMisses", cacheMisses, "indexHits", indexHits, "indexMisses", indexMisses)
 
 	if err := vTx.Commit(); err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
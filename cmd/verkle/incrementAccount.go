@@ -1,60 +1,27 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
 	"sync"
 	"time"
 
-	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
-func badKeysForAddress(tx kv.RwTx, address common.Address) ([][]byte, error) {
-	var badKeys [][]byte
-	// Delete also code and storage slots that are connected to that account (iterating over lookups is simpe)
-	storageLookupCursor, err := tx.Cursor(verkledb.PedersenHashedStorageLookup)
-	if err != nil {
-		return nil, err
-	}
-	defer storageLookupCursor.Close()
-
-	codeLookupCursor, err := tx.Cursor(verkledb.PedersenHashedCodeLookup)
-	if err != nil {
-		return nil, err
-	}
-	defer codeLookupCursor.Close()
-
-	for k, treeKey, err := storageLookupCursor.Seek(address[:]); len(k) >= 20 && bytes.Equal(k[:20], address[:]); k, treeKey, err = storageLookupCursor.Next() {
-		if err != nil {
-			return nil, err
-		}
-		badKeys = append(badKeys, common.CopyBytes(treeKey))
-	}
-
-	for k, treeKey, err := codeLookupCursor.Seek(address[:]); len(k) >= 20 && bytes.Equal(k[:20], address[:]); k, treeKey, err = codeLookupCursor.Next() {
-		if err != nil {
-			return nil, err
-		}
-		badKeys = append(badKeys, common.CopyBytes(treeKey))
-	}
-	return badKeys, nil
-}
-
 func incrementAccount(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) error {
 	logInterval := time.NewTicker(30 * time.Second)
 	logPrefix := "IncrementVerkleAccount"
 
-	collectorLookup := etl.NewCollector(verkledb.PedersenHashedCodeLookup, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
-	defer collectorLookup.Close()
+	keys := newTreeKeyCollectors(cfg)
+	defer keys.close()
 
 	jobs := make(chan *regenerateIncrementalPedersenAccountsJob, batchSize)
 	out := make(chan *regenerateIncrementalPedersenAccountsOut, batchSize)
@@ -75,38 +42,35 @@ func incrementAccount(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 		return err
 	}
 	defer accountCursor.Close()
-	verkleWriter := verkledb.NewVerkleTreeWriter(vTx, cfg.tmpdir)
-	// Start Goroutine for collection
-	go func() {
-		defer debug.LogPanic()
-		defer cancelWorkers()
-		for o := range out {
-			// Remove all bad keys
-			for _, badKey := range o.badKeys {
-				if err := verkleWriter.Insert(badKey, nil); err != nil {
-					panic(err)
-				}
-			}
-			if o.absentInState {
-				continue
-			}
-			if err := verkleWriter.UpdateAccount(o.versionHash, o.codeSize, o.account); err != nil {
-				panic(err)
-			}
-			if err := verkleWriter.WriteContractCodeChunks(o.codeKeys, o.codeChunks); err != nil {
-				panic(err)
-			}
-			// Build lookup [address + index]
-			for i := range o.codeChunks {
-				lookupKey := make([]byte, 24)
-				copy(lookupKey, o.address[:])
-				binary.BigEndian.PutUint32(lookupKey[20:], uint32(i))
-				if err := collectorLookup.Collect(lookupKey, o.codeKeys[i]); err != nil {
-					panic(err)
-				}
+	root, err := verkledb.ReadVerkleRoot(tx, from)
+	if err != nil {
+		return err
+	}
+	verkleWriter, err := verkledb.NewBufferedVerkleTree(vTx, root, cfg.tmpdir)
+	if err != nil {
+		return err
+	}
+	collector := collectOutputs(cancelWorkers, out, func(o *regenerateIncrementalPedersenAccountsOut) error {
+		// Remove all bad keys
+		for _, badKey := range o.badKeys {
+			if err := verkleWriter.Delete(badKey); err != nil {
+				return err
 			}
 		}
-	}()
+		if o.absentInState {
+			return nil
+		}
+		if err := verkleWriter.UpdateAccount(o.versionHash, o.codeSize, o.account); err != nil {
+			return err
+		}
+		if err := verkleWriter.WriteContractCodeChunks(o.codeKeys, o.codeChunks); err != nil {
+			return err
+		}
+		if err := keys.account(o.address[:], o.versionHash); err != nil {
+			return err
+		}
+		return keys.code(o.address[:], o.codeKeys)
+	})
 	marker := verkledb.NewVerkleMarker(true)
 	defer marker.Rollback()
 
@@ -140,13 +104,15 @@ func incrementAccount(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 		}
 		// Start
 		if len(encodedAccount) == 0 {
-			badKeys, err := badKeysForAddress(vTx, address)
+			badKeys, err := verkledb.ReadPedersenLookups(vTx, address)
 			if err != nil {
 				return err
 			}
-			jobs <- &regenerateIncrementalPedersenAccountsJob{
+			if !sendJob(ctx, jobs, &regenerateIncrementalPedersenAccountsJob{
 				absentInState: true,
 				badKeys:       badKeys,
+			}) {
+				break
 			}
 		} else {
 			var acc accounts.Account
@@ -165,17 +131,23 @@ func incrementAccount(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 				if err != nil {
 					return err
 				}
-				badKeys, err = badKeysForAddress(vTx, address)
+				badKeys, err = verkledb.ReadPedersenLookups(vTx, address)
 				if err != nil {
 					return err
 				}
 			}
-			jobs <- &regenerateIncrementalPedersenAccountsJob{
+			// Indexed stems are put in the shared cache, so that the workers do not derive them again
+			if err := verkledb.PrefetchStems(vTx, address[:], append(codeTreeIndices(len(code)), uint256.NewInt(0))...); err != nil {
+				return err
+			}
+			if !sendJob(ctx, jobs, &regenerateIncrementalPedersenAccountsJob{
 				address:       address,
 				account:       acc,
 				code:          code,
 				absentInState: false,
 				badKeys:       badKeys,
+			}) {
+				break
 			}
 		}
 		if err := marker.MarkAsDone(addressBytes); err != nil {
@@ -190,17 +162,12 @@ func incrementAccount(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 	close(jobs)
 	wg.Wait()
 	close(out)
-	if err := collectorLookup.Load(vTx, verkledb.PedersenHashedCodeLookup, identityFuncForVerkleTree, etl.TransformArgs{Quit: context.Background().Done(),
-		LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
-			return []interface{}{"key", common.Bytes2Hex(k)}
-		}}); err != nil {
+	if err := collector.Wait(); err != nil {
 		return err
 	}
-	// Get root
-	root, err := verkledb.ReadVerkleRoot(tx, from)
-	if err != nil {
+	if err := keys.load(vTx); err != nil {
 		return err
 	}
-	_, err = verkleWriter.CommitVerkleTree(root)
+	_, err = verkleWriter.Commit()
 	return err
 }
@@ -7,15 +7,15 @@ import (
 	"time"
 
 	"github.com/holiman/uint256"
-	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -23,8 +23,8 @@ func incrementStorage(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 	logInterval := time.NewTicker(30 * time.Second)
 	logPrefix := "IncrementVerkleStorage"
 
-	collectorLookup := etl.NewCollector(verkledb.PedersenHashedStorageLookup, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
-	defer collectorLookup.Close()
+	keys := newTreeKeyCollectors(cfg)
+	defer keys.close()
 
 	jobs := make(chan *regeneratePedersenStorageJob, batchSize)
 	out := make(chan *regeneratePedersenStorageJob, batchSize)
@@ -45,21 +45,20 @@ func incrementStorage(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 		return err
 	}
 	defer storageCursor.Close()
-	verkleWriter := verkledb.NewVerkleTreeWriter(vTx, cfg.tmpdir)
-	// Start Goroutine for collection
-	go func() {
-		defer debug.LogPanic()
-		defer cancelWorkers()
-		for o := range out {
-			if err := verkleWriter.Insert(o.storageVerkleKey[:], o.storageValue); err != nil {
-				panic(err)
-			}
-
-			if err := collectorLookup.Collect(append(o.address[:], o.storageKey.Bytes()...), o.storageVerkleKey[:]); err != nil {
-				panic(err)
-			}
+	root, err := verkledb.ReadVerkleRoot(tx, from)
+	if err != nil {
+		return err
+	}
+	verkleWriter, err := verkledb.NewBufferedVerkleTree(vTx, root, cfg.tmpdir)
+	if err != nil {
+		return err
+	}
+	collector := collectOutputs(cancelWorkers, out, func(o *regeneratePedersenStorageJob) error {
+		if err := verkleWriter.Insert(o.storageVerkleKey[:], o.storageValue); err != nil {
+			return err
 		}
-	}()
+		return keys.storage(o.address[:], o.storageKey, o.storageVerkleKey[:])
+	})
 	marker := verkledb.NewVerkleMarker(true)
 	defer marker.Rollback()
 
@@ -115,13 +114,19 @@ func incrementStorage(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 
 		if len(storageValue) > 0 {
 			storageValueFormatted = make([]byte, 32)
-			verkledb.Int256ToVerkleFormat(new(uint256.Int).SetBytes(storageValue), storageValueFormatted)
+			vtree.Int256ToVerkleFormat(new(uint256.Int).SetBytes(storageValue), storageValueFormatted)
 		}
 
-		jobs <- &regeneratePedersenStorageJob{
+		treeIndex, _ := vtree.StorageSlotTreeIndex(storageKey)
+		if err := verkledb.PrefetchStems(vTx, address[:], treeIndex); err != nil {
+			return err
+		}
+		if !sendJob(ctx, jobs, &regeneratePedersenStorageJob{
 			address:      address,
 			storageKey:   storageKey,
 			storageValue: storageValueFormatted,
+		}) {
+			break
 		}
 		if err := marker.MarkAsDone(changesetKey); err != nil {
 			return err
@@ -135,18 +140,13 @@ func incrementStorage(vTx kv.RwTx, tx kv.Tx, cfg optionsCfg, from, to uint64) er
 	close(jobs)
 	wg.Wait()
 	close(out)
-	if err := collectorLookup.Load(vTx, verkledb.PedersenHashedStorageLookup, identityFuncForVerkleTree, etl.TransformArgs{Quit: context.Background().Done(),
-		LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
-			return []interface{}{"key", common.Bytes2Hex(k)}
-		}}); err != nil {
+	if err := collector.Wait(); err != nil {
 		return err
 	}
-	// Get root
-	root, err := verkledb.ReadVerkleRoot(tx, from)
-	if err != nil {
+	if err := keys.load(vTx); err != nil {
 		return err
 	}
-	newRoot, err := verkleWriter.CommitVerkleTree(root)
+	newRoot, err := verkleWriter.Commit()
 	if err != nil {
 		return err
 	}
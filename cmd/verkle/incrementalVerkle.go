@@ -3,17 +3,11 @@ package main
 import (
 	"time"
 
-	"github.com/ledgerwatch/erigon-lib/etl"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
-func identityFuncForVerkleTree(k []byte, value []byte, _ etl.CurrentTableReader, next etl.LoadNextFunc) error {
-	return next(k, k, value)
-}
-
 /*func readAccountKey(tx kv.RwTx, address []byte) ([]byte, error) {
 	return tx.GetOne(PedersenHashedAccountsLookup, address)
 }
@@ -31,14 +25,14 @@ func readCodeKey(tx kv.RwTx, address []byte, index *uint256.Int) ([]byte, error)
 
 func IncrementVerkleTree(cfg optionsCfg) error {
 	start := time.Now()
-	db, err := mdbx.Open(cfg.stateDb, log.Root(), true)
+	db, err := verkledb.Open(cfg.stateDb, log.Root(), true)
 	if err != nil {
 		log.Error("Error while opening database", "err", err.Error())
 		return err
 	}
 	defer db.Close()
 
-	vDb, err := mdbx.Open(cfg.verkleDb, log.Root(), false)
+	vDb, err := verkledb.Open(cfg.verkleDb, log.Root(), false)
 	if err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return err
@@ -81,6 +75,7 @@ func IncrementVerkleTree(cfg optionsCfg) error {
 		return err
 	}
 
+	logTreeKeyStats()
 	log.Info("Finished", "elapesed", time.Since(start))
 	return vTx.Commit()
 }
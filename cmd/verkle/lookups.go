@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// treeKeyCollectors gathers the keys derived by the workers: the stems go into the stem index and the keys into the
+// Pedersen lookups, unless those are disabled. Entries are laid out the same way verkledb.TreeKeys writes them.
+type treeKeyCollectors struct {
+	lookups bool
+	buckets []string
+	byTable map[string]*etl.Collector
+}
+
+func newTreeKeyCollectors(cfg optionsCfg) *treeKeyCollectors {
+	c := &treeKeyCollectors{
+		lookups: !cfg.disabledLookups,
+		buckets: []string{verkledb.VerkleStemIndex, verkledb.PedersenHashedAccountsLookup, verkledb.PedersenHashedStorageLookup, verkledb.PedersenHashedCodeLookup},
+		byTable: map[string]*etl.Collector{},
+	}
+	for _, bucket := range c.buckets {
+		c.byTable[bucket] = etl.NewCollector(bucket, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	}
+	return c
+}
+
+func (c *treeKeyCollectors) close() {
+	for _, collector := range c.byTable {
+		collector.Close()
+	}
+}
+
+func (c *treeKeyCollectors) stem(address []byte, treeIndex *uint256.Int, key []byte) error {
+	return c.byTable[verkledb.VerkleStemIndex].Collect(verkledb.StemIndexKey(address, treeIndex), key[:vtree.StemLength])
+}
+
+func (c *treeKeyCollectors) account(address, versionKey []byte) error {
+	if err := c.stem(address, uint256.NewInt(0), versionKey); err != nil {
+		return err
+	}
+	if !c.lookups {
+		return nil
+	}
+	return c.byTable[verkledb.PedersenHashedAccountsLookup].Collect(common.CopyBytes(address), versionKey)
+}
+
+func (c *treeKeyCollectors) storage(address []byte, storageKey *uint256.Int, key []byte) error {
+	treeIndex, _ := vtree.StorageSlotTreeIndex(storageKey)
+	if err := c.stem(address, treeIndex, key); err != nil {
+		return err
+	}
+	if !c.lookups {
+		return nil
+	}
+	return c.byTable[verkledb.PedersenHashedStorageLookup].Collect(verkledb.PedersenStorageLookupKey(address, storageKey), key)
+}
+
+func (c *treeKeyCollectors) code(address []byte, chunkKeys [][]byte) error {
+	for i, key := range chunkKeys {
+		// Chunks sharing a stem only need it once
+		if treeIndex, subIndex := vtree.CodeChunkTreeIndex(uint256.NewInt(uint64(i))); i == 0 || subIndex == 0 {
+			if err := c.stem(address, treeIndex, key); err != nil {
+				return err
+			}
+		}
+		if !c.lookups {
+			continue
+		}
+		if err := c.byTable[verkledb.PedersenHashedCodeLookup].Collect(verkledb.PedersenCodeLookupKey(address, uint32(i)), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *treeKeyCollectors) load(tx kv.RwTx) error {
+	for _, bucket := range c.buckets {
+		if err := c.byTable[bucket].Load(tx, bucket, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done(),
+			LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
+				return []interface{}{"key", common.Bytes2Hex(k)}
+			}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// codeTreeIndices returns the tree indices of the stems holding the chunks of the given code
+func codeTreeIndices(codeSize int) []*uint256.Int {
+	var treeIndices []*uint256.Int
+	for i := 0; i < (codeSize+30)/31; i++ {
+		if treeIndex, subIndex := vtree.CodeChunkTreeIndex(uint256.NewInt(uint64(i))); i == 0 || subIndex == 0 {
+			treeIndices = append(treeIndices, treeIndex)
+		}
+	}
+	return treeIndices
+}
+
+func logTreeKeyStats() {
+	cacheHits, cacheMisses := vtree.TreeKeyCacheStats()
+	indexHits, indexMisses := verkledb.StemIndexStats()
+	log.Info("Tree key derivation", "cacheHits", cacheHits, "cacheMisses", cacheMisses, "indexHits", indexHits, "indexMisses", indexMisses)
+}
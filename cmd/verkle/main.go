@@ -5,8 +5,9 @@ import (
 	"flag"
 
 	"github.com/c2h5oh/datasize"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -17,10 +18,12 @@ type optionsCfg struct {
 	workersCount    uint
 	tmpdir          string
 	disabledLookups bool
+	block           int64       // block of the tree checked by verify or executed by stateless, the execution progress if negative
+	root            common.Hash // root of the tree checked by verify, the root of block if empty
 }
 
 func analyseOut(cfg optionsCfg) error {
-	db, err := mdbx.Open(cfg.verkleDb, log.Root(), false)
+	db, err := verkledb.Open(cfg.verkleDb, log.Root(), false)
 	if err != nil {
 		return err
 	}
@@ -54,10 +57,14 @@ func main() {
 	verkleDb := flag.String("verkle-chaindata", "out", "path to the output chaindata database file")
 	workersCount := flag.Uint("workers", 5, "amount of goroutines")
 	tmpdir := flag.String("tmpdir", "/tmp/etl-temp", "amount of goroutines")
-	action := flag.String("action", "", "action to execute (hashstate, bucketsizes, verkle)")
+	action := flag.String("action", "", "action to execute (hashstate, bucketsizes, verkle, incremental, verify, stateless)")
 	disableLookups := flag.Bool("disable-lookups", false, "disable lookups generation (more compact database)")
+	treeKeyCacheSize := flag.Int("treekey-cache", vtree.DefaultTreeKeyCacheSize, "amount of tree key stems kept in memory")
+	block := flag.Int64("block", -1, "block of the tree to verify or of the block to execute statelessly (execution progress if negative)")
+	root := flag.String("root", "", "root of the tree to verify, instead of the root of --block")
 
 	flag.Parse()
+	vtree.SetTreeKeyCacheSize(*treeKeyCacheSize)
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(3), log.StderrHandler))
 
 	opt := optionsCfg{
@@ -67,6 +74,8 @@ func main() {
 		workersCount:    *workersCount,
 		tmpdir:          *tmpdir,
 		disabledLookups: *disableLookups,
+		block:           *block,
+		root:            common.HexToHash(*root),
 	}
 	switch *action {
 	case "hashstate":
@@ -85,6 +94,14 @@ func main() {
 		if err := IncrementVerkleTree(opt); err != nil {
 			log.Error("Error", "err", err.Error())
 		}
+	case "verify":
+		if err := VerifyVerkleTree(opt); err != nil {
+			log.Error("Error", "err", err.Error())
+		}
+	case "stateless":
+		if err := ExecuteStateless(opt); err != nil {
+			log.Error("Error", "err", err.Error())
+		}
 	default:
 		log.Warn("No valid --action specified, aborting")
 	}
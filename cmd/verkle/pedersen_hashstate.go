@@ -8,13 +8,11 @@ import (
 	"time"
 
 	"github.com/holiman/uint256"
-	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -32,13 +30,13 @@ import (
 	return
 }*/
 
-func regeneratePedersenAccounts(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTreeWriter) error {
+func regeneratePedersenAccounts(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTree) error {
 	logPrefix := "PedersenHashedAccounts"
 	start := time.Now()
 	log.Info("Started Generation of Pedersen Hashed Accounts")
 
-	collectorLookup := etl.NewCollector(verkledb.PedersenHashedAccountsLookup, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
-	defer collectorLookup.Close()
+	keys := newTreeKeyCollectors(cfg)
+	defer keys.close()
 
 	plainStateCursor, err := readTx.Cursor(kv.PlainState)
 	if err != nil {
@@ -61,20 +59,12 @@ func regeneratePedersenAccounts(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, ver
 		}(i)
 	}
 	defer cancelWorkers()
-	// Start Goroutine for collection
-	go func() {
-		defer debug.LogPanic()
-		defer cancelWorkers()
-		for o := range out {
-			if err := verkleWriter.UpdateAccount(o.versionHash[:], o.codeSize, o.account); err != nil {
-				panic(err)
-			}
-
-			if err := collectorLookup.Collect(o.address[:], o.versionHash[:]); err != nil {
-				panic(err)
-			}
+	collector := collectOutputs(cancelWorkers, out, func(o *regeneratePedersenAccountsOut) error {
+		if err := verkleWriter.UpdateAccount(o.versionHash[:], o.codeSize, o.account); err != nil {
+			return err
 		}
-	}()
+		return keys.account(o.address[:], o.versionHash[:])
+	})
 	for k, v, err := plainStateCursor.First(); k != nil; k, v, err = plainStateCursor.Next() {
 		if err != nil {
 			return err
@@ -92,10 +82,12 @@ func regeneratePedersenAccounts(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, ver
 				}
 				codeSize = uint64(len(code))
 			}
-			jobs <- &regeneratePedersenAccountsJob{
+			if !sendJob(ctx, jobs, &regeneratePedersenAccountsJob{
 				address:  common.BytesToAddress(k),
 				account:  acc,
 				codeSize: codeSize,
+			}) {
+				break
 			}
 			select {
 			case <-logEvery.C:
@@ -108,23 +100,25 @@ func regeneratePedersenAccounts(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, ver
 	close(jobs)
 	wg.Wait()
 	close(out)
+	if err := collector.Wait(); err != nil {
+		return err
+	}
 
-	collectorLookup.Load(outTx, verkledb.PedersenHashedAccountsLookup, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done(),
-		LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
-			return []interface{}{"key", common.Bytes2Hex(k)}
-		}})
+	if err := keys.load(outTx); err != nil {
+		return err
+	}
 	log.Info("Finished generation of Pedersen Hashed Accounts", "elapsed", time.Since(start))
 
 	return nil
 }
 
-func regeneratePedersenStorage(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTreeWriter) error {
+func regeneratePedersenStorage(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTree) error {
 	logPrefix := "PedersenHashedStorage"
 	start := time.Now()
 	log.Info("Started Generation of Pedersen Hashed Storage")
 
-	collectorLookup := etl.NewCollector(verkledb.PedersenHashedStorageLookup, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
-	defer collectorLookup.Close()
+	keys := newTreeKeyCollectors(cfg)
+	defer keys.close()
 
 	plainStateCursor, err := readTx.Cursor(kv.PlainState)
 	if err != nil {
@@ -148,22 +142,12 @@ func regeneratePedersenStorage(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verk
 		}(i)
 	}
 	defer cancelWorkers()
-	// Start Goroutine for collection
-	go func() {
-		defer debug.LogPanic()
-		defer cancelWorkers()
-		for o := range out {
-			if err := verkleWriter.Insert(o.storageVerkleKey[:], o.storageValue); err != nil {
-				panic(err)
-			}
-			if cfg.disabledLookups {
-				continue
-			}
-			if err := collectorLookup.Collect(append(o.address[:], o.storageKey.Bytes()...), o.storageVerkleKey[:]); err != nil {
-				panic(err)
-			}
+	collector := collectOutputs(cancelWorkers, out, func(o *regeneratePedersenStorageJob) error {
+		if err := verkleWriter.Insert(o.storageVerkleKey[:], o.storageValue); err != nil {
+			return err
 		}
-	}()
+		return keys.storage(o.address[:], o.storageKey, o.storageVerkleKey[:])
+	})
 
 	var address common.Address
 	var incarnation uint64
@@ -176,10 +160,12 @@ func regeneratePedersenStorage(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verk
 				continue
 			}
 			storageValue := new(uint256.Int).SetBytes(v).Bytes32()
-			jobs <- &regeneratePedersenStorageJob{
+			if !sendJob(ctx, jobs, &regeneratePedersenStorageJob{
 				storageKey:   new(uint256.Int).SetBytes(k[28:]),
 				storageValue: storageValue[:],
 				address:      address,
+			}) {
+				break
 			}
 			select {
 			case <-logInterval.C:
@@ -199,23 +185,25 @@ func regeneratePedersenStorage(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verk
 	close(jobs)
 	wg.Wait()
 	close(out)
+	if err := collector.Wait(); err != nil {
+		return err
+	}
 
-	collectorLookup.Load(outTx, verkledb.PedersenHashedStorageLookup, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done(),
-		LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
-			return []interface{}{"key", common.Bytes2Hex(k)}
-		}})
+	if err := keys.load(outTx); err != nil {
+		return err
+	}
 	log.Info("Finished generation of Pedersen Hashed Storage", "elapsed", time.Since(start))
 
 	return nil
 }
 
-func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTreeWriter) error {
+func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleWriter *verkledb.VerkleTree) error {
 	logPrefix := "PedersenHashedCode"
 	start := time.Now()
 	log.Info("Started Generation of Pedersen Hashed Code")
 
-	collectorLookup := etl.NewCollector(verkledb.PedersenHashedCodeLookup, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
-	defer collectorLookup.Close()
+	keys := newTreeKeyCollectors(cfg)
+	defer keys.close()
 
 	plainStateCursor, err := readTx.Cursor(kv.PlainState)
 	if err != nil {
@@ -239,33 +227,16 @@ func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleW
 		}(i)
 	}
 	defer cancelWorkers()
-	// Start Goroutine for collection
-	go func() {
-		defer debug.LogPanic()
-		defer cancelWorkers()
-		for o := range out {
-			// Write code chunks
-			if o.codeSize == 0 {
-				continue
-			}
-			if err := verkleWriter.WriteContractCodeChunks(o.chunksKeys, o.chunks); err != nil {
-				panic(err)
-			}
-			if cfg.disabledLookups {
-				continue
-			}
-			for i := range o.chunks {
-
-				// Build lookup [address + index]
-				lookupKey := make([]byte, 24)
-				copy(lookupKey, o.address[:])
-				binary.BigEndian.PutUint32(lookupKey[20:], uint32(i))
-				if err := collectorLookup.Collect(lookupKey, o.chunksKeys[i]); err != nil {
-					panic(err)
-				}
-			}
+	collector := collectOutputs(cancelWorkers, out, func(o *regeneratePedersenCodeOut) error {
+		// Write code chunks
+		if o.codeSize == 0 {
+			return nil
 		}
-	}()
+		if err := verkleWriter.WriteContractCodeChunks(o.chunksKeys, o.chunks); err != nil {
+			return err
+		}
+		return keys.code(o.address[:], o.chunksKeys)
+	})
 
 	for k, v, err := plainStateCursor.First(); k != nil; k, v, err = plainStateCursor.Next() {
 		if err != nil {
@@ -287,9 +258,11 @@ func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleW
 			return err
 		}
 
-		jobs <- &regeneratePedersenCodeJob{
+		if !sendJob(ctx, jobs, &regeneratePedersenCodeJob{
 			address: common.BytesToAddress(k),
 			code:    common.CopyBytes(code),
+		}) {
+			break
 		}
 		select {
 		case <-logInterval.C:
@@ -301,11 +274,11 @@ func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleW
 	close(jobs)
 	wg.Wait()
 	close(out)
+	if err := collector.Wait(); err != nil {
+		return err
+	}
 
-	if err := collectorLookup.Load(outTx, verkledb.PedersenHashedCodeLookup, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done(),
-		LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
-			return []interface{}{"key", common.Bytes2Hex(k)}
-		}}); err != nil {
+	if err := keys.load(outTx); err != nil {
 		return err
 	}
 	log.Info("Finished generation of Pedersen Hashed Code", "elapsed", time.Since(start))
@@ -314,14 +287,14 @@ func regeneratePedersenCode(outTx kv.RwTx, readTx kv.Tx, cfg optionsCfg, verkleW
 }
 
 func RegeneratePedersenHashstate(cfg optionsCfg) error {
-	db, err := mdbx.Open(cfg.stateDb, log.Root(), true)
+	db, err := verkledb.Open(cfg.stateDb, log.Root(), true)
 	if err != nil {
 		log.Error("Error while opening database", "err", err.Error())
 		return err
 	}
 	defer db.Close()
 
-	vDb, err := mdbx.Open(cfg.stateDb, log.Root(), false)
+	vDb, err := verkledb.Open(cfg.stateDb, log.Root(), false)
 	if err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return err
@@ -344,7 +317,10 @@ func RegeneratePedersenHashstate(cfg optionsCfg) error {
 		return err
 	}
 
-	verleWriter := verkledb.NewVerkleTreeWriter(vTx, cfg.tmpdir)
+	verleWriter, err := verkledb.NewBufferedVerkleTree(vTx, common.Hash{}, cfg.tmpdir)
+	if err != nil {
+		return err
+	}
 
 	if err := regeneratePedersenAccounts(vTx, tx, cfg, verleWriter); err != nil {
 		return err
@@ -356,5 +332,6 @@ func RegeneratePedersenHashstate(cfg optionsCfg) error {
 	if err := regeneratePedersenStorage(vTx, tx, cfg, verleWriter); err != nil {
 		return err
 	}
+	logTreeKeyStats()
 	return vTx.Commit()
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// ExecuteStateless runs a block again with nothing but the witness in its header and the root of its parent
+func ExecuteStateless(cfg optionsCfg) error {
+	start := time.Now()
+	db, err := verkledb.Open(cfg.stateDb, log.Root(), true)
+	if err != nil {
+		log.Error("Error while opening database", "err", err.Error())
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginRo(cfg.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	execRs, err := executeStateless(tx, cfg.block)
+	if err != nil {
+		return err
+	}
+	log.Info("Finished", "gasUsed", uint64(execRs.GasUsed), "receipts", len(execRs.Receipts), "elapsed", time.Since(start))
+	return nil
+}
+
+// executeStateless runs the block, the execution head if negative, from its witness. The receipts are checked against
+// the header by the execution, the state is never read from the database.
+func executeStateless(tx kv.Tx, block int64) (*core.EphemeralExecResult, error) {
+	if block < 0 {
+		progress, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return nil, err
+		}
+		block = int64(progress)
+	}
+	blockNum := uint64(block)
+	genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := rawdb.ReadChainConfig(tx, genesisHash)
+	if err != nil {
+		return nil, err
+	}
+	if chainConfig == nil {
+		return nil, fmt.Errorf("no chain config")
+	}
+	if blockNum == 0 {
+		return nil, fmt.Errorf("genesis cannot be executed")
+	}
+	blockHash, err := rawdb.ReadCanonicalHash(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	blk, _, err := rawdb.ReadBlockWithSenders(tx, blockHash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	parent := rawdb.ReadHeader(tx, blk.ParentHash(), blockNum-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block %d not found", blockNum)
+	}
+	engine, err := statelessEngine(chainConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer engine.Close()
+
+	getHeader := func(hash common.Hash, number uint64) *types.Header { return rawdb.ReadHeader(tx, hash, number) }
+	blockHashFunc := core.GetHashFn(blk.Header(), getHeader)
+	return core.ExecuteBlockStateless(chainConfig, &vm.Config{}, blockHashFunc, engine, blk, parent, nil, nil)
+}
+
+// statelessEngine is the engine finalizing the blocks of the chain, it does not verify the seals
+func statelessEngine(chainConfig *params.ChainConfig) (consensus.Engine, error) {
+	switch {
+	case chainConfig.Clique != nil:
+		return clique.New(chainConfig, params.CliqueSnapshot, memdb.New()), nil
+	case chainConfig.Aura != nil, chainConfig.Parlia != nil, chainConfig.Bor != nil:
+		return nil, fmt.Errorf("stateless execution is not supported for the engine of %s", chainConfig.ChainName)
+	default:
+		return ethash.NewFaker(), nil
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// maxResolvedLeaves is the amount of leaves read before the resolved part of the tree is dropped
+const maxResolvedLeaves = 1_000_000
+
+// flatLeavesTable keeps the keys of the leaves of the flat state, to find the leaves of the tree that are not in it
+const flatLeavesTable = "FlatLeaves"
+
+// verkleVerifier counts the problems found in the tree, each of them is logged as it is found
+type verkleVerifier struct {
+	tx           kv.Tx
+	rootHash     common.Hash
+	root         verkle.VerkleNode
+	logInterval  *time.Ticker
+	nodes        uint64
+	leaves       uint64
+	badNodes     uint64
+	missingNodes uint64
+	dangling     uint64
+	badLeaves    uint64
+	extraLeaves  uint64
+	flatKeys     *etl.Collector // keys of the leaves of the flat state, while they are compared
+	flatLeaves   kv.Tx          // the same keys once loaded, nil if the leaves are not compared
+}
+
+// loadRoot parses the root again, dropping the nodes resolved so far, so that memory stays bounded
+func (v *verkleVerifier) loadRoot() error {
+	encoded, err := v.resolve(v.rootHash[:])
+	if err != nil {
+		return err
+	}
+	v.root, err = verkle.ParseNode(encoded, 0, v.rootHash[:])
+	return err
+}
+
+func (v *verkleVerifier) problems() uint64 {
+	return v.badNodes + v.missingNodes + v.dangling + v.badLeaves + v.extraLeaves
+}
+
+func (v *verkleVerifier) resolve(commitment []byte) ([]byte, error) {
+	return v.tx.GetOne(verkledb.VerkleTrie, commitment)
+}
+
+// VerifyVerkleTree checks the tree committed at the execution head, or the one of cfg.block or cfg.root, against the flat state:
+// the commitment of every node reachable from the root is recomputed, nodes which are not reachable are reported as dangling,
+// the leaves of every account, contract code and storage slot are compared with PlainState and PlainContractCode,
+// and the leaves of the tree which are not in the flat state are reported as extra.
+// Nodes of older roots, kept around for unwinds, are reported as dangling as well.
+func VerifyVerkleTree(cfg optionsCfg) error {
+	start := time.Now()
+	db, err := verkledb.Open(cfg.stateDb, log.Root(), true)
+	if err != nil {
+		log.Error("Error while opening database", "err", err.Error())
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginRo(cfg.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The tree is kept in chaindata by the VerkleTrie stage
+	vTx := tx
+	if cfg.verkleDb != cfg.stateDb {
+		vDb, err := verkledb.Open(cfg.verkleDb, log.Root(), true)
+		if err != nil {
+			log.Error("Error while opening database", "err", err.Error())
+			return err
+		}
+		defer vDb.Close()
+
+		if vTx, err = vDb.BeginRo(cfg.ctx); err != nil {
+			return err
+		}
+		defer vTx.Rollback()
+	}
+
+	if err := verifyVerkleTree(tx, vTx, cfg.tmpdir, cfg.block, cfg.root); err != nil {
+		return err
+	}
+	log.Info("Finished", "elapsed", time.Since(start))
+	return nil
+}
+
+// verifyVerkleTree checks the tree of the block, the execution head if negative, or the tree of the root if it is not empty.
+// The flat state is at the execution head, so the leaves of the trees of other blocks are not compared with it.
+func verifyVerkleTree(tx, vTx kv.Tx, tmpdir string, block int64, root common.Hash) error {
+	head, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	blockNum := head
+	if block >= 0 {
+		blockNum = uint64(block)
+	}
+	if root == (common.Hash{}) {
+		if root, err = verkledb.ReadVerkleRoot(vTx, blockNum); err != nil {
+			return err
+		}
+		if root == (common.Hash{}) {
+			return fmt.Errorf("no verkle root for block %d", blockNum)
+		}
+	}
+	log.Info("Verifying verkle tree", "block", blockNum, "root", root)
+
+	v := &verkleVerifier{
+		tx:          vTx,
+		rootHash:    root,
+		logInterval: time.NewTicker(30 * time.Second),
+	}
+	defer v.logInterval.Stop()
+
+	if blockNum == head {
+		flatLeaves, removeFlatLeaves, err := openFlatLeaves(tmpdir)
+		if err != nil {
+			return err
+		}
+		defer removeFlatLeaves()
+		if err := v.verifyLeaves(tx, flatLeaves, tmpdir); err != nil {
+			return err
+		}
+		log.Info("Verified verkle leaves", "leaves", v.leaves, "bad", v.badLeaves)
+	} else {
+		log.Warn("The flat state is at another block, the leaves are not compared with it", "head", head)
+	}
+
+	if err := v.verifyNodes(tmpdir); err != nil {
+		return err
+	}
+	log.Info("Verified verkle nodes", "nodes", v.nodes, "bad", v.badNodes, "missing", v.missingNodes, "dangling", v.dangling, "extraLeaves", v.extraLeaves)
+
+	if v.problems() > 0 {
+		return fmt.Errorf("verkle tree at block %d has %d problems", blockNum, v.problems())
+	}
+	return nil
+}
+
+// verifyNodes walks the tree from the root, recomputing every commitment, and then looks for nodes that were not visited
+func (v *verkleVerifier) verifyNodes(tmpdir string) error {
+	visited := etl.NewCollector("VerifyVerkle", tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	defer visited.Close()
+
+	if err := v.verifyNode(v.rootHash[:], 0, visited); err != nil {
+		return err
+	}
+
+	c, err := v.tx.Cursor(verkledb.VerkleTrie)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// Both the collector and the bucket are sorted, so anything in the bucket in between two visited nodes is dangling
+	k, _, err := c.First()
+	if err != nil {
+		return err
+	}
+	countDangling := func(until []byte) error {
+		for ; k != nil && (until == nil || bytes.Compare(k, until) < 0); k, _, err = c.Next() {
+			if err != nil {
+				return err
+			}
+			v.dangling++
+			log.Debug("Dangling verkle node", "commitment", common.Bytes2Hex(k))
+		}
+		return err
+	}
+	if err := visited.Load(nil, "", func(commitment, _ []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
+		if err := countDangling(commitment); err != nil {
+			return err
+		}
+		if k != nil && bytes.Equal(k, commitment) {
+			k, _, err = c.Next()
+		}
+		return err
+	}, etl.TransformArgs{Quit: make(chan struct{})}); err != nil {
+		return err
+	}
+	return countDangling(nil)
+}
+
+func (v *verkleVerifier) verifyNode(commitment []byte, depth byte, visited *etl.Collector) error {
+	encoded, err := v.resolve(commitment)
+	if err != nil {
+		return err
+	}
+	if len(encoded) == 0 {
+		v.missingNodes++
+		log.Warn("Missing verkle node", "commitment", common.Bytes2Hex(commitment), "depth", depth)
+		return nil
+	}
+	if err := visited.Collect(commitment, nil); err != nil {
+		return err
+	}
+	v.nodes++
+	select {
+	case <-v.logInterval.C:
+		log.Info("Verifying verkle nodes", "nodes", v.nodes, "depth", depth)
+	default:
+	}
+
+	node, err := verkle.ParseNode(encoded, depth, commitment)
+	if err != nil {
+		v.badNodes++
+		log.Warn("Invalid verkle node encoding", "commitment", common.Bytes2Hex(commitment), "err", err)
+		return nil
+	}
+	var computed [32]byte
+	switch n := node.(type) {
+	case *verkle.LeafNode:
+		// Leaf commitments are computed out of the values when parsing
+		computed = n.ComputeCommitment().Bytes()
+		if err := v.verifyTreeLeaves(n); err != nil {
+			return err
+		}
+	case *verkle.InternalNode:
+		// Parsing keeps the stored commitment, so it is recomputed out of the children in a fresh node
+		fresh := verkle.New().(*verkle.InternalNode)
+		copy(fresh.Children(), n.Children())
+		computed = fresh.ComputeCommitment().Bytes()
+		for _, child := range n.Children() {
+			if _, ok := child.(verkle.Empty); ok {
+				continue
+			}
+			childCommitment := child.ComputeCommitment().Bytes()
+			if err := v.verifyNode(childCommitment[:], depth+1, visited); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected verkle node type %T", node)
+	}
+	if !bytes.Equal(computed[:], commitment) {
+		v.badNodes++
+		log.Warn("Verkle commitment mismatch", "have", common.Bytes2Hex(commitment), "computed", common.Bytes2Hex(computed[:]), "depth", depth)
+	}
+	return nil
+}
+
+// verifyTreeLeaves looks up the leaves of the node in the leaves of the flat state, reporting the ones which are not there.
+// Storage slots cleared by the incremental generation are kept as zero leaves, so zero leaves are not reported.
+func (v *verkleVerifier) verifyTreeLeaves(n *verkle.LeafNode) error {
+	if v.flatLeaves == nil {
+		return nil
+	}
+	for i := 0; i < verkle.NodeWidth; i++ {
+		value := n.Value(i)
+		if value == nil || bytes.Equal(value, zeroLeaf[:]) {
+			continue
+		}
+		has, err := v.flatLeaves.Has(flatLeavesTable, n.Key(i))
+		if err != nil {
+			return err
+		}
+		if !has {
+			v.extraLeaves++
+			log.Warn("Verkle leaf is not in the flat state", "key", common.Bytes2Hex(n.Key(i)), "value", common.Bytes2Hex(value))
+		}
+	}
+	return nil
+}
+
+var zeroLeaf [32]byte
+
+// openFlatLeaves opens a temporary database for the keys of the leaves of the flat state, removed by the returned function
+func openFlatLeaves(tmpdir string) (kv.RwTx, func(), error) {
+	if err := os.MkdirAll(tmpdir, 0755); err != nil {
+		return nil, nil, err
+	}
+	dir, err := os.MkdirTemp(tmpdir, "verkle-verify")
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := mdbx.NewMDBX(log.Root()).Path(dir).WithTableCfg(func(kv.TableCfg) kv.TableCfg {
+		return kv.TableCfg{flatLeavesTable: {}}
+	}).Open()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	tx, err := db.BeginRw(context.Background())
+	if err != nil {
+		db.Close()
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return tx, func() {
+		tx.Rollback()
+		db.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// verifyLeaves compares the leaves of every account and storage slot in PlainState with the tree,
+// and keeps their keys in flatLeaves for verifyTreeLeaves
+func (v *verkleVerifier) verifyLeaves(tx kv.Tx, flatLeaves kv.RwTx, tmpdir string) error {
+	if err := v.loadRoot(); err != nil {
+		return err
+	}
+	v.flatKeys = etl.NewCollector("VerifyVerkleLeaves", tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	defer v.flatKeys.Close()
+	if err := v.compareLeaves(tx); err != nil {
+		return err
+	}
+	if err := v.flatKeys.Load(flatLeaves, flatLeavesTable, etl.IdentityLoadFunc, etl.TransformArgs{Quit: make(chan struct{})}); err != nil {
+		return err
+	}
+	v.flatLeaves = flatLeaves
+	return nil
+}
+
+func (v *verkleVerifier) compareLeaves(tx kv.Tx) error {
+	c, err := tx.Cursor(kv.PlainState)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var incarnation uint64
+	for k, value, err := c.First(); k != nil; k, value, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if len(k) == common.AddressLength {
+			var acc accounts.Account
+			if err := acc.DecodeForStorage(value); err != nil {
+				return err
+			}
+			incarnation = acc.Incarnation
+			if err := v.verifyAccount(tx, common.BytesToAddress(k), acc); err != nil {
+				return err
+			}
+		} else {
+			address, storageIncarnation, slot := dbutils.PlainParseCompositeStorageKey(k)
+			// Storage of self-destructed incarnations is not in the tree
+			if storageIncarnation != incarnation {
+				continue
+			}
+			formatted := make([]byte, 32)
+			vtree.Int256ToVerkleFormat(new(uint256.Int).SetBytes(value), formatted)
+			if err := v.verifyLeaf(address, "storage", vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(slot[:])), formatted); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-v.logInterval.C:
+			log.Info("Verifying verkle leaves", "leaves", v.leaves, "key", common.Bytes2Hex(k))
+		default:
+		}
+	}
+	return nil
+}
+
+func (v *verkleVerifier) verifyAccount(tx kv.Tx, address common.Address, acc accounts.Account) error {
+	var code []byte
+	if !acc.IsEmptyCodeHash() {
+		codeHash, err := tx.GetOne(kv.PlainContractCode, dbutils.PlainGenerateStoragePrefix(address[:], acc.Incarnation))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(codeHash, acc.CodeHash[:]) {
+			log.Warn("Contract code hash differs from the account", "address", address, "account", acc.CodeHash, "code", common.Bytes2Hex(codeHash))
+		}
+		if code, err = tx.GetOne(kv.Code, acc.CodeHash[:]); err != nil {
+			return err
+		}
+		chunkedCode := vtree.ChunkifyCode(code)
+		for i, key := range vtree.GetTreeKeyCodeChunks(address[:], len(chunkedCode)/32) {
+			if err := v.verifyLeaf(address, "code", key, chunkedCode[i*32:(i+1)*32]); err != nil {
+				return err
+			}
+		}
+	}
+	keys, values := verkledb.AccountLeaves(vtree.GetTreeKeyVersion(address[:]), uint64(len(code)), acc)
+	for i, key := range keys {
+		if err := v.verifyLeaf(address, "account", key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *verkleVerifier) verifyLeaf(address common.Address, kind string, key, want []byte) error {
+	have, err := v.root.Get(key, v.resolve)
+	if err != nil {
+		return err
+	}
+	v.leaves++
+	// Leaf values are read back from the database padded to 32 bytes
+	padded := make([]byte, 32)
+	copy(padded, want)
+	// Empty values are not loaded, so the value is kept along with the key
+	if err := v.flatKeys.Collect(key, padded); err != nil {
+		return err
+	}
+	if have == nil || !bytes.Equal(have, padded) {
+		v.badLeaves++
+		log.Warn("Verkle leaf differs from the flat state", "kind", kind, "address", address, "key", common.Bytes2Hex(key), "have", common.Bytes2Hex(have), "want", common.Bytes2Hex(padded))
+	}
+	if v.leaves%maxResolvedLeaves == 0 {
+		return v.loadRoot()
+	}
+	return nil
+}
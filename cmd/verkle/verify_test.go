@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+// writeVerifiedState writes two accounts, one of them a contract with a storage slot, to the flat state and to
+// the tree of block 1, which is the execution progress. extra is called on the tree before it is committed.
+func writeVerifiedState(t *testing.T, tx kv.RwTx, extra func(tree *verkledb.VerkleTree)) common.Hash {
+	tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+
+	eoa := common.HexToAddress("0xe0a")
+	contract := common.HexToAddress("0xc0de")
+	code := common.FromHex("0x6001600055600160015560016002556001600355")
+	slot := common.HexToHash("0x01")
+	value := uint256.NewInt(42)
+
+	eoaAccount := accounts.NewAccount()
+	eoaAccount.Balance.SetUint64(1000)
+	eoaAccount.Nonce = 3
+	contractAccount := accounts.NewAccount()
+	contractAccount.Balance.SetUint64(1)
+	contractAccount.Incarnation = 1
+	contractAccount.CodeHash = crypto.Keccak256Hash(code)
+	for address, acc := range map[common.Address]accounts.Account{eoa: eoaAccount, contract: contractAccount} {
+		encoded := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(encoded)
+		require.NoError(t, tx.Put(kv.PlainState, address[:], encoded))
+	}
+	require.NoError(t, tx.Put(kv.Code, contractAccount.CodeHash[:], code))
+	require.NoError(t, tx.Put(kv.PlainContractCode, dbutils.PlainGenerateStoragePrefix(contract[:], 1), contractAccount.CodeHash[:]))
+	require.NoError(t, tx.Put(kv.PlainState, dbutils.PlainGenerateCompositeStorageKey(contract[:], 1, slot[:]), value.Bytes()))
+
+	require.NoError(t, tree.UpdateAccount(vtree.GetTreeKeyVersion(eoa[:]), 0, eoaAccount))
+	require.NoError(t, tree.UpdateAccount(vtree.GetTreeKeyVersion(contract[:]), uint64(len(code)), contractAccount))
+	chunkedCode := vtree.ChunkifyCode(code)
+	var chunks [][]byte
+	for i := 0; i < len(chunkedCode); i += 32 {
+		chunks = append(chunks, chunkedCode[i:i+32])
+	}
+	require.NoError(t, tree.WriteContractCodeChunks(vtree.GetTreeKeyCodeChunks(contract[:], len(chunks)), chunks))
+	formatted := make([]byte, 32)
+	vtree.Int256ToVerkleFormat(value, formatted)
+	require.NoError(t, tree.Insert(vtree.GetTreeKeyStorageSlot(contract[:], new(uint256.Int).SetBytes(slot[:])), formatted))
+	if extra != nil {
+		extra(tree)
+	}
+
+	root, err := tree.Commit()
+	require.NoError(t, err)
+	require.NoError(t, verkledb.WriteVerkleRoot(tx, 1, root))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 1))
+	return root
+}
+
+func TestVerifyVerkleTree(t *testing.T) {
+	db := verkledb.NewMemDB()
+	defer db.Close()
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	root := writeVerifiedState(t, tx, nil)
+
+	require.NoError(t, verifyVerkleTree(tx, tx, t.TempDir(), -1, common.Hash{}))
+	require.NoError(t, verifyVerkleTree(tx, tx, t.TempDir(), -1, root))
+	// The tree of another block is only checked if it has a root
+	require.NoError(t, verkledb.WriteVerkleRoot(tx, 0, root))
+	require.NoError(t, verifyVerkleTree(tx, tx, t.TempDir(), 0, common.Hash{}))
+	require.Error(t, verifyVerkleTree(tx, tx, t.TempDir(), 2, common.Hash{}))
+}
+
+func TestVerifyVerkleTreeCorruptedNode(t *testing.T) {
+	db := verkledb.NewMemDB()
+	defer db.Close()
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	root := writeVerifiedState(t, tx, nil)
+
+	// Change a value of a leaf node without updating its commitment
+	c, err := tx.RwCursor(verkledb.VerkleTrie)
+	require.NoError(t, err)
+	defer c.Close()
+	var corrupted bool
+	for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+		require.NoError(t, err)
+		if common.BytesToHash(k) == root {
+			continue
+		}
+		v = common.CopyBytes(v)
+		v[len(v)-1] ^= 0xff
+		require.NoError(t, c.Put(k, v))
+		corrupted = true
+		break
+	}
+	require.True(t, corrupted)
+
+	err = verifyVerkleTree(tx, tx, t.TempDir(), -1, common.Hash{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "problems")
+}
+
+func TestVerifyVerkleTreeExtraLeaf(t *testing.T) {
+	db := verkledb.NewMemDB()
+	defer db.Close()
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	missing := common.HexToAddress("0xdead")
+	root := writeVerifiedState(t, tx, func(tree *verkledb.VerkleTree) {
+		// The storage slot of an account which is not in the flat state
+		require.NoError(t, tree.Insert(vtree.GetTreeKeyStorageSlot(missing[:], uint256.NewInt(7)), common.LeftPadBytes([]byte{1}, 32)))
+	})
+
+	// Every node is sound and every leaf of the flat state is in the tree, only the extra leaf is wrong
+	err = verifyVerkleTree(tx, tx, t.TempDir(), -1, root)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 problems")
+}
@@ -4,23 +4,22 @@ import (
 	"time"
 
 	"github.com/ledgerwatch/erigon-lib/etl"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
 func GenerateVerkleTree(cfg optionsCfg) error {
 	start := time.Now()
-	db, err := mdbx.Open(cfg.stateDb, log.Root(), true)
+	db, err := verkledb.Open(cfg.stateDb, log.Root(), true)
 	if err != nil {
 		log.Error("Error while opening database", "err", err.Error())
 		return err
 	}
 	defer db.Close()
 
-	vDb, err := mdbx.Open(cfg.verkleDb, log.Root(), false)
+	vDb, err := verkledb.Open(cfg.verkleDb, log.Root(), false)
 	if err != nil {
 		log.Error("Error while opening db transaction", "err", err.Error())
 		return err
@@ -43,7 +42,10 @@ func GenerateVerkleTree(cfg optionsCfg) error {
 		return err
 	}
 
-	verkleWriter := verkledb.NewVerkleTreeWriter(vTx, cfg.tmpdir)
+	verkleWriter, err := verkledb.NewBufferedVerkleTree(vTx, common.Hash{}, cfg.tmpdir)
+	if err != nil {
+		return err
+	}
 
 	if err := regeneratePedersenAccounts(vTx, tx, cfg, verkleWriter); err != nil {
 		return err
@@ -62,11 +64,12 @@ func GenerateVerkleTree(cfg optionsCfg) error {
 	log.Info("Started Verkle Tree creation")
 
 	var root common.Hash
-	if root, err = verkleWriter.CommitVerkleTreeFromScratch(); err != nil {
+	if root, err = verkleWriter.CommitFromScratch(int(cfg.workersCount)); err != nil {
 		return err
 	}
 
 	log.Info("Verkle Tree Generation completed", "elapsed", time.Since(start), "root", common.Bytes2Hex(root[:]))
+	logTreeKeyStats()
 
 	var progress uint64
 	if progress, err = stages.GetStageProgress(tx, stages.Execution); err != nil {
@@ -5,8 +5,10 @@ import (
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"golang.org/x/sync/errgroup"
 )
 
 type regeneratePedersenAccountsJob struct {
@@ -64,6 +66,37 @@ type regenerateIncrementalPedersenAccountsOut struct {
 
 const batchSize = 10000
 
+// collectOutputs runs collect on the outputs of the workers until out is closed.
+// After the first error the workers are cancelled and the remaining outputs are
+// dropped, so that no worker blocks on out. Wait returns the error.
+func collectOutputs[T any](cancelWorkers context.CancelFunc, out <-chan T, collect func(T) error) *errgroup.Group {
+	collector := new(errgroup.Group)
+	collector.Go(func() error {
+		defer debug.LogPanic()
+		var err error
+		for o := range out {
+			if err != nil {
+				continue
+			}
+			if err = collect(o); err != nil {
+				cancelWorkers()
+			}
+		}
+		return err
+	})
+	return collector
+}
+
+// sendJob sends the job to the workers, and returns false if they were cancelled.
+func sendJob[T any](ctx context.Context, jobs chan<- T, job T) bool {
+	select {
+	case jobs <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func pedersenAccountWorker(ctx context.Context, logPrefix string, in chan *regeneratePedersenAccountsJob, out chan *regeneratePedersenAccountsOut) {
 	var job *regeneratePedersenAccountsJob
 	var ok bool
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectOutputsError(t *testing.T) {
+	jobs := make(chan int, 1)
+	out := make(chan int, 1)
+	wg := new(sync.WaitGroup)
+	ctx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				out <- job
+			}
+		}()
+	}
+
+	errBad := errors.New("bad output")
+	collected := 0
+	collector := collectOutputs(cancelWorkers, out, func(o int) error {
+		if o == 10 {
+			return errBad
+		}
+		collected++
+		return nil
+	})
+	sent := 0
+	for i := 0; i < 1000; i++ {
+		if !sendJob(ctx, jobs, i) {
+			break
+		}
+		sent++
+	}
+	close(jobs)
+	wg.Wait()
+	close(out)
+
+	require.ErrorIs(t, collector.Wait(), errBad)
+	require.Less(t, sent, 1000)
+	require.Less(t, collected, sent)
+}
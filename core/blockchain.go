@@ -329,6 +329,41 @@ func ExecuteBlockEphemerally(
 	return execRs, nil
 }
 
+// ExecuteBlockStateless runs a post-Martin block without local state. Reads are served from the partial
+// verkle tree of the block witness, which is verified against the root of the parent block.
+// Execution fails if it touches a key that the witness does not cover. The post-state root is not checked.
+func ExecuteBlockStateless(
+	chainConfig *params.ChainConfig,
+	vmConfig *vm.Config,
+	blockHashFunc func(n uint64) common.Hash,
+	engine consensus.Engine,
+	block *types.Block,
+	parent *types.Header,
+	epochReader consensus.EpochReader,
+	chainReader consensus.ChainHeaderReader,
+) (*EphemeralExecResult, error) {
+	header := block.Header()
+	if !chainConfig.IsMartin(header.Number.Uint64()) {
+		return nil, fmt.Errorf("stateless execution of block %d before Martin", header.Number.Uint64())
+	}
+	if !header.Verkle {
+		return nil, fmt.Errorf("block %d has no verkle witness", header.Number.Uint64())
+	}
+	stateReader, err := state.NewStatelessVerkleReader(parent.Root, header.VerkleProof, header.VerkleKeyVals)
+	if err != nil {
+		return nil, fmt.Errorf("verkle witness of block %d: %w", header.Number.Uint64(), err)
+	}
+	execRs, err := ExecuteBlockEphemerally(chainConfig, vmConfig, blockHashFunc, engine, block, stateReader, state.NewNoopWriter(), epochReader, chainReader, false, nil)
+	// An incomplete witness is the root cause of whatever mismatch it leads to
+	if witnessErr := stateReader.Err(); witnessErr != nil {
+		return nil, fmt.Errorf("stateless execution of block %d: %w", header.Number.Uint64(), witnessErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return execRs, nil
+}
+
 // ExecuteBlockEphemerallyBor runs a block from provided stateReader and
 // writes the result to the provided stateWriter
 func ExecuteBlockEphemerallyBor(
@@ -29,6 +29,7 @@ import (
 	"sync"
 
 	"github.com/c2h5oh/datasize"
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
@@ -45,6 +46,7 @@ import (
 	"github.com/ledgerwatch/erigon/params/networkname"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -329,24 +331,7 @@ func (g *Genesis) ToBlock() (*types.Block, *state.IntraBlockState, error) {
 		defer tx.Rollback()
 		r, w := state.NewDbStateReader(tx), state.NewDbStateWriter(tx, 0)
 		statedb = state.New(r)
-		for addr, account := range g.Alloc {
-			balance, overflow := uint256.FromBig(account.Balance)
-			if overflow {
-				panic("overflow at genesis allocs")
-			}
-			statedb.AddBalance(addr, balance)
-			statedb.SetCode(addr, account.Code)
-			statedb.SetNonce(addr, account.Nonce)
-			for key, value := range account.Storage {
-				key := key
-				val := uint256.NewInt(0).SetBytes(value.Bytes())
-				statedb.SetState(addr, &key, *val)
-			}
-
-			if len(account.Code) > 0 || len(account.Storage) > 0 {
-				statedb.SetIncarnation(addr, state.FirstContractIncarnation)
-			}
-		}
+		g.applyAlloc(statedb)
 		if err := statedb.FinalizeTx(&params.Rules{}, w); err != nil {
 			panic(err)
 		}
@@ -356,6 +341,14 @@ func (g *Genesis) ToBlock() (*types.Block, *state.IntraBlockState, error) {
 		}
 	}()
 	wg.Wait()
+	if g.Config != nil && g.Config.IsMartin(0) {
+		// Chains that are verkle from genesis commit to the allocation with a verkle tree
+		verkleRoot := verkle.New()
+		if err := WriteVerkleAlloc(verkleRoot, nil, nil, g.Alloc); err != nil {
+			return nil, nil, err
+		}
+		root = verkleRoot.ComputeCommitment().Bytes()
+	}
 	decodeSeal := func(in []byte) (seal []rlp.RawValue) {
 		if len(in) == 0 {
 			return nil
@@ -401,6 +394,61 @@ func (g *Genesis) ToBlock() (*types.Block, *state.IntraBlockState, error) {
 	return types.NewBlock(head, nil, nil, nil), statedb, nil
 }
 
+func (g *Genesis) applyAlloc(statedb *state.IntraBlockState) {
+	for addr, account := range g.Alloc {
+		balance, overflow := uint256.FromBig(account.Balance)
+		if overflow {
+			panic("overflow at genesis allocs")
+		}
+		statedb.AddBalance(addr, balance)
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		for key, value := range account.Storage {
+			key := key
+			val := uint256.NewInt(0).SetBytes(value.Bytes())
+			statedb.SetState(addr, &key, *val)
+		}
+
+		if len(account.Code) > 0 || len(account.Storage) > 0 {
+			statedb.SetIncarnation(addr, state.FirstContractIncarnation)
+		}
+	}
+}
+
+// WriteVerkleAlloc writes the allocation into the verkle tree, leaves are encoded the same way as by post-Martin execution.
+// The Pedersen lookups of the leaves are kept by keys, unless it is nil.
+func WriteVerkleAlloc(root verkle.VerkleNode, resolver verkle.NodeResolverFn, keys state.VerkleTreeKeys, alloc GenesisAlloc) error {
+	statedb := state.New(verkledb.NewStateReader(root, resolver))
+	(&Genesis{Alloc: alloc}).applyAlloc(statedb)
+	writer := state.NewVerkleStateWriter(root, resolver)
+	if keys != nil {
+		writer.WithLookups(keys)
+	}
+	return statedb.FinalizeTx(&params.Rules{}, writer)
+}
+
+// writeVerkleGenesis stores the verkle tree of the allocation, which is where the VerkleTrie stage starts from
+func (g *Genesis) writeVerkleGenesis(tx kv.RwTx, block *types.Block) error {
+	if err := verkledb.InitDB(tx); err != nil {
+		return err
+	}
+	tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+	if err != nil {
+		return err
+	}
+	if err := WriteVerkleAlloc(tree.Node(), tree.Resolve, verkledb.NewTreeKeys(tx, true), g.Alloc); err != nil {
+		return err
+	}
+	root, err := tree.Commit()
+	if err != nil {
+		return err
+	}
+	if root != block.Root() {
+		return fmt.Errorf("verkle genesis root mismatch, have %x, want %x", root, block.Root())
+	}
+	return verkledb.WriteVerkleRoot(tx, 0, root)
+}
+
 func (g *Genesis) WriteGenesisState(tx kv.RwTx) (*types.Block, *state.IntraBlockState, error) {
 	block, statedb, err := g.ToBlock()
 	if err != nil {
@@ -432,6 +480,11 @@ func (g *Genesis) WriteGenesisState(tx kv.RwTx) (*types.Block, *state.IntraBlock
 	if err := blockWriter.WriteHistory(); err != nil {
 		return nil, statedb, fmt.Errorf("cannot write history: %w", err)
 	}
+	if g.Config != nil && g.Config.IsMartin(0) {
+		if err := g.writeVerkleGenesis(tx, block); err != nil {
+			return nil, statedb, fmt.Errorf("cannot write verkle tree: %w", err)
+		}
+	}
 	return block, statedb, nil
 }
 
@@ -766,6 +819,15 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// VerkleDeveloperGenesisBlock returns the genesis block of the dev chain which is verkle from block 0.
+func VerkleDeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
+	genesis := DeveloperGenesisBlock(period, faucet)
+	config := *params.VerkleDevChainConfig
+	config.Clique = &params.CliqueConfig{Period: period, Epoch: params.VerkleDevChainConfig.Clique.Epoch}
+	genesis.Config = &config
+	return genesis
+}
+
 func readPrealloc(filename string) GenesisAlloc {
 	f, err := allocs.Open(filename)
 	if err != nil {
@@ -2,12 +2,17 @@ package core
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/params/networkname"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,3 +51,49 @@ func TestCommitGenesisIdempotency(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, uint64(2), seq)
 }
+
+func TestVerkleGenesis(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	faucet := common.HexToAddress("0xfa")
+	contract := common.HexToAddress("0xc0de")
+	code := common.FromHex("0x6001600055600160015560016002556001600355")
+	slot := common.HexToHash("0x01")
+
+	genesis := VerkleDeveloperGenesisBlock(0, faucet)
+	genesis.Alloc = GenesisAlloc{
+		faucet:   {Balance: big.NewInt(1_000_000)},
+		contract: {Balance: big.NewInt(1), Code: code, Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x2a")}},
+	}
+	merkleGenesis := DeveloperGenesisBlock(0, faucet)
+	merkleGenesis.Alloc = genesis.Alloc
+	merkleBlock, _, err := merkleGenesis.ToBlock()
+	require.NoError(t, err)
+
+	_, block, err := WriteGenesisBlock(tx, genesis, nil, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, merkleBlock.Root(), block.Root())
+
+	// The tree is stored in chaindata at block 0
+	root, err := verkledb.ReadVerkleRoot(tx, 0)
+	require.NoError(t, err)
+	require.Equal(t, block.Root(), root)
+	tree, err := verkledb.NewVerkleTree(tx, root)
+	require.NoError(t, err)
+	reader := verkledb.NewStateReader(tree.Node(), tree.Resolve)
+
+	acc, err := reader.ReadAccountData(faucet)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1_000_000), acc.Balance.Uint64())
+	// Accounts of the tree are seen by execution like the ones of the flat state
+	require.Equal(t, uint64(1_000_000), state.New(reader).GetBalance(faucet).Uint64())
+
+	acc, err = reader.ReadAccountData(contract)
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256Hash(code), acc.CodeHash)
+	readCode, err := reader.ReadAccountCode(contract, acc.Incarnation, acc.CodeHash)
+	require.NoError(t, err)
+	require.Equal(t, code, readCode)
+	value, err := reader.ReadAccountStorage(contract, acc.Incarnation, &slot)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x2a}, value)
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+// VerkleKeyRecorder is a wrapper for an instance of type StateReader
+// It remembers the verkle tree keys of everything read through it, which is the witness of the execution
+type VerkleKeyRecorder struct {
+	r        StateReader
+	accounts map[common.Address]struct{}
+	code     map[common.Address]struct{}
+	keys     map[string]struct{}
+}
+
+// NewVerkleKeyRecorder wraps a given state reader into the recorder
+func NewVerkleKeyRecorder(r StateReader) *VerkleKeyRecorder {
+	return &VerkleKeyRecorder{
+		r:        r,
+		accounts: map[common.Address]struct{}{},
+		code:     map[common.Address]struct{}{},
+		keys:     map[string]struct{}{},
+	}
+}
+
+// Keys returns the recorded tree keys in ascending order
+func (vr *VerkleKeyRecorder) Keys() [][]byte {
+	keys := make([][]byte, 0, len(vr.keys))
+	for key := range vr.keys {
+		keys = append(keys, []byte(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys
+}
+
+func (vr *VerkleKeyRecorder) recordAccount(address common.Address) {
+	if _, ok := vr.accounts[address]; ok {
+		return
+	}
+	vr.accounts[address] = struct{}{}
+	// All header leaves share the stem of the version leaf
+	versionKey := vtree.GetTreeKeyVersion(address[:])
+	for _, leaf := range []byte{vtree.VersionLeafKey, vtree.BalanceLeafKey, vtree.NonceLeafKey, vtree.CodeKeccakLeafKey, vtree.CodeSizeLeafKey} {
+		key := common.CopyBytes(versionKey)
+		key[31] = leaf
+		vr.keys[string(key)] = struct{}{}
+	}
+}
+
+func (vr *VerkleKeyRecorder) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	vr.recordAccount(address)
+	return vr.r.ReadAccountData(address)
+}
+
+func (vr *VerkleKeyRecorder) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	vr.keys[string(vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(key[:])))] = struct{}{}
+	return vr.r.ReadAccountStorage(address, incarnation, key)
+}
+
+func (vr *VerkleKeyRecorder) ReadAccountCode(address common.Address, incarnation uint64, codeHash common.Hash) ([]byte, error) {
+	code, err := vr.r.ReadAccountCode(address, incarnation, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := vr.code[address]; !ok && len(code) > 0 {
+		vr.code[address] = struct{}{}
+		for _, key := range vtree.GetTreeKeyCodeChunks(address[:], len(vtree.ChunkifyCode(code))/32) {
+			vr.keys[string(key)] = struct{}{}
+		}
+	}
+	return code, nil
+}
+
+func (vr *VerkleKeyRecorder) ReadAccountCodeSize(address common.Address, incarnation uint64, codeHash common.Hash) (int, error) {
+	vr.recordAccount(address)
+	return vr.r.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+func (vr *VerkleKeyRecorder) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	return vr.r.ReadAccountIncarnation(address)
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+// ReadVerkleAccount assembles an account from the leaves of its header, nil means that the account does not exist
+func ReadVerkleAccount(get func([]byte) ([]byte, error), address common.Address) (*accounts.Account, error) {
+	versionKey := vtree.GetTreeKeyVersion(address[:])
+	leaf := func(suffix byte) ([]byte, error) {
+		key := common.CopyBytes(versionKey)
+		key[31] = suffix
+		return get(key)
+	}
+	balance, err := leaf(vtree.BalanceLeafKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := leaf(vtree.NonceLeafKey)
+	if err != nil {
+		return nil, err
+	}
+	codeHash, err := leaf(vtree.CodeKeccakLeafKey)
+	if err != nil {
+		return nil, err
+	}
+	// Every account has a code hash, deleted leaves are zeroed rather than removed from the tree
+	if len(codeHash) != common.HashLength || common.BytesToHash(codeHash) == (common.Hash{}) {
+		return nil, nil
+	}
+
+	acc := accounts.NewAccount()
+	// Like an account decoded from the flat state, otherwise IntraBlockState zeroes its balance
+	acc.Initialised = true
+	acc.Balance.SetBytes(verkleToBigEndian(balance))
+	if len(nonce) >= 8 {
+		acc.Nonce = binary.LittleEndian.Uint64(nonce)
+	}
+	copy(acc.CodeHash[:], codeHash)
+	// The tree does not keep incarnations
+	if !acc.IsEmptyCodeHash() {
+		acc.Incarnation = FirstContractIncarnation
+	}
+	return &acc, nil
+}
+
+// ReadVerkleStorage returns the value of a storage slot with the leading zeroes stripped, like the flat state keeps it
+func ReadVerkleStorage(get func([]byte) ([]byte, error), address common.Address, key *common.Hash) ([]byte, error) {
+	value, err := get(vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(key[:])))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return new(uint256.Int).SetBytes(verkleToBigEndian(value)).Bytes(), nil
+}
+
+// ReadVerkleCodeSize returns the code size of an account, 0 if it has no code or does not exist
+func ReadVerkleCodeSize(get func([]byte) ([]byte, error), address common.Address) (int, error) {
+	codeSize, err := get(vtree.GetTreeKeyCodeSize(address[:]))
+	if err != nil || len(codeSize) < 8 {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint64(codeSize)), nil
+}
+
+// ReadVerkleCode joins the code chunks of an account and checks them against the code hash
+func ReadVerkleCode(get func([]byte) ([]byte, error), address common.Address, codeSize int, codeHash common.Hash) ([]byte, error) {
+	chunkKeys := vtree.GetTreeKeyCodeChunks(address[:], (codeSize+30)/31)
+	code := make([]byte, 0, len(chunkKeys)*31)
+	for _, chunkKey := range chunkKeys {
+		chunk, err := get(chunkKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunk) != 32 {
+			return nil, fmt.Errorf("invalid code chunk of %x: %x", address, chunk)
+		}
+		// First byte of the chunk is the offset of the first instruction
+		code = append(code, chunk[1:]...)
+	}
+	code = code[:codeSize]
+	if crypto.Keccak256Hash(code) != codeHash {
+		return nil, fmt.Errorf("code of %x in verkle tree does not match hash %x", address, codeHash)
+	}
+	return code, nil
+}
+
+// verkleToBigEndian reverses the little endian encoding of numbers in verkle leaves
+func verkleToBigEndian(value []byte) []byte {
+	reversed := make([]byte, len(value))
+	for i, b := range value {
+		reversed[len(value)-i-1] = b
+	}
+	return reversed
+}
@@ -0,0 +1,289 @@
+package state
+
+import (
+	"encoding/binary"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+var _ StateWriter = (*VerkleStateWriter)(nil)
+
+// VerkleTreeKeys derives the tree keys written by VerkleStateWriter and keeps their Pedersen lookups.
+// Storage slots are scattered over the tree, the lookups are the only way to find the storage of an account.
+type VerkleTreeKeys interface {
+	VersionKey(address []byte) ([]byte, error)
+	StorageKey(address []byte, storageKey *uint256.Int) ([]byte, error)
+	CodeChunkKeys(address []byte, count int) ([][]byte, error)
+	// DeleteStorageKeys removes the lookups of the storage slots of the address and returns their tree keys
+	DeleteStorageKeys(address []byte) ([][]byte, error)
+	// DeleteCodeKeys removes the lookups of the code chunks of the address and returns their tree keys
+	DeleteCodeKeys(address []byte) ([][]byte, error)
+}
+
+// VerkleStateWriter applies the changes of execution directly to the verkle tree, so that the state root
+// of a block after the Martin fork comes out of execution. The leaves are encoded the same way as by the
+// transition from the plain state. Persisting the modified nodes is up to the owner of the tree.
+type VerkleStateWriter struct {
+	root     verkle.VerkleNode
+	resolver verkle.NodeResolverFn
+	keys     VerkleTreeKeys // nil if the Pedersen lookups of the written keys are not kept
+}
+
+func NewVerkleStateWriter(root verkle.VerkleNode, resolver verkle.NodeResolverFn) *VerkleStateWriter {
+	return &VerkleStateWriter{
+		root:     root,
+		resolver: resolver,
+	}
+}
+
+// WithLookups makes the writer keep the Pedersen lookups of the keys it writes, like the transition does.
+// Without them the storage of deleted accounts can't be removed.
+func (w *VerkleStateWriter) WithLookups(keys VerkleTreeKeys) *VerkleStateWriter {
+	w.keys = keys
+	return w
+}
+
+// Root returns the commitment of the tree with all the changes written so far
+func (w *VerkleStateWriter) Root() common.Hash {
+	return w.root.ComputeCommitment().Bytes()
+}
+
+func (w *VerkleStateWriter) insert(key, value []byte) error {
+	return w.root.Insert(key, value, w.resolver)
+}
+
+// delete removes a leaf, absent leaves are skipped
+func (w *VerkleStateWriter) delete(key []byte) error {
+	value, err := w.root.Get(key, w.resolver)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	return w.root.Delete(key, w.resolver)
+}
+
+func headerLeafKey(versionKey []byte, leaf byte) []byte {
+	key := common.CopyBytes(versionKey)
+	key[31] = leaf
+	return key
+}
+
+func (w *VerkleStateWriter) versionKey(address common.Address) ([]byte, error) {
+	if w.keys != nil {
+		return w.keys.VersionKey(address[:])
+	}
+	return vtree.GetTreeKeyVersion(address[:]), nil
+}
+
+func (w *VerkleStateWriter) codeChunkKeys(address common.Address, count int) ([][]byte, error) {
+	if w.keys != nil {
+		return w.keys.CodeChunkKeys(address[:], count)
+	}
+	return vtree.GetTreeKeyCodeChunks(address[:], count), nil
+}
+
+func (w *VerkleStateWriter) storageKey(address common.Address, key *common.Hash) ([]byte, error) {
+	slot := new(uint256.Int).SetBytes(key[:])
+	if w.keys != nil {
+		return w.keys.StorageKey(address[:], slot)
+	}
+	return vtree.GetTreeKeyStorageSlot(address[:], slot), nil
+}
+
+func (w *VerkleStateWriter) codeSize(address common.Address) (int, error) {
+	return ReadVerkleCodeSize(func(key []byte) ([]byte, error) {
+		return w.root.Get(key, w.resolver)
+	}, address)
+}
+
+func (w *VerkleStateWriter) UpdateAccountData(address common.Address, original, account *accounts.Account) error {
+	versionKey, err := w.versionKey(address)
+	if err != nil {
+		return err
+	}
+	var nonce [32]byte
+	binary.LittleEndian.PutUint64(nonce[:], account.Nonce)
+
+	if err := w.insert(versionKey, []byte{0}); err != nil {
+		return err
+	}
+	if err := w.insert(headerLeafKey(versionKey, vtree.NonceLeafKey), nonce[:]); err != nil {
+		return err
+	}
+	if err := w.insert(headerLeafKey(versionKey, vtree.CodeKeccakLeafKey), common.CopyBytes(account.CodeHash[:])); err != nil {
+		return err
+	}
+	if err := w.insert(headerLeafKey(versionKey, vtree.BalanceLeafKey), uint256ToVerkleFormat(&account.Balance)); err != nil {
+		return err
+	}
+	// Code size of contracts is written together with the code, which comes before the account data
+	if account.IsEmptyCodeHash() {
+		var codeSize [32]byte
+		return w.insert(headerLeafKey(versionKey, vtree.CodeSizeLeafKey), codeSize[:])
+	}
+	return nil
+}
+
+func (w *VerkleStateWriter) UpdateAccountCode(address common.Address, incarnation uint64, codeHash common.Hash, code []byte) error {
+	// Chunks of the code of a previous incarnation past the end of the new code are dropped
+	prevCodeSize, err := w.codeSize(address)
+	if err != nil {
+		return err
+	}
+	chunkedCode := vtree.ChunkifyCode(code)
+	chunkCount := len(chunkedCode) / 32
+	if prevChunkCount := (prevCodeSize + 30) / 31; prevChunkCount > chunkCount {
+		for _, chunkKey := range vtree.GetTreeKeyCodeChunks(address[:], prevChunkCount)[chunkCount:] {
+			if err := w.delete(chunkKey); err != nil {
+				return err
+			}
+		}
+	}
+	chunkKeys, err := w.codeChunkKeys(address, chunkCount)
+	if err != nil {
+		return err
+	}
+	for i, chunkKey := range chunkKeys {
+		if err := w.insert(chunkKey, common.CopyBytes(chunkedCode[i*32:(i+1)*32])); err != nil {
+			return err
+		}
+	}
+	var codeSize [32]byte
+	binary.LittleEndian.PutUint64(codeSize[:], uint64(len(code)))
+	return w.insert(vtree.GetTreeKeyCodeSize(address[:]), codeSize[:])
+}
+
+// DeleteAccount removes the header, the code and, if the writer keeps lookups, the storage of the account
+func (w *VerkleStateWriter) DeleteAccount(address common.Address, original *accounts.Account) error {
+	if w.keys != nil {
+		storageKeys, err := w.keys.DeleteStorageKeys(address[:])
+		if err != nil {
+			return err
+		}
+		if err := w.deleteAll(storageKeys); err != nil {
+			return err
+		}
+		codeKeys, err := w.keys.DeleteCodeKeys(address[:])
+		if err != nil {
+			return err
+		}
+		if err := w.deleteAll(codeKeys); err != nil {
+			return err
+		}
+	}
+	versionKey, err := w.versionKey(address)
+	if err != nil {
+		return err
+	}
+	codeSize, err := w.codeSize(address)
+	if err != nil {
+		return err
+	}
+	if err := w.deleteAll(vtree.GetTreeKeyCodeChunks(address[:], (codeSize+30)/31)); err != nil {
+		return err
+	}
+	for _, leaf := range []byte{vtree.VersionLeafKey, vtree.BalanceLeafKey, vtree.NonceLeafKey, vtree.CodeKeccakLeafKey, vtree.CodeSizeLeafKey} {
+		if err := w.delete(headerLeafKey(versionKey, leaf)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *VerkleStateWriter) deleteAll(keys [][]byte) error {
+	for _, key := range keys {
+		if err := w.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *VerkleStateWriter) WriteAccountStorage(address common.Address, incarnation uint64, key *common.Hash, original, value *uint256.Int) error {
+	if *original == *value {
+		return nil
+	}
+	slotKey, err := w.storageKey(address, key)
+	if err != nil {
+		return err
+	}
+	if value.IsZero() {
+		return w.delete(slotKey)
+	}
+	return w.insert(slotKey, uint256ToVerkleFormat(value))
+}
+
+// CreateContract removes the storage of a previous incarnation of the contract, the code is replaced by UpdateAccountCode
+func (w *VerkleStateWriter) CreateContract(address common.Address) error {
+	if w.keys == nil {
+		return nil
+	}
+	storageKeys, err := w.keys.DeleteStorageKeys(address[:])
+	if err != nil {
+		return err
+	}
+	return w.deleteAll(storageKeys)
+}
+
+// uint256ToVerkleFormat encodes a number as a 32 byte little endian leaf value
+func uint256ToVerkleFormat(x *uint256.Int) []byte {
+	value := x.Bytes32()
+	return verkleToBigEndian(value[:])
+}
+
+var _ WriterWithChangeSets = (*VerkleTeeWriter)(nil)
+
+// VerkleTeeWriter passes the changes to the wrapped writer and mirrors them into the verkle tree,
+// for tools which keep the flat state and need the verkle root of it as well
+type VerkleTeeWriter struct {
+	WriterWithChangeSets
+	verkle *VerkleStateWriter
+}
+
+func NewVerkleTeeWriter(w WriterWithChangeSets, verkle *VerkleStateWriter) *VerkleTeeWriter {
+	return &VerkleTeeWriter{
+		WriterWithChangeSets: w,
+		verkle:               verkle,
+	}
+}
+
+func (w *VerkleTeeWriter) UpdateAccountData(address common.Address, original, account *accounts.Account) error {
+	if err := w.WriterWithChangeSets.UpdateAccountData(address, original, account); err != nil {
+		return err
+	}
+	return w.verkle.UpdateAccountData(address, original, account)
+}
+
+func (w *VerkleTeeWriter) UpdateAccountCode(address common.Address, incarnation uint64, codeHash common.Hash, code []byte) error {
+	if err := w.WriterWithChangeSets.UpdateAccountCode(address, incarnation, codeHash, code); err != nil {
+		return err
+	}
+	return w.verkle.UpdateAccountCode(address, incarnation, codeHash, code)
+}
+
+func (w *VerkleTeeWriter) DeleteAccount(address common.Address, original *accounts.Account) error {
+	if err := w.WriterWithChangeSets.DeleteAccount(address, original); err != nil {
+		return err
+	}
+	return w.verkle.DeleteAccount(address, original)
+}
+
+func (w *VerkleTeeWriter) WriteAccountStorage(address common.Address, incarnation uint64, key *common.Hash, original, value *uint256.Int) error {
+	if err := w.WriterWithChangeSets.WriteAccountStorage(address, incarnation, key, original, value); err != nil {
+		return err
+	}
+	return w.verkle.WriteAccountStorage(address, incarnation, key, original, value)
+}
+
+func (w *VerkleTeeWriter) CreateContract(address common.Address) error {
+	if err := w.WriterWithChangeSets.CreateContract(address); err != nil {
+		return err
+	}
+	return w.verkle.CreateContract(address)
+}
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gballet/go-verkle"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+// ErrVerkleWitnessMissingKey is returned when execution reads a tree key that is not covered by the block witness
+var ErrVerkleWitnessMissingKey = errors.New("key not covered by verkle witness")
+
+// StatelessVerkleReader serves state reads from the partial verkle tree of a block witness.
+// It has no access to local state, so every read has to be covered by the witness.
+type StatelessVerkleReader struct {
+	values map[string][]byte // covered keys, absent leaves have nil values
+	err    error             // first read of an uncovered key
+}
+
+// NewStatelessVerkleReader rebuilds the partial tree from the serialized multiproof and its key/values,
+// and checks the proof against the root of the parent state
+func NewStatelessVerkleReader(parentRoot common.Hash, proof []byte, keyVals []verkle.KeyValuePair) (*StatelessVerkleReader, error) {
+	if len(keyVals) == 0 {
+		return nil, fmt.Errorf("empty verkle witness")
+	}
+	values := make(map[string][]byte, len(keyVals))
+	normalized := make([]verkle.KeyValuePair, len(keyVals))
+	for i, kv := range keyVals {
+		if len(kv.Key) != 32 {
+			return nil, fmt.Errorf("invalid verkle key length %d", len(kv.Key))
+		}
+		normalized[i].Key = kv.Key
+		// RLP turns nil values of absent leaves into empty slices
+		if len(kv.Value) > 0 {
+			normalized[i].Value = kv.Value
+		}
+		values[string(kv.Key)] = normalized[i].Value
+	}
+
+	rootC := new(verkle.Point)
+	if err := rootC.SetBytes(parentRoot[:]); err != nil {
+		return nil, fmt.Errorf("invalid parent verkle root %x: %w", parentRoot, err)
+	}
+	deserialized, err := verkle.DeserializeProof(proof, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize verkle proof: %w", err)
+	}
+	tree, err := verkle.TreeFromProof(deserialized, rootC)
+	if err != nil {
+		return nil, fmt.Errorf("rebuild verkle tree from proof: %w", err)
+	}
+	cfg, err := verkle.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	pe, _, _ := verkle.GetCommitmentsForMultiproof(tree, deserialized.Keys)
+	if !verkle.VerifyVerkleProof(deserialized, pe.Cis, pe.Zis, pe.Yis, cfg) {
+		return nil, fmt.Errorf("verkle proof does not match parent root %x", parentRoot)
+	}
+	return &StatelessVerkleReader{values: values}, nil
+}
+
+// Err returns the first read of a key that the witness does not cover.
+// IntraBlockState swallows reader errors, so it has to be checked after execution.
+func (r *StatelessVerkleReader) Err() error {
+	return r.err
+}
+
+func (r *StatelessVerkleReader) get(key []byte) ([]byte, error) {
+	value, ok := r.values[string(key)]
+	if !ok {
+		if r.err == nil {
+			r.err = fmt.Errorf("%w: %x", ErrVerkleWitnessMissingKey, key)
+		}
+		return nil, r.err
+	}
+	return value, nil
+}
+
+func (r *StatelessVerkleReader) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	return ReadVerkleAccount(r.get, address)
+}
+
+func (r *StatelessVerkleReader) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	return ReadVerkleStorage(r.get, address, key)
+}
+
+func (r *StatelessVerkleReader) ReadAccountCode(address common.Address, incarnation uint64, codeHash common.Hash) ([]byte, error) {
+	if codeHash == emptyCodeHashH {
+		return nil, nil
+	}
+	codeSize, err := r.ReadAccountCodeSize(address, incarnation, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	chunkCount := (codeSize + 30) / 31
+	if chunkCount > len(r.values) {
+		// Can't be covered, don't derive keys for a bogus size
+		if r.err == nil {
+			r.err = fmt.Errorf("%w: %d code chunks of %x", ErrVerkleWitnessMissingKey, chunkCount, address)
+		}
+		return nil, r.err
+	}
+	return ReadVerkleCode(r.get, address, codeSize, codeHash)
+}
+
+func (r *StatelessVerkleReader) ReadAccountCodeSize(address common.Address, incarnation uint64, codeHash common.Hash) (int, error) {
+	return ReadVerkleCodeSize(r.get, address)
+}
+
+func (r *StatelessVerkleReader) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	return 0, nil
+}
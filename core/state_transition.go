@@ -30,6 +30,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
 )
 
 var emptyCodeHash = crypto.Keccak256Hash(nil)
@@ -388,6 +389,21 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, st.gas, gas)
 	}
 	st.gas -= gas
+	// Since Martin the headers of the sender and the recipient are a part of the verkle witness (EIP-4762)
+	if st.evm.ChainRules().IsMartin {
+		witness := st.evm.Witness()
+		witnessGas := witness.TouchAccountWrite(msg.From(), false, vtree.NonceLeafKey, vtree.BalanceLeafKey)
+		if !contractCreation {
+			witnessGas += witness.TouchAccountRead(st.to(), vtree.VersionLeafKey, vtree.CodeKeccakLeafKey, vtree.CodeSizeLeafKey)
+			if !msg.Value().IsZero() {
+				witnessGas += witness.TouchAccountWrite(st.to(), false, vtree.BalanceLeafKey)
+			}
+		}
+		if st.gas < witnessGas {
+			return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, st.gas, gas+witnessGas)
+		}
+		st.gas -= witnessGas
+	}
 
 	var bailout bool
 	// Gas bailout (for trace_call) should only be applied if there is not sufficient balance to perform value transfer
@@ -401,7 +417,6 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 	if st.evm.ChainRules().IsBerlin {
 		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(st.evm.ChainRules()), msg.AccessList())
 	}
-
 	var (
 		ret   []byte
 		vmerr error // vm errors do not effect consensus and are therefore not assigned to err
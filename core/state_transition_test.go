@@ -0,0 +1,46 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionWitnessGas(t *testing.T) {
+	martinConfig := *params.AllEthashProtocolChanges
+	martinConfig.MartinBlock = common.Big0
+
+	sender := common.HexToAddress("0x5e4de4")
+	recipient := common.HexToAddress("0x4ec1")
+	apply := func(config *params.ChainConfig, gasLimit uint64) (*ExecutionResult, error) {
+		_, tx := memdb.NewTestTx(t)
+		ibs := state.New(state.NewPlainStateReader(tx))
+		ibs.AddBalance(sender, uint256.NewInt(params.Ether))
+		blockCtx := vm.BlockContext{CanTransfer: CanTransfer, Transfer: Transfer}
+		evm := vm.NewEVM(blockCtx, vm.TxContext{Origin: sender}, ibs, config, vm.Config{})
+		msg := types.NewMessage(sender, &recipient, 0, uint256.NewInt(1), gasLimit, uint256.NewInt(0), nil, nil, nil, nil, false)
+		return ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64), true, false)
+	}
+
+	result, err := apply(params.AllEthashProtocolChanges, 100_000)
+	require.NoError(t, err)
+	require.Equal(t, params.TxGas, result.UsedGas)
+
+	// The nonce and balance of the sender are written, the recipient's header is read and its balance written
+	senderGas := params.WitnessBranchReadCost + 2*params.WitnessChunkReadCost + params.WitnessBranchWriteCost + 2*params.WitnessChunkWriteCost
+	recipientGas := params.WitnessBranchReadCost + 4*params.WitnessChunkReadCost + params.WitnessBranchWriteCost + params.WitnessChunkWriteCost
+	result, err = apply(&martinConfig, 100_000)
+	require.NoError(t, err)
+	require.Equal(t, params.TxGas+senderGas+recipientGas, result.UsedGas)
+
+	_, err = apply(&martinConfig, params.TxGas+senderGas)
+	require.ErrorIs(t, err, ErrIntrinsicGas)
+}
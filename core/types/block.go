@@ -196,7 +196,6 @@ func (h Header) EncodingSize() int {
 			}
 			encodingSize += len(h.VerkleProof)
 		}
-		encodingSize++
 
 		var tmpBuffer bytes.Buffer
 		if err := rlp.Encode(&tmpBuffer, h.VerkleKeyVals); err != nil {
@@ -237,11 +236,10 @@ func (h Header) EncodeRLP(w io.Writer) error {
 			}
 			encodingSize += len(h.VerkleProof)
 		}
-		encodingSize++
 
 		var tmpBuffer bytes.Buffer
 		if err := rlp.Encode(&tmpBuffer, h.VerkleKeyVals); err != nil {
-			return nil
+			return err
 		}
 		encodingSize += tmpBuffer.Len()
 	}
@@ -483,7 +481,7 @@ func (h Header) EncodeRLP(w io.Writer) error {
 		}
 
 		if err := rlp.Encode(w, h.VerkleKeyVals); err != nil {
-			return nil
+			return err
 		}
 	}
 
@@ -578,6 +576,20 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 		if !errors.Is(err, rlp.EOL) {
 			return fmt.Errorf("open accessTuple: %d %w", len(h.Seal), err)
 		}
+		// The seal consists of strings, so a trailing list is the verkle key values
+		// that follow the verkle proof
+		if n := len(h.Seal); n >= 2 {
+			if kind, _, _, err := rlp.Split(h.Seal[n-1]); err == nil && kind == rlp.List {
+				if h.VerkleProof, _, err = rlp.SplitString(h.Seal[n-2]); err != nil {
+					return fmt.Errorf("read VerkleProof: %w", err)
+				}
+				if err = rlp.DecodeBytes(h.Seal[n-1], &h.VerkleKeyVals); err != nil {
+					return fmt.Errorf("read VerkleKeyVals: %w", err)
+				}
+				h.Verkle = true
+				h.Seal = h.Seal[:n-2]
+			}
+		}
 	} else {
 		if b, err = s.Bytes(); err != nil {
 			return fmt.Errorf("read MixDigest: %w", err)
@@ -593,7 +605,9 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 			return fmt.Errorf("wrong size for Nonce: %d", len(b))
 		}
 		copy(h.Nonce[:], b)
-		if b, err = s.Uint256Bytes(); err != nil {
+		// BaseFee and the verkle proof are both optional strings, the verkle proof
+		// is the one followed by the verkle key values list
+		if b, err = s.Raw(); err != nil {
 			if errors.Is(err, rlp.EOL) {
 				h.BaseFee = nil
 				h.Eip1559 = false
@@ -604,19 +618,37 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 			}
 			return fmt.Errorf("read BaseFee: %w", err)
 		}
-		h.Eip1559 = true
-		h.BaseFee = new(big.Int).SetBytes(b)
-	}
-
-	if h.Verkle {
-		if h.VerkleProof, err = s.Bytes(); err != nil {
+		kind, _, err := s.Kind()
+		if err != nil && !errors.Is(err, rlp.EOL) {
 			return fmt.Errorf("read VerkleProof: %w", err)
 		}
-		rawKv, err := s.Raw()
-		if err != nil {
-			return err
+		if err == nil && kind == rlp.List {
+			if h.VerkleProof, _, err = rlp.SplitString(b); err != nil {
+				return fmt.Errorf("read VerkleProof: %w", err)
+			}
+			h.Verkle = true
+		} else {
+			baseFee, err := rlp.NewStream(bytes.NewReader(b), uint64(len(b))).Uint256Bytes()
+			if err != nil {
+				return fmt.Errorf("read BaseFee: %w", err)
+			}
+			h.Eip1559 = true
+			h.BaseFee = new(big.Int).SetBytes(baseFee)
+			// Verkle proof is optional and follows BaseFee
+			if h.VerkleProof, err = s.Bytes(); err != nil {
+				if !errors.Is(err, rlp.EOL) {
+					return fmt.Errorf("read VerkleProof: %w", err)
+				}
+				h.VerkleProof = nil
+			} else {
+				h.Verkle = true
+			}
+		}
+		if h.Verkle {
+			if err = s.Decode(&h.VerkleKeyVals); err != nil {
+				return fmt.Errorf("read VerkleKeyVals: %w", err)
+			}
 		}
-		rlp.DecodeBytes(rawKv, h.VerkleKeyVals)
 	}
 	if err := s.ListEnd(); err != nil {
 		return fmt.Errorf("close header struct: %w", err)
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 
 	"github.com/ledgerwatch/erigon/common"
@@ -205,6 +206,141 @@ func TestEIP2718BlockEncoding(t *testing.T) {
 	}
 }
 
+func TestVerkleHeaderEncoding(t *testing.T) {
+	header := &Header{
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(100),
+		GasLimit:   8_000_000,
+		GasUsed:    21_000,
+		Time:       1426516743,
+		Extra:      []byte("verkle"),
+		Eip1559:    true,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+
+	// Header without a proof keeps the London encoding
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var decoded Header
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Verkle || decoded.Hash() != header.Hash() {
+		t.Fatalf("wrong decoded header: verkle %t, hash %x, want %x", decoded.Verkle, decoded.Hash(), header.Hash())
+	}
+
+	header.Verkle = true
+	header.VerkleProof = bytes.Repeat([]byte{0xaa}, 100)
+	header.VerkleKeyVals = []verkle.KeyValuePair{
+		{Key: bytes.Repeat([]byte{0x01}, 32), Value: bytes.Repeat([]byte{0x02}, 32)},
+		{Key: bytes.Repeat([]byte{0x03}, 32), Value: []byte{}},
+	}
+	enc, err = rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if size := rlp.ListSize(uint64(header.EncodingSize())); size != uint64(len(enc)) {
+		t.Fatalf("wrong encoding size: %d, want %d", size, len(enc))
+	}
+	decoded = Header{}
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !decoded.Verkle || !bytes.Equal(decoded.VerkleProof, header.VerkleProof) || !reflect.DeepEqual(decoded.VerkleKeyVals, header.VerkleKeyVals) {
+		t.Fatalf("verkle fields mismatch: got %x %v", decoded.VerkleProof, decoded.VerkleKeyVals)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+}
+
+func TestVerkleHeaderEncodingWithoutBaseFee(t *testing.T) {
+	header := &Header{
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(100),
+		GasLimit:   8_000_000,
+		Time:       1426516743,
+		Verkle:     true,
+		// A proof short enough to pass for a BaseFee
+		VerkleProof:   []byte{0x01, 0x02},
+		VerkleKeyVals: []verkle.KeyValuePair{{Key: bytes.Repeat([]byte{0x01}, 32), Value: bytes.Repeat([]byte{0x02}, 32)}},
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if size := rlp.ListSize(uint64(header.EncodingSize())); size != uint64(len(enc)) {
+		t.Fatalf("wrong encoding size: %d, want %d", size, len(enc))
+	}
+	var decoded Header
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Eip1559 || decoded.BaseFee != nil {
+		t.Fatalf("verkle proof decoded as BaseFee %v", decoded.BaseFee)
+	}
+	if !decoded.Verkle || !bytes.Equal(decoded.VerkleProof, header.VerkleProof) || !reflect.DeepEqual(decoded.VerkleKeyVals, header.VerkleKeyVals) {
+		t.Fatalf("verkle fields mismatch: got %x %v", decoded.VerkleProof, decoded.VerkleKeyVals)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+}
+
+func TestVerkleHeaderEncodingWithSeal(t *testing.T) {
+	step, err := rlp.EncodeToBytes(uint64(5))
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	signature, err := rlp.EncodeToBytes(bytes.Repeat([]byte{0x11}, 65))
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	header := &Header{
+		Difficulty:    big.NewInt(1),
+		Number:        big.NewInt(100),
+		GasLimit:      8_000_000,
+		Time:          1426516743,
+		Seal:          []rlp.RawValue{step, signature},
+		WithSeal:      true,
+		Verkle:        true,
+		VerkleProof:   bytes.Repeat([]byte{0xaa}, 100),
+		VerkleKeyVals: []verkle.KeyValuePair{{Key: bytes.Repeat([]byte{0x01}, 32), Value: []byte{}}},
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	decoded := Header{WithSeal: true}
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !reflect.DeepEqual(decoded.Seal, header.Seal) {
+		t.Fatalf("seal mismatch: got %x, want %x", decoded.Seal, header.Seal)
+	}
+	if !decoded.Verkle || !bytes.Equal(decoded.VerkleProof, header.VerkleProof) || !reflect.DeepEqual(decoded.VerkleKeyVals, header.VerkleKeyVals) {
+		t.Fatalf("verkle fields mismatch: got %x %v", decoded.VerkleProof, decoded.VerkleKeyVals)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch: got %x, want %x", decoded.Hash(), header.Hash())
+	}
+
+	// Sealed headers without a proof keep their seal
+	header.Verkle, header.VerkleProof, header.VerkleKeyVals = false, nil, nil
+	if enc, err = rlp.EncodeToBytes(header); err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	decoded = Header{WithSeal: true}
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.Verkle || !reflect.DeepEqual(decoded.Seal, header.Seal) {
+		t.Fatalf("wrong decoded header: verkle %t, seal %x", decoded.Verkle, decoded.Seal)
+	}
+}
+
 func TestUncleHash(t *testing.T) {
 	uncles := make([]*Header, 0)
 	h := CalcUncleHash(uncles)
@@ -0,0 +1,150 @@
+package vm
+
+import (
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+// AccessWitness records the verkle tree leaves accessed by a transaction (EIP-4762).
+// Each touch returns the witness gas of the leaves accessed for the first time.
+type AccessWitness interface {
+	// TouchAccountRead records reads of the given account header leaves
+	TouchAccountRead(address common.Address, leaves ...byte) uint64
+	// TouchAccountWrite records writes of the given account header leaves, `fill` is set for an account that did not exist
+	TouchAccountWrite(address common.Address, fill bool, leaves ...byte) uint64
+	// TouchNewAccount records the writes of all header leaves of a created account
+	TouchNewAccount(address common.Address) uint64
+	// TouchSlot records the access of a storage slot, `fill` is set when an empty slot is written
+	TouchSlot(address common.Address, slot *uint256.Int, write, fill bool) uint64
+	// TouchCode records the access of the code chunks holding code[start:start+size], capped by the code length
+	TouchCode(address common.Address, start, size, codeLen uint64, write bool) uint64
+}
+
+type accessMode byte
+
+const (
+	accessRead accessMode = 1 << iota
+	accessWrite
+)
+
+// stemRef identifies a stem by the account and its tree index, so that each stem is only hashed once
+type stemRef struct {
+	address   common.Address
+	treeIndex uint256.Int
+}
+
+// accessWitness keeps track of the verkle tree leaves accessed by a transaction.
+// The first access of a stem (branch) and of a leaf (chunk) is charged, later accesses are free.
+// Accesses are not reverted together with the state, the gas for them has been paid.
+type accessWitness struct {
+	branches map[string]accessMode // by stem
+	chunks   map[string]accessMode // by tree key
+	stems    map[stemRef][]byte
+}
+
+func newAccessWitness() *accessWitness {
+	return &accessWitness{
+		branches: map[string]accessMode{},
+		chunks:   map[string]accessMode{},
+		stems:    map[stemRef][]byte{},
+	}
+}
+
+// touch records the access of a tree key and returns its witness gas
+func (aw *accessWitness) touch(key []byte, write, fill bool) uint64 {
+	var gas uint64
+	stem, chunk := string(key[:31]), string(key)
+	if aw.branches[stem]&accessRead == 0 {
+		aw.branches[stem] |= accessRead
+		gas += params.WitnessBranchReadCost
+	}
+	if aw.chunks[chunk]&accessRead == 0 {
+		aw.chunks[chunk] |= accessRead
+		gas += params.WitnessChunkReadCost
+	}
+	if !write {
+		return gas
+	}
+	if aw.branches[stem]&accessWrite == 0 {
+		aw.branches[stem] |= accessWrite
+		gas += params.WitnessBranchWriteCost
+	}
+	if aw.chunks[chunk]&accessWrite == 0 {
+		aw.chunks[chunk] |= accessWrite
+		gas += params.WitnessChunkWriteCost
+		if fill {
+			gas += params.WitnessChunkFillCost
+		}
+	}
+	return gas
+}
+
+func (aw *accessWitness) stem(address common.Address, treeIndex *uint256.Int) []byte {
+	ref := stemRef{address: address, treeIndex: *treeIndex}
+	stem, ok := aw.stems[ref]
+	if !ok {
+		stem = vtree.GetTreeKey(address[:], treeIndex, 0)
+		aw.stems[ref] = stem
+	}
+	return stem
+}
+
+func (aw *accessWitness) touchAccount(address common.Address, write, fill bool, leaves []byte) uint64 {
+	var gas uint64
+	key := common.CopyBytes(aw.stem(address, uint256.NewInt(0)))
+	for _, leaf := range leaves {
+		key[31] = leaf
+		gas += aw.touch(key, write, fill)
+	}
+	return gas
+}
+
+// TouchAccountRead records reads of the given account header leaves
+func (aw *accessWitness) TouchAccountRead(address common.Address, leaves ...byte) uint64 {
+	return aw.touchAccount(address, false, false, leaves)
+}
+
+// TouchAccountWrite records writes of the given account header leaves, `fill` is set for an account that did not exist
+func (aw *accessWitness) TouchAccountWrite(address common.Address, fill bool, leaves ...byte) uint64 {
+	return aw.touchAccount(address, true, fill, leaves)
+}
+
+// TouchNewAccount records the writes of all header leaves of a created account
+func (aw *accessWitness) TouchNewAccount(address common.Address) uint64 {
+	return aw.TouchAccountWrite(address, true, vtree.VersionLeafKey, vtree.BalanceLeafKey, vtree.NonceLeafKey, vtree.CodeKeccakLeafKey, vtree.CodeSizeLeafKey)
+}
+
+// TouchSlot records the access of a storage slot, `fill` is set when an empty slot is written
+func (aw *accessWitness) TouchSlot(address common.Address, slot *uint256.Int, write, fill bool) uint64 {
+	treeIndex, subIndex := vtree.StorageSlotTreeIndex(slot)
+	key := make([]byte, 32)
+	copy(key, aw.stem(address, treeIndex))
+	key[31] = subIndex
+	return aw.touch(key, write, fill)
+}
+
+// TouchCode records the access of the code chunks holding code[start:start+size], capped by the code length
+func (aw *accessWitness) TouchCode(address common.Address, start, size, codeLen uint64, write bool) uint64 {
+	if size == 0 || start >= codeLen {
+		return 0
+	}
+	end := start + size
+	if end > codeLen || end < start {
+		end = codeLen
+	}
+	var gas uint64
+	codeOffset := vtree.CodeOffset.Uint64()
+	nodeWidth := vtree.VerkleNodeWidth.Uint64()
+	key := make([]byte, 32)
+	var treeIndex uint256.Int
+	for chunk := start / 31; chunk <= (end-1)/31; chunk++ {
+		position := codeOffset + chunk
+		copy(key, aw.stem(address, treeIndex.SetUint64(position/nodeWidth)))
+		key[31] = byte(position % nodeWidth)
+		// Deployed code always fills new chunks
+		gas += aw.touch(key, write, write)
+	}
+	return gas
+}
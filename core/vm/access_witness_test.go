@@ -0,0 +1,41 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessWitness(t *testing.T) {
+	address := common.HexToAddress("0xc0de")
+	aw := newAccessWitness()
+
+	require.Equal(t, params.WitnessBranchReadCost+2*params.WitnessChunkReadCost, aw.TouchAccountRead(address, vtree.VersionLeafKey, vtree.CodeSizeLeafKey))
+	require.Zero(t, aw.TouchAccountRead(address, vtree.CodeSizeLeafKey))
+	// Reads of written leaves are free, writes of read leaves are not
+	require.Equal(t, params.WitnessBranchWriteCost+params.WitnessChunkWriteCost, aw.TouchAccountWrite(address, false, vtree.CodeSizeLeafKey))
+	require.Zero(t, aw.TouchAccountWrite(address, true, vtree.CodeSizeLeafKey))
+	require.Equal(t, params.WitnessChunkReadCost+params.WitnessChunkWriteCost+params.WitnessChunkFillCost, aw.TouchAccountWrite(address, true, vtree.BalanceLeafKey))
+
+	// First 128 code chunks share the stem of the header
+	require.Equal(t, 2*params.WitnessChunkReadCost, aw.TouchCode(address, 0, 32, 100, false))
+	require.Zero(t, aw.TouchCode(address, 100, 1, 100, false))
+	// Chunks 127 and 128 are in different stems
+	require.Equal(t, params.WitnessBranchReadCost+2*params.WitnessChunkReadCost, aw.TouchCode(address, 127*31, 32, 200*31, false))
+	for _, chunk := range []uint64{0, 1, 127, 128} {
+		require.Contains(t, aw.chunks, string(vtree.GetTreeKeyCodeChunk(address[:], uint256.NewInt(chunk))))
+	}
+	require.Zero(t, aw.TouchCode(address, 200*31, 10, 200*31, false))
+
+	slot := uint256.NewInt(1000)
+	require.Equal(t, params.WitnessBranchReadCost+params.WitnessChunkReadCost, aw.TouchSlot(address, slot, false, false))
+	require.Contains(t, aw.chunks, string(vtree.GetTreeKeyStorageSlot(address[:], slot)))
+	require.Zero(t, aw.TouchSlot(address, slot, false, false))
+	// The first 64 slots share the stem of the header
+	require.Equal(t, params.WitnessChunkReadCost, aw.TouchSlot(address, uint256.NewInt(3), false, false))
+	require.Contains(t, aw.chunks, string(vtree.GetTreeKeyStorageSlot(address[:], uint256.NewInt(3))))
+}
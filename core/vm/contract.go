@@ -56,6 +56,8 @@ type Contract struct {
 	CodeHash common.Hash
 	CodeAddr *common.Address
 	Input    []byte
+	// IsDeployment is set for init code, which is not stored in the state and so has no witness
+	IsDeployment bool
 
 	Gas   uint64
 	value *uint256.Int
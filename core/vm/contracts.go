@@ -129,6 +129,7 @@ var (
 	PrecompiledAddressesIstanbulForBSC []common.Address
 	PrecompiledAddressesByzantium      []common.Address
 	PrecompiledAddressesHomestead      []common.Address
+	PrecompiledAddressesVerkleProof    []common.Address
 )
 
 func init() {
@@ -147,10 +148,22 @@ func init() {
 	for k := range PrecompiledContractsBerlin {
 		PrecompiledAddressesBerlin = append(PrecompiledAddressesBerlin, k)
 	}
+	for k := range PrecompiledContractsVerkleProof {
+		PrecompiledAddressesVerkleProof = append(PrecompiledAddressesVerkleProof, k)
+	}
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules *params.Rules) []common.Address {
+	addresses := forkPrecompiles(rules)
+	if rules.IsVerkleProofPrecompile {
+		addresses = append(append(make([]common.Address, 0, len(addresses)+len(PrecompiledAddressesVerkleProof)), addresses...), PrecompiledAddressesVerkleProof...)
+	}
+	return addresses
+}
+
+// forkPrecompiles returns the precompiles enabled by the Ethereum (or BSC) fork of the current configuration.
+func forkPrecompiles(rules *params.Rules) []common.Address {
 	switch {
 	case rules.IsBerlin:
 		return PrecompiledAddressesBerlin
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/gballet/go-verkle"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+const (
+	verkleProofRootLength       uint64 = 32
+	verkleProofCountLength      uint64 = 32
+	verkleProofKeyLength        uint64 = 32
+	verkleProofValueLength      uint64 = 32
+	verkleProofLeafLength              = verkleProofKeyLength + 1 + verkleProofValueLength
+	verkleProofResultLeafLength        = 32 + verkleProofValueLength
+)
+
+// PrecompiledContractsVerkleProof contains the precompiles enabled by VerkleProofPrecompileBlock
+// on top of the ones of the current fork.
+var PrecompiledContractsVerkleProof = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{0x01, 0x00}): &verkleProofVerify{},
+}
+
+var (
+	verkleConfigOnce sync.Once
+	verkleConfig     *verkle.Config
+)
+
+// getVerkleConfig returns the IPA settings the precompile verifies proofs with. go-verkle computes them on first use,
+// or reads them from its precomp file, and they do not depend on the input: failing to set them up is a fault of the
+// node rather than a failed call, so it panics instead of executing the block differently than the other nodes.
+func getVerkleConfig() *verkle.Config {
+	verkleConfigOnce.Do(func() {
+		cfg, err := verkle.GetConfig()
+		if err != nil {
+			panic(fmt.Sprintf("verkle config: %v", err))
+		}
+		verkleConfig = cfg
+	})
+	return verkleConfig
+}
+
+// verkleProofVerify implemented as a native contract.
+// It checks a go-verkle multiproof of another chain's state against its verkle root.
+type verkleProofVerify struct{}
+
+func (c *verkleProofVerify) RequiredGas(input []byte) uint64 {
+	count, ok := verkleProofLeafCount(input)
+	if !ok {
+		return params.VerkleProofVerifyBaseGas
+	}
+	return params.VerkleProofVerifyBaseGas + count*params.VerkleProofVerifyPerKeyGas
+}
+
+// verkleProofLeafCount returns the number of leaves declared by the input,
+// if the input is long enough to hold them.
+func verkleProofLeafCount(input []byte) (uint64, bool) {
+	if uint64(len(input)) < verkleProofRootLength+verkleProofCountLength {
+		return 0, false
+	}
+	countBytes := input[verkleProofRootLength : verkleProofRootLength+verkleProofCountLength]
+	for _, b := range countBytes[:verkleProofCountLength-uint64TypeLength] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	count := binary.BigEndian.Uint64(countBytes[verkleProofCountLength-uint64TypeLength:])
+	if count == 0 || count > (uint64(len(input))-verkleProofRootLength-verkleProofCountLength)/verkleProofLeafLength {
+		return 0, false
+	}
+	return count, true
+}
+
+// input:
+// | root     | leaf count | leaves                                  | serialized proof |
+// | 32 bytes | 32 bytes   | count * (key 32 | present 1 | value 32) |                  |
+// Leaves are in the order of the proof, absent leaves have a zero present byte and a zero value.
+//
+// output:
+// | count * (present 32 | value 32) |
+func (c *verkleProofVerify) Run(input []byte) (result []byte, err error) {
+	// Set up before go-verkle looks the settings up on its own, and outside of the recover below
+	cfg := getVerkleConfig()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+
+	count, ok := verkleProofLeafCount(input)
+	if !ok {
+		return nil, fmt.Errorf("invalid input: input should include %d bytes root, %d bytes leaf count and the leaves", verkleProofRootLength, verkleProofCountLength)
+	}
+	rootC := new(verkle.Point)
+	if err := rootC.SetBytes(input[:verkleProofRootLength]); err != nil {
+		return nil, fmt.Errorf("invalid verkle root: %w", err)
+	}
+
+	leaves := input[verkleProofRootLength+verkleProofCountLength:]
+	keyVals := make([]verkle.KeyValuePair, count)
+	result = make([]byte, count*verkleProofResultLeafLength)
+	for i := uint64(0); i < count; i++ {
+		leaf := leaves[i*verkleProofLeafLength : (i+1)*verkleProofLeafLength]
+		keyVals[i].Key = leaf[:verkleProofKeyLength]
+		value := leaf[verkleProofKeyLength+1:]
+		switch leaf[verkleProofKeyLength] {
+		case 0:
+			for _, b := range value {
+				if b != 0 {
+					return nil, fmt.Errorf("invalid input: absent leaf %d has a value", i)
+				}
+			}
+		case 1:
+			keyVals[i].Value = value
+			out := result[i*verkleProofResultLeafLength : (i+1)*verkleProofResultLeafLength]
+			out[31] = 1
+			copy(out[32:], value)
+		default:
+			return nil, fmt.Errorf("invalid input: leaf %d present flag %d", i, leaf[verkleProofKeyLength])
+		}
+	}
+
+	proof, err := verkle.DeserializeProof(leaves[count*verkleProofLeafLength:], keyVals)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize verkle proof: %w", err)
+	}
+	tree, err := verkle.TreeFromProof(proof, rootC)
+	if err != nil {
+		return nil, fmt.Errorf("rebuild verkle tree from proof: %w", err)
+	}
+	pe, _, _ := verkle.GetCommitmentsForMultiproof(tree, proof.Keys)
+	if !verkle.VerifyVerkleProof(proof, pe.Cis, pe.Zis, pe.Yis, cfg) {
+		return nil, fmt.Errorf("invalid verkle proof")
+	}
+	return result, nil
+}
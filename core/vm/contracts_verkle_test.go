@@ -0,0 +1,97 @@
+package vm
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gballet/go-verkle"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func verkleProofInput(t *testing.T, root []byte, keyVals []verkle.KeyValuePair, proof []byte) []byte {
+	t.Helper()
+	input := make([]byte, 64, 64+len(keyVals)*int(verkleProofLeafLength)+len(proof))
+	copy(input, root)
+	binary.BigEndian.PutUint64(input[56:64], uint64(len(keyVals)))
+	for _, kv := range keyVals {
+		leaf := make([]byte, verkleProofLeafLength)
+		copy(leaf, kv.Key)
+		if kv.Value != nil {
+			leaf[32] = 1
+			copy(leaf[33:], kv.Value)
+		}
+		input = append(input, leaf...)
+	}
+	return append(input, proof...)
+}
+
+func TestVerkleProofVerify(t *testing.T) {
+	tree := verkle.New()
+	present := make([]byte, 32)
+	present[0] = 0x11
+	value := make([]byte, 32)
+	value[31] = 0x2a
+	require.NoError(t, tree.Insert(present, value, nil))
+	other := make([]byte, 32)
+	other[0] = 0x22
+	require.NoError(t, tree.Insert(other, value, nil))
+	root := tree.ComputeCommitment().Bytes()
+
+	absent := make([]byte, 32)
+	absent[0] = 0x11
+	absent[31] = 0x01
+	proof, _, _, _, err := verkle.MakeVerkleMultiProof(tree, [][]byte{present, absent}, map[string][]byte{string(present): value})
+	require.NoError(t, err)
+	serialized, keyVals, err := verkle.SerializeProof(proof)
+	require.NoError(t, err)
+
+	c := &verkleProofVerify{}
+	input := verkleProofInput(t, root[:], keyVals, serialized)
+	require.Equal(t, params.VerkleProofVerifyBaseGas+2*params.VerkleProofVerifyPerKeyGas, c.RequiredGas(input))
+
+	result, err := c.Run(input)
+	require.NoError(t, err)
+	require.Len(t, result, 2*int(verkleProofResultLeafLength))
+	for i, kv := range keyVals {
+		out := result[i*int(verkleProofResultLeafLength) : (i+1)*int(verkleProofResultLeafLength)]
+		if kv.Value == nil {
+			require.Equal(t, make([]byte, verkleProofResultLeafLength), out)
+			continue
+		}
+		require.Equal(t, byte(1), out[31])
+		require.Equal(t, value, out[32:])
+	}
+
+	t.Run("tampered value", func(t *testing.T) {
+		tampered := make([]verkle.KeyValuePair, len(keyVals))
+		copy(tampered, keyVals)
+		for i := range tampered {
+			if tampered[i].Value != nil {
+				tampered[i].Value = make([]byte, 32)
+			}
+		}
+		_, err := c.Run(verkleProofInput(t, root[:], tampered, serialized))
+		require.Error(t, err)
+	})
+	t.Run("wrong root", func(t *testing.T) {
+		require.NoError(t, tree.Insert(absent, value, nil))
+		otherRoot := tree.ComputeCommitment().Bytes()
+		_, err := c.Run(verkleProofInput(t, otherRoot[:], keyVals, serialized))
+		require.Error(t, err)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		require.Equal(t, params.VerkleProofVerifyBaseGas, c.RequiredGas(input[:64]))
+		_, err := c.Run(input[:64])
+		require.Error(t, err)
+	})
+}
+
+func TestVerkleProofPrecompileActivation(t *testing.T) {
+	addr := PrecompiledAddressesVerkleProof[0]
+	require.NotContains(t, ActivePrecompiles(&params.Rules{IsBerlin: true}), addr)
+	active := ActivePrecompiles(&params.Rules{IsBerlin: true, IsVerkleProofPrecompile: true})
+	require.Contains(t, active, addr)
+	require.Len(t, active, len(PrecompiledAddressesBerlin)+1)
+}
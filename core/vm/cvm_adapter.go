@@ -53,3 +53,7 @@ func (cvm *CVMAdapter) IntraBlockState() IntraBlockState {
 func (cvm *CVMAdapter) TxContext() TxContext {
 	return TxContext{}
 }
+
+func (cvm *CVMAdapter) Witness() AccessWitness {
+	return newAccessWitness()
+}
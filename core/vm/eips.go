@@ -189,3 +189,29 @@ func opPush0(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	scope.Stack.Push(new(uint256.Int))
 	return nil, nil
 }
+
+// enable4762 applies EIP-4762 (Verkle tree witness gas costs)
+// - Charges the first access of verkle branches and leaves within a transaction
+// - Replaces the cold access costs of EIP-2929
+// Code chunks of the executed code are charged by the interpreter, created accounts and code by the EVM.
+func enable4762(jt *JumpTable) {
+	for op, witnessGas := range map[OpCode]witnessGasFunc{
+		SLOAD:        witnessSLoadEIP4762,
+		SSTORE:       witnessSStoreEIP4762,
+		BALANCE:      witnessBalanceEIP4762,
+		EXTCODESIZE:  witnessExtCodeSizeEIP4762,
+		EXTCODEHASH:  witnessExtCodeHashEIP4762,
+		EXTCODECOPY:  witnessExtCodeCopyEIP4762,
+		CODECOPY:     witnessCodeCopyEIP4762,
+		CALL:         witnessCallEIP4762,
+		CALLCODE:     witnessCallNoValueEIP4762,
+		DELEGATECALL: witnessCallNoValueEIP4762,
+		STATICCALL:   witnessCallNoValueEIP4762,
+		SELFDESTRUCT: witnessSelfdestructEIP4762,
+	} {
+		// Martin can be scheduled on top of a fork that does not have the opcode yet
+		if jt[op] != nil {
+			jt[op].dynamicGas = makeGasEIP4762(jt[op].dynamicGas, witnessGas)
+		}
+	}
+}
@@ -58,6 +58,11 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
+	if evm.chainRules.IsVerkleProofPrecompile {
+		if p, ok := PrecompiledContractsVerkleProof[addr]; ok {
+			return p, true
+		}
+	}
 	p, ok := precompiles[addr]
 	return p, ok
 }
@@ -133,6 +138,8 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// witness holds the verkle leaves accessed by the current transaction, used for gas since Martin
+	witness AccessWitness
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -157,6 +164,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, state IntraBlockState, chain
 func (evm *EVM) Reset(txCtx TxContext, ibs IntraBlockState) {
 	evm.txContext = txCtx
 	evm.intraBlockState = ibs
+	evm.witness = nil
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -492,12 +500,18 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// The contract is a scoped environment for this execution context only.
 	contract := NewContract(caller, AccountRef(address), value, gas, evm.config.SkipAnalysis)
 	contract.SetCodeOptionalHash(&address, codeAndHash)
+	contract.IsDeployment = true
 
 	if evm.config.NoRecursion && evm.depth > 0 {
 		return nil, address, gas, nil
 	}
 
-	ret, err = run(evm, contract, nil, false)
+	// Since Martin the header of the new account is a part of the witness
+	if evm.chainRules.IsMartin && !contract.UseGas(evm.Witness().TouchNewAccount(address)) {
+		err = ErrOutOfGas
+	} else {
+		ret, err = run(evm, contract, nil, false)
+	}
 
 	// check whether the max code size has been exceeded
 	maxCodeSizeExceeded := evm.chainRules.IsSpuriousDragon && len(ret) > params.MaxCodeSize
@@ -514,6 +528,9 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// by the error checking condition below.
 	if err == nil && !maxCodeSizeExceeded {
 		createDataGas := uint64(len(ret)) * params.CreateDataGas
+		if evm.chainRules.IsMartin {
+			createDataGas += evm.Witness().TouchCode(address, 0, uint64(len(ret)), uint64(len(ret)), true)
+		}
 		if contract.UseGas(createDataGas) {
 			evm.intraBlockState.SetCode(address, ret)
 		} else if evm.chainRules.IsHomestead {
@@ -583,3 +600,11 @@ func (evm *EVM) TxContext() TxContext {
 func (evm *EVM) IntraBlockState() IntraBlockState {
 	return evm.intraBlockState
 }
+
+// Witness returns the verkle leaves accessed by the current transaction, it starts out empty after Reset
+func (evm *EVM) Witness() AccessWitness {
+	if evm.witness == nil {
+		evm.witness = newAccessWitness()
+	}
+	return evm.witness
+}
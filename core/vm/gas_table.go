@@ -25,6 +25,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core/vm/stack"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
 )
 
 // memoryGasCost calculates the quadratic gas for memory expansion. It does so
@@ -444,3 +445,41 @@ func gasSelfdestruct(evm *EVM, contract *Contract, stack *stack.Stack, mem *Memo
 	}
 	return gas, nil
 }
+
+// witnessCodeCopyEIP4762 charges the chunks of the executing code read by CODECOPY
+func witnessCodeCopyEIP4762(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+	if contract.IsDeployment || contract.CodeAddr == nil {
+		return 0, nil
+	}
+	start, overflow := stack.Back(1).Uint64WithOverflow()
+	if overflow {
+		// Nothing is copied from beyond the code
+		return 0, nil
+	}
+	size, overflow := stack.Back(2).Uint64WithOverflow()
+	if overflow {
+		return 0, ErrGasUintOverflow
+	}
+	return evm.Witness().TouchCode(*contract.CodeAddr, start, size, uint64(len(contract.Code)), false), nil
+}
+
+// witnessExtCodeCopyEIP4762 charges the header and the chunks of the external code read by EXTCODECOPY
+func witnessExtCodeCopyEIP4762(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+	addr := common.Address(stack.Peek().Bytes20())
+	evm.IntraBlockState().AddAddressToAccessList(addr)
+	witness := evm.Witness()
+	gas := witness.TouchAccountRead(addr, vtree.VersionLeafKey, vtree.CodeSizeLeafKey)
+	start, overflow := stack.Back(2).Uint64WithOverflow()
+	if overflow {
+		return gas, nil
+	}
+	size, overflow := stack.Back(3).Uint64WithOverflow()
+	if overflow {
+		return 0, ErrGasUintOverflow
+	}
+	codeGas := witness.TouchCode(addr, start, size, uint64(evm.IntraBlockState().GetCodeSize(addr)), false)
+	if gas, overflow = math.SafeAdd(gas, codeGas); overflow {
+		return 0, ErrGasUintOverflow
+	}
+	return gas, nil
+}
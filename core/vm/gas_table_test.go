@@ -114,3 +114,59 @@ func TestEIP2200(t *testing.T) {
 		})
 	}
 }
+
+var eip4762Tests = []struct {
+	input      string
+	used       uint64 // before Martin
+	usedMartin uint64
+}{
+	{"0x600054600054", 2206, 2506}, // SLOAD of a header slot twice, the code chunk shares the stem
+	{"0x608054", 2103, 4303},       // SLOAD of a main storage slot
+	{"0x6001600055", 22106, 32006}, // SSTORE filling an empty header slot
+	{"0x600131", 2603, 4303},       // BALANCE of another account
+	{"0x60013160013f", 2706, 4606}, // BALANCE and EXTCODEHASH of the same account
+}
+
+func TestEIP4762(t *testing.T) {
+	// TestEIP2200 enables EIP-2200 on the shared Berlin instruction set, so run on London
+	londonConfig := *params.AllEthashProtocolChanges
+	londonConfig.LondonBlock = common.Big0
+	martinConfig := londonConfig
+	martinConfig.MartinBlock = common.Big0
+
+	for i, tt := range eip4762Tests {
+		tt := tt
+		i := i
+
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for _, config := range []*params.ChainConfig{&londonConfig, &martinConfig} {
+				address := common.BytesToAddress([]byte("contract"))
+				_, tx := memdb.NewTestTx(t)
+
+				s := state.New(state.NewPlainStateReader(tx))
+				s.CreateAccount(address, true)
+				s.SetCode(address, hexutil.MustDecode(tt.input))
+				_ = s.CommitBlock(config.Rules(0), state.NewPlainStateWriter(tx, tx, 0))
+
+				vmctx := BlockContext{
+					CanTransfer: func(IntraBlockState, common.Address, *uint256.Int) bool { return true },
+					Transfer:    func(IntraBlockState, common.Address, common.Address, *uint256.Int, bool) {},
+				}
+				vmenv := NewEVM(vmctx, TxContext{}, s, config, Config{})
+				s.PrepareAccessList(common.Address{}, &address, nil, nil)
+
+				_, gas, err := vmenv.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(uint256.Int), false /* bailout */)
+				if err != nil {
+					t.Fatalf("test %d: %v", i, err)
+				}
+				want := tt.used
+				if config.IsMartin(0) {
+					want = tt.usedMartin
+				}
+				if used := math.MaxUint64 - gas; used != want {
+					t.Errorf("test %d (martin %t): gas used mismatch: have %v, want %v", i, config.IsMartin(0), used, want)
+				}
+			}
+		})
+	}
+}
@@ -100,4 +100,5 @@ type VMInterface interface {
 	Context() BlockContext
 	IntraBlockState() IntraBlockState
 	TxContext() TxContext
+	Witness() AccessWitness
 }
@@ -113,6 +113,9 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	default:
 		jt = &frontierInstructionSet
 	}
+	if evm.ChainRules().IsMartin {
+		jt = martinInstructionSets[jt]
+	}
 	if len(cfg.ExtraEips) > 0 {
 		for i, eip := range cfg.ExtraEips {
 			if err := EnableEIP(eip, jt); err != nil {
@@ -158,6 +161,9 @@ func NewEVMInterpreterByVM(vm *VM) *EVMInterpreter {
 	default:
 		jt = &frontierInstructionSet
 	}
+	if vm.evm.ChainRules().IsMartin {
+		jt = martinInstructionSets[jt]
+	}
 	if len(vm.cfg.ExtraEips) > 0 {
 		for i, eip := range vm.cfg.ExtraEips {
 			if err := EnableEIP(eip, jt); err != nil {
@@ -220,6 +226,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		gasCopy uint64 // for Tracer to log gas remaining before execution
 		logged  bool   // deferred Tracer should ignore already logged steps
 		res     []byte // result of the opcode execution function
+		// code of deployed contracts is charged since Martin
+		chargeCode   = in.evm.ChainRules().IsMartin && !contract.IsDeployment && contract.CodeAddr != nil
+		witnessChunk = ^uint64(0) // last charged code chunk
 	)
 	// Don't move this deferrred function, it's placed before the capturestate-deferred method,
 	// so that it get's executed _after_: the capturestate needs the stacks before
@@ -285,6 +294,21 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		if !contract.UseGas(operation.constantGas) {
 			return nil, ErrOutOfGas
 		}
+		// Code chunks of the instruction, push data included, are part of the verkle witness
+		if chargeCode {
+			size := uint64(1)
+			if operation.isPush {
+				size += uint64(operation.opNum)
+			}
+			if pc/31 != witnessChunk || (pc+size-1)/31 != witnessChunk {
+				chunkGas := in.evm.Witness().TouchCode(*contract.CodeAddr, pc, size, uint64(len(contract.Code)), false)
+				cost += chunkGas
+				if !contract.UseGas(chunkGas) {
+					return nil, ErrOutOfGas
+				}
+				witnessChunk = (pc + size - 1) / 31
+			}
+		}
 
 		var memorySize uint64
 		// calculate the new memory size and expand the memory to fit
@@ -72,6 +72,34 @@ var (
 // JumpTable contains the EVM opcodes supported at a given fork.
 type JumpTable [256]*operation
 
+// martinInstructionSets holds the verkle variants of the fork instruction sets.
+// Martin changes the state commitment only, so it can be scheduled on top of any fork.
+var martinInstructionSets = map[*JumpTable]*JumpTable{}
+
+func init() {
+	for _, jt := range []*JumpTable{
+		&frontierInstructionSet, &homesteadInstructionSet, &tangerineWhistleInstructionSet, &spuriousDragonInstructionSet,
+		&byzantiumInstructionSet, &constantinopleInstructionSet, &istanbulInstructionSet, &berlinInstructionSet,
+		&londonInstructionSet, &shanghaiInstructionSet, &cancunInstructionSet,
+	} {
+		martinInstructionSet := newMartinInstructionSet(jt)
+		martinInstructionSets[jt] = &martinInstructionSet
+	}
+}
+
+// newMartinInstructionSet returns the instructions of the given fork with verkle witness gas costs.
+func newMartinInstructionSet(forkInstructionSet *JumpTable) JumpTable {
+	var instructionSet JumpTable
+	for i, op := range forkInstructionSet {
+		if op != nil {
+			opCopy := *op
+			instructionSet[i] = &opCopy
+		}
+	}
+	enable4762(&instructionSet) // Verkle tree witness gas costs https://eips.ethereum.org/EIPS/eip-4762
+	return instructionSet
+}
+
 // newCancunInstructionSet returns the frontier, homestead, byzantium,
 // constantinople, istanbul, petersburg, berlin, london, paris, shanghai,
 // and cancun instructions.
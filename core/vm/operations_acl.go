@@ -24,6 +24,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core/vm/stack"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
 )
 
 func makeGasSStoreFunc(clearingRefund uint64) gasFunc {
@@ -248,3 +249,97 @@ func makeSelfdestructGasFn(refundsEnabled bool) gasFunc {
 	}
 	return gasFunc
 }
+
+// witnessGasFunc returns the EIP-4762 witness gas of an operation
+type witnessGasFunc func(*EVM, *Contract, *stack.Stack) (uint64, error)
+
+// makeGasEIP4762 charges the verkle witness of an operation on top of its fork cost.
+// Witness functions add the accessed address and slot to the access list first, so the witness
+// replaces the cold access cost of EIP-2929.
+func makeGasEIP4762(forkGas gasFunc, witnessGas witnessGasFunc) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *stack.Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		witness, err := witnessGas(evm, contract, stack)
+		if err != nil {
+			return 0, err
+		}
+		if forkGas == nil {
+			return witness, nil
+		}
+		// Like the cold cost in makeCallVariantGasCallEIP2929, the witness is charged before
+		// the fork cost, so that calls get 63/64 of what is left after it
+		if !contract.UseGas(witness) {
+			return 0, ErrOutOfGas
+		}
+		gas, err := forkGas(evm, contract, stack, mem, memorySize)
+		contract.Gas += witness
+		if err != nil {
+			return 0, err
+		}
+		var overflow bool
+		if gas, overflow = math.SafeAdd(gas, witness); overflow {
+			return 0, ErrGasUintOverflow
+		}
+		return gas, nil
+	}
+}
+
+func witnessSLoadEIP4762(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+	loc := stack.Peek()
+	evm.IntraBlockState().AddSlotToAccessList(contract.Address(), common.Hash(loc.Bytes32()))
+	return evm.Witness().TouchSlot(contract.Address(), loc, false, false), nil
+}
+
+func witnessSStoreEIP4762(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+	loc := stack.Peek()
+	slot := common.Hash(loc.Bytes32())
+	evm.IntraBlockState().AddSlotToAccessList(contract.Address(), slot)
+	var original uint256.Int
+	evm.IntraBlockState().GetCommittedState(contract.Address(), &slot, &original)
+	return evm.Witness().TouchSlot(contract.Address(), loc, true, original.IsZero()), nil
+}
+
+// makeWitnessAccountReadEIP4762 charges the reads of the given header leaves of the account on top of the stack.
+// This method is used by:
+// - extcodehash,
+// - extcodesize,
+// - (ext) balance
+func makeWitnessAccountReadEIP4762(leaves ...byte) witnessGasFunc {
+	return func(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+		addr := common.Address(stack.Peek().Bytes20())
+		evm.IntraBlockState().AddAddressToAccessList(addr)
+		return evm.Witness().TouchAccountRead(addr, leaves...), nil
+	}
+}
+
+// makeWitnessCallEIP4762 charges the header of the callee that is needed to load its code,
+// and the balances of both sides when value is transferred
+func makeWitnessCallEIP4762(transfersValue bool) witnessGasFunc {
+	return func(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+		addr := common.Address(stack.Back(1).Bytes20())
+		evm.IntraBlockState().AddAddressToAccessList(addr)
+		witness := evm.Witness()
+		gas := witness.TouchAccountRead(addr, vtree.VersionLeafKey, vtree.CodeSizeLeafKey)
+		if transfersValue && !stack.Back(2).IsZero() {
+			gas += witness.TouchAccountWrite(contract.Address(), false, vtree.BalanceLeafKey)
+			gas += witness.TouchAccountWrite(addr, !evm.IntraBlockState().Exist(addr), vtree.BalanceLeafKey)
+		}
+		return gas, nil
+	}
+}
+
+func witnessSelfdestructEIP4762(evm *EVM, contract *Contract, stack *stack.Stack) (uint64, error) {
+	beneficiary := common.Address(stack.Peek().Bytes20())
+	evm.IntraBlockState().AddAddressToAccessList(beneficiary)
+	witness := evm.Witness()
+	gas := witness.TouchAccountWrite(contract.Address(), false, vtree.BalanceLeafKey)
+	gas += witness.TouchAccountWrite(beneficiary, !evm.IntraBlockState().Exist(beneficiary), vtree.BalanceLeafKey)
+	return gas, nil
+}
+
+var (
+	witnessBalanceEIP4762     = makeWitnessAccountReadEIP4762(vtree.BalanceLeafKey)
+	witnessExtCodeSizeEIP4762 = makeWitnessAccountReadEIP4762(vtree.VersionLeafKey, vtree.CodeSizeLeafKey)
+	witnessExtCodeHashEIP4762 = makeWitnessAccountReadEIP4762(vtree.CodeKeccakLeafKey)
+	witnessCallEIP4762        = makeWitnessCallEIP4762(true)
+	witnessCallNoValueEIP4762 = makeWitnessCallEIP4762(false)
+)
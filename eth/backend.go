@@ -41,7 +41,6 @@ import (
 	prototypes "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
 	libstate "github.com/ledgerwatch/erigon-lib/state"
 	txpool2 "github.com/ledgerwatch/erigon-lib/txpool"
@@ -54,8 +53,6 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
 	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
 	"github.com/ledgerwatch/erigon/cmd/state/exec22"
-	"github.com/ledgerwatch/erigon/cmd/verkle-transition/verkle"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/consensus"
@@ -90,6 +87,8 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
 	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
@@ -151,7 +150,6 @@ type Ethereum struct {
 	forkValidator           *engineapi.ForkValidator
 	downloader              *downloader.Downloader
 	triggerVerkleCh         chan uint64
-	verkleDb                kv.RwDB
 }
 
 // New creates a new Ethereum object (including the
@@ -304,10 +302,11 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		}
 		cfg := stack.Config().P2P
 		cfg.NodeDatabase = filepath.Join(stack.Config().Dirs.Nodes, eth.ProtocolToString[cfg.ProtocolVersion])
+		cfg.VerkleWitness = backend.chainConfig.MartinBlock != nil
 		server := sentry.NewGrpcServer(backend.sentryCtx, discovery, readNodeInfo, &cfg, cfg.ProtocolVersion)
 
 		backend.sentryServers = append(backend.sentryServers, server)
-		sentries = []direct.SentryClient{direct.NewSentryClientDirect(cfg.ProtocolVersion, server)}
+		sentries = []direct.SentryClient{sentry.NewSentryClientDirect(cfg.ProtocolVersion, server)}
 
 		go func() {
 			logEvery := time.NewTicker(120 * time.Second)
@@ -355,86 +354,60 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		currentBlockNumber = currentBlock.NumberU64()
 	}
 
-	if config.ForceVerkle {
+	// A gradual conversion builds the tree after the fork, there is nothing to build ahead of it
+	if config.ForceVerkle && chainConfig.IsVerkleConversion() {
+		log.Warn("Verkle tree is converted gradually after the Martin block, not building it in the background")
+	} else if config.ForceVerkle {
 		log.Info("Verkle tree will be built in the background forcefully")
 		// Go routine for verkle trees
 		go func() {
-			coreTx, err := backend.chainDB.BeginRo(context.Background())
-			if err != nil {
-				panic(err)
-			}
-			h, _ := rawdb.ReadCanonicalHash(coreTx, 0)
-			currB := rawdb.ReadCurrentBlockNumber(coreTx)
-			ch, _ := rawdb.ReadChainConfig(coreTx, h)
-			if *currB >= ch.MartinBlock.Uint64()-2 {
-				coreTx.Rollback()
-
+			if chainConfig.IsMartin(currentBlockNumber + 2) {
 				log.Info("Transition point detected, switching to verkle Trees")
 				return
 			}
-			verkeDb, err := mdbx.Open("verkledb", log.Root(), false)
-			if err != nil {
-				panic(err)
-			}
-			tx, err := verkeDb.BeginRw(context.Background())
-			if err != nil {
-				panic(err)
-			}
-			if err := verkledb.InitDB(tx); err != nil {
-				panic(err)
-			}
 			logp := false
 
 			for {
 				curr := <-triggerVerkle
 				fmt.Println(curr)
-				if curr < ch.PapiBlock.Uint64() {
+				if curr < chainConfig.PapiBlock.Uint64() {
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
-				if curr >= ch.PapiBlock.Uint64() && logp {
+				if curr >= chainConfig.PapiBlock.Uint64() && logp {
 					log.Info("Verkle tree generation start now!")
 					logp = true
 				}
-				if curr >= ch.MartinBlock.Uint64()-2 {
-					tx.Rollback()
-					verkeDb.Close()
+				if chainConfig.IsMartin(curr + 2) {
 					log.Info("Transition point detected, switching to verkle Trees")
 					return
 				}
 				<-triggerVerkle
-				from, err := stages.GetStageProgress(tx, stages.VerkleTrie)
-				if err != nil {
-					return
-				}
-				root, err := verkledb.ReadVerkleRoot(tx, from-1)
-				if err != nil {
-					panic(err)
-				}
-				verkleTree := verkle.NewVerkleTree(tx, root)
-				var accRoot common.Hash
+				// The tree is written into chaindata, next to the state it is built from
+				var from uint64
 				var storageRoot common.Hash
-
-				if accRoot, err = verkle.ProcessAccounts(coreTx, tx, verkleTree, from); err != nil {
-					panic(err)
-				}
-
-				if storageRoot, err = verkle.ProcessStorage(coreTx, tx, verkleTree, from, accRoot); err != nil {
-					panic(err)
-				}
-
-				// Commit to verkle db
-				if err = tx.Commit(); err != nil {
-					panic(err)
-				}
-				tx, err = verkeDb.BeginRw(context.Background())
-				if err != nil {
-					panic(err)
-				}
-				coreTx.Rollback()
-				coreTx, err = chainKv.BeginRo(context.Background())
-				if err != nil {
-					panic(err)
+				if err := backend.chainDB.Update(ctx, func(tx kv.RwTx) error {
+					progress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+					if err != nil {
+						return err
+					}
+					from = progress
+					root, err := verkledb.ReadVerkleRoot(tx, from)
+					if err != nil {
+						return err
+					}
+					verkleTree, err := verkledb.NewVerkleTree(tx, root)
+					if err != nil {
+						return err
+					}
+					if _, err = transition.ProcessAccounts(tx, tx, verkleTree, from); err != nil {
+						return err
+					}
+					storageRoot, err = transition.ProcessStorage(tx, tx, verkleTree, from)
+					return err
+				}); err != nil {
+					log.Error("Verkle tree generation stopped", "err", err)
+					return
 				}
 
 				log.Info("Verkle tree is synced up", "root", storageRoot, "lastBlockWithState", from)
@@ -645,7 +618,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		headCh = make(chan *types.Block, 1)
 	}
 
-	backend.stagedSync, err = stages2.NewStagedSync(backend.sentryCtx, backend.chainDB, backend.chainDB, stack.Config().P2P, config, backend.sentriesClient, backend.notifications, backend.downloaderClient, allSnapshots, headCh, txNums, agg, backend.forkValidator, triggerVerkle)
+	backend.stagedSync, err = stages2.NewStagedSync(backend.sentryCtx, backend.chainDB, stack.Config().P2P, config, backend.sentriesClient, backend.notifications, backend.downloaderClient, allSnapshots, headCh, txNums, agg, backend.forkValidator, triggerVerkle)
 	if err != nil {
 		return nil, err
 	}
@@ -694,7 +667,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, agg, txNums, httpRpcCfg)
 	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, httpRpcCfg)
 	go func() {
-		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
+		if err := cli.StartRpcServer(ctx, httpRpcCfg, chainKv, apiList, authApiList); err != nil {
 			log.Error(err.Error())
 			return
 		}
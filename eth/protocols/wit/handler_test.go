@@ -0,0 +1,152 @@
+package wit_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	sentry2 "github.com/ledgerwatch/erigon/cmd/sentry/sentry"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/protocols/wit"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// testKey is a private key to use for funding a tester account.
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+	// testAddr is the Ethereum address of the tester account.
+	testAddr = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+func newMock(t *testing.T) *stages.MockSentry {
+	return stages.MockWithGenesis(t, &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+	}, testKey, false)
+}
+
+// TestFetchVerkleWitness requests a witness from the multi-client of one mock, passes the request to the multi-client
+// of another, which serves it, and its answer back to the first one
+func TestFetchVerkleWitness(t *testing.T) {
+	server, client := newMock(t), newMock(t)
+
+	address := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	balanceKey := vtree.GetTreeKeyBalance(address[:])
+	absentKey := vtree.GetTreeKeyStorageSlot(address[:], uint256.NewInt(1000))
+	header := &types.Header{
+		Number:        big.NewInt(1),
+		Eip1559:       true,
+		BaseFee:       big.NewInt(1),
+		Verkle:        true,
+		VerkleProof:   []byte{0x01, 0x02},
+		VerkleKeyVals: []verkle.KeyValuePair{{Key: balanceKey, Value: common.Hash{1}.Bytes()}},
+	}
+	var root common.Hash
+	err := server.DB.Update(server.Ctx, func(tx kv.RwTx) error {
+		if err := verkledb.InitDB(tx); err != nil {
+			return err
+		}
+		tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+		if err != nil {
+			return err
+		}
+		if err := tree.Insert(balanceKey, common.Hash{1}.Bytes()); err != nil {
+			return err
+		}
+		if root, err = tree.Commit(); err != nil {
+			return err
+		}
+		rawdb.WriteHeader(tx, header)
+		return nil
+	})
+	require.NoError(t, err)
+
+	type fetched struct {
+		witness *wit.VerkleWitnessPacket
+		peerID  [64]byte
+		err     error
+	}
+	results := make(chan fetched, 1)
+	go func() {
+		witness, peerID, err := client.MultiClient().FetchVerkleWitness(client.Ctx, &wit.GetVerkleWitnessPacket{
+			BlockHashes: []common.Hash{header.Hash(), {0xff}},
+			Root:        root,
+			Stems:       [][]byte{balanceKey[:31], absentKey[:31]},
+		})
+		results <- fetched{witness, peerID, err}
+	}()
+	require.Eventually(t, func() bool { return client.SentMessages() > 0 }, 10*time.Second, 10*time.Millisecond)
+	request := client.SentMessage(0)
+	require.Equal(t, wit.MessageId_GET_VERKLE_WITNESS_1, request.Id)
+
+	server.ReceiveWg.Add(1)
+	for _, err = range server.Send(&sentry.InboundMessage{Id: request.Id, Data: request.Data, PeerId: client.PeerId}) {
+		require.NoError(t, err)
+	}
+	server.ReceiveWg.Wait()
+	response := server.SentMessage(0)
+	require.Equal(t, wit.MessageId_VERKLE_WITNESS_1, response.Id)
+
+	client.ReceiveWg.Add(1)
+	for _, err = range client.Send(&sentry.InboundMessage{Id: response.Id, Data: response.Data, PeerId: server.PeerId}) {
+		require.NoError(t, err)
+	}
+	client.ReceiveWg.Wait()
+
+	var result fetched
+	select {
+	case result = <-results:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no verkle witness delivered")
+	}
+	require.NoError(t, result.err)
+	require.Equal(t, sentry2.ConvertH512ToPeerID(server.PeerId), result.peerID)
+	witness := result.witness
+
+	// The unknown block is left out
+	require.Equal(t, 1, len(witness.Blocks))
+	require.Equal(t, header.Hash(), witness.Blocks[0].Hash)
+	require.Equal(t, header.VerkleProof, witness.Blocks[0].Proof)
+	require.Equal(t, header.VerkleKeyVals, witness.Blocks[0].KeyVals)
+
+	// The set value of the present stem, the first key of the absent one
+	_, err = verkle.DeserializeProof(witness.StemProof, witness.StemLeaves)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(witness.StemLeaves))
+	values := map[string][]byte{}
+	for _, keyVal := range witness.StemLeaves {
+		values[string(keyVal.Key)] = keyVal.Value
+	}
+	require.Equal(t, common.Hash{1}.Bytes(), values[string(balanceKey)])
+	absentStemKey := append(common.CopyBytes(absentKey[:31]), 0)
+	value, ok := values[string(absentStemKey)]
+	require.True(t, ok)
+	require.Empty(t, value)
+
+	// A stateless client checks the stems against the root before reading them
+	_, err = state.NewStatelessVerkleReader(root, witness.StemProof, witness.StemLeaves)
+	require.NoError(t, err)
+	_, err = state.NewStatelessVerkleReader(common.Hash{0xff}, witness.StemProof, witness.StemLeaves)
+	require.Error(t, err)
+
+	// A witness nobody waits for is dropped
+	client.ReceiveWg.Add(1)
+	for _, err = range client.Send(&sentry.InboundMessage{Id: response.Id, Data: response.Data, PeerId: server.PeerId}) {
+		require.NoError(t, err)
+	}
+	client.ReceiveWg.Wait()
+}
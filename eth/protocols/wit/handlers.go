@@ -0,0 +1,58 @@
+package wit
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+)
+
+const (
+	// softResponseLimit is the target maximum size of replies to data retrievals.
+	softResponseLimit = 2 * 1024 * 1024
+
+	// maxWitnessesServe is the maximum number of block verkle witnesses to serve.
+	maxWitnessesServe = 1024
+
+	// maxStemsServe is the maximum number of stems whose leaves are proven at once.
+	// Each leaf holds up to 256 values, the practical limit is about softResponseLimit.
+	maxStemsServe = 256
+)
+
+func AnswerGetVerkleWitnessQuery(db kv.Tx, query *GetVerkleWitnessPacket) (*VerkleWitnessPacket, error) {
+	// Gather witnesses until the fetch or network limits is reached
+	var bytes int
+	response := &VerkleWitnessPacket{RequestId: query.RequestId}
+	for lookups, hash := range query.BlockHashes {
+		if bytes >= softResponseLimit || len(response.Blocks) >= maxWitnessesServe ||
+			lookups >= 2*maxWitnessesServe {
+			break
+		}
+		header, err := rawdb.ReadHeaderByHash(db, hash)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			continue
+		}
+		response.Blocks = append(response.Blocks, &BlockWitness{Hash: hash, Proof: header.VerkleProof, KeyVals: header.VerkleKeyVals})
+		bytes += len(header.VerkleProof)
+		for _, keyVal := range header.VerkleKeyVals {
+			bytes += len(keyVal.Key) + len(keyVal.Value)
+		}
+	}
+	if len(query.Stems) == 0 || bytes >= softResponseLimit {
+		return response, nil
+	}
+	stems := query.Stems
+	if len(stems) > maxStemsServe {
+		stems = stems[:maxStemsServe]
+	}
+	proof, keyVals, err := verkledb.ProveStems(db, query.Root, stems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove verkle stems: %w", err)
+	}
+	response.StemProof, response.StemLeaves = proof, keyVals
+	return response, nil
+}
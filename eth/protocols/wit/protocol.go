@@ -0,0 +1,79 @@
+package wit
+
+import (
+	"github.com/gballet/go-verkle"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	WIT1 = 1
+)
+
+// ProtocolName is the short name of the `wit` protocol used during devp2p capability negotiation.
+// The capability is only run by the peers of verkle networks, next to `eth`.
+const ProtocolName = "wit"
+
+// ProtocolLength is the number of message codes used by the protocol
+const ProtocolLength = 2
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 10 * 1024 * 1024
+const ProtocolMaxMsgSize = maxMessageSize
+
+const (
+	GetVerkleWitnessMsg = 0x00
+	VerkleWitnessMsg    = 0x01
+)
+
+// Sentry message ids of the wit messages. The MessageId enum of the sentry interface, which is part of erigon-lib,
+// has no values for them, so ids from 64 up are reserved for capabilities outside of it, leaving room for the
+// eth ids, which end at 31. Being unknown to the enum, they never go over gRPC: the wit capability is only run by
+// the sentry embedded in the node, whose client passes messages in process. Standalone sentries do not run it, and
+// their clients neither subscribe to the ids nor send them.
+const (
+	MessageId_GET_VERKLE_WITNESS_1 proto_sentry.MessageId = 64
+	MessageId_VERKLE_WITNESS_1     proto_sentry.MessageId = 65
+)
+
+var ToProto = map[uint64]proto_sentry.MessageId{
+	GetVerkleWitnessMsg: MessageId_GET_VERKLE_WITNESS_1,
+	VerkleWitnessMsg:    MessageId_VERKLE_WITNESS_1,
+}
+
+var FromProto = map[proto_sentry.MessageId]uint64{
+	MessageId_GET_VERKLE_WITNESS_1: GetVerkleWitnessMsg,
+	MessageId_VERKLE_WITNESS_1:     VerkleWitnessMsg,
+}
+
+// GetVerkleWitnessPacket represents a query of verkle witnesses: the proofs and key/values of the
+// headers of the blocks, and the leaves of the stems in the tree with the given root.
+type GetVerkleWitnessPacket struct {
+	RequestId   uint64
+	BlockHashes []common.Hash
+	Root        common.Hash
+	Stems       [][]byte
+}
+
+// BlockWitness is the verkle witness of a block, as in its header.
+type BlockWitness struct {
+	Hash    common.Hash
+	Proof   []byte
+	KeyVals []verkle.KeyValuePair
+}
+
+// VerkleWitnessPacket is the network packet for verkle witnesses distribution.
+// Unknown blocks are left out, so are absent stems, whose proof shows they aren't in the tree.
+type VerkleWitnessPacket struct {
+	RequestId  uint64
+	Blocks     []*BlockWitness
+	StemProof  []byte
+	StemLeaves []verkle.KeyValuePair
+}
+
+func (*GetVerkleWitnessPacket) Name() string { return "GetVerkleWitness" }
+func (*GetVerkleWitnessPacket) Kind() byte   { return GetVerkleWitnessMsg }
+
+func (*VerkleWitnessPacket) Name() string { return "VerkleWitness" }
+func (*VerkleWitnessPacket) Kind() byte   { return VerkleWitnessMsg }
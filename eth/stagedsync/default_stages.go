@@ -8,7 +8,8 @@ import (
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 )
 
-func DefaultStages(ctx context.Context, verkleDb kv.RwDB, sm prune.Mode, snapshots SnapshotsCfg, headers HeadersCfg, cumulativeIndex CumulativeIndexCfg, blockHashCfg BlockHashesCfg, bodies BodiesCfg, issuance IssuanceCfg, senders SendersCfg, exec ExecuteBlockCfg, hashState HashStateCfg, trieCfg TrieCfg, history HistoryCfg, logIndex LogIndexCfg, callTraces CallTracesCfg, txLookup TxLookupCfg, finish FinishCfg, test bool) []*Stage {
+func DefaultStages(ctx context.Context, sm prune.Mode, snapshots SnapshotsCfg, headers HeadersCfg, cumulativeIndex CumulativeIndexCfg, blockHashCfg BlockHashesCfg, bodies BodiesCfg, issuance IssuanceCfg, senders SendersCfg, exec ExecuteBlockCfg, hashState HashStateCfg, trieCfg TrieCfg, history HistoryCfg, logIndex LogIndexCfg, callTraces CallTracesCfg, txLookup TxLookupCfg, finish FinishCfg, test bool) []*Stage {
+	verkleCfg := StageVerkleCfg(txLookup.db, exec.chainConfig, sm, finish.verkleCh)
 	return []*Stage{
 		{
 			ID:          stages.Snapshots,
@@ -208,13 +209,13 @@ func DefaultStages(ctx context.Context, verkleDb kv.RwDB, sm prune.Mode, snapsho
 			ID:          stages.VerkleTrie,
 			Description: "Generate verkle trie",
 			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, tx kv.RwTx) error {
-				return SpawnVerkle(s, tx, 0, StageVerkleCfg(verkleDb, txLookup.db, exec.chainConfig, blockHashCfg.tmpDir, finish.verkleCh), ctx)
+				return SpawnVerkle(s, tx, 0, verkleCfg, ctx)
 			},
 			Unwind: func(firstCycle bool, u *UnwindState, s *StageState, tx kv.RwTx) error {
-				return nil
+				return UnwindVerkle(u, s, tx, verkleCfg, ctx)
 			},
 			Prune: func(firstCycle bool, p *PruneState, tx kv.RwTx) error {
-				return nil
+				return PruneVerkle(p, tx, verkleCfg, ctx)
 			},
 		},
 		{
@@ -224,7 +225,7 @@ func DefaultStages(ctx context.Context, verkleDb kv.RwDB, sm prune.Mode, snapsho
 				return SpawnVerkleIncarnation(s, tx, 0 /* toBlock */, txLookup, ctx)
 			},
 			Unwind: func(firstCycle bool, u *UnwindState, s *StageState, tx kv.RwTx) error {
-				return nil
+				return UnwindVerkleIncarnation(u, s, tx, txLookup, ctx)
 			},
 			Prune: func(firstCycle bool, p *PruneState, tx kv.RwTx) error {
 				return nil
@@ -365,6 +366,9 @@ type PruneOrder []stages.SyncStage
 
 var DefaultUnwindOrder = UnwindOrder{
 	stages.Finish,
+	// Verkle stages read changesets, so they have to be unwound before Execution
+	stages.VerkleTrieIncarnation,
+	stages.VerkleTrie,
 	stages.TxLookup,
 	stages.LogIndex,
 	stages.StorageHistoryIndex,
@@ -397,6 +401,7 @@ var StateUnwindOrder = UnwindOrder{
 
 var DefaultPruneOrder = PruneOrder{
 	stages.Finish,
+	stages.VerkleTrie,
 	stages.Snapshots,
 	stages.TxLookup,
 	stages.LogIndex,
@@ -46,6 +46,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/sync/semaphore"
 )
@@ -140,12 +141,26 @@ func executeBlock(
 	writeCallTraces bool,
 	initialCycle bool,
 	effectiveEngine consensus.Engine,
+	verkleTree *verkledb.VerkleTree, // nil if the state of the block is not written to the verkle tree by execution
+	verkleCommitted bool, // whether the header commits to verkleTree, otherwise the caller commits it with the conversion
 ) error {
 	blockNum := block.NumberU64()
 	stateReader, stateWriter, err := newStateReaderWriter(batch, tx, block, writeChangesets, cfg.accumulator, initialCycle, cfg.stateStream)
 	if err != nil {
 		return err
 	}
+	blockWriter := stateWriter
+	// The tree is there after Martin, the flat state is read before. Writes go to both, the flat state keeps the
+	// changesets and the incarnations, which the tree does not.
+	if verkleTree != nil {
+		if verkleCommitted {
+			stateReader = verkledb.NewStateReader(verkleTree.Node(), verkleTree.Resolve).WithIncarnations(stateReader)
+		} else {
+			// Whatever the conversion has not moved into the tree yet is read from the flat state
+			stateReader = verkledb.NewOverlayReader(verkleTree.Node(), verkleTree.Resolve, stateReader)
+		}
+		blockWriter = state.NewVerkleTeeWriter(stateWriter, state.NewVerkleStateWriter(verkleTree.Node(), verkleTree.Resolve).WithLookups(verkledb.NewTreeKeys(tx, true)))
+	}
 
 	// where the magic happens
 	getHeader := func(hash commonold.Hash, number uint64) *types.Header {
@@ -169,17 +184,22 @@ func executeBlock(
 	getHashFn := core.GetHashFn(block.Header(), getHeader)
 
 	if isPoSa {
-		execRs, err = core.ExecuteBlockEphemerallyForBSC(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
+		execRs, err = core.ExecuteBlockEphemerallyForBSC(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, blockWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
 	} else if isBor {
-		execRs, err = core.ExecuteBlockEphemerallyBor(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
+		execRs, err = core.ExecuteBlockEphemerallyBor(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, blockWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
 	} else {
-		execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
+		execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, blockWriter, epochReader{tx: tx}, chainReader{config: cfg.chainConfig, tx: tx, blockReader: cfg.blockReader}, false, getTracer)
 	}
 	if err != nil {
 		return err
 	}
 	receipts = execRs.Receipts
 	stateSyncReceipt = execRs.ReceiptForStorage
+	if verkleTree != nil && verkleCommitted {
+		if err = commitExecutionVerkleTree(tx, verkleTree, block.Header()); err != nil {
+			return err
+		}
+	}
 
 	if writeReceipts {
 		if err = rawdb.AppendReceipts(tx, blockNum, receipts); err != nil {
@@ -437,6 +457,11 @@ func SpawnExecuteBlocksStage(s *StageState, u Unwinder, tx kv.RwTx, toBlock uint
 		asyncEngine = asyncEngine.WithExecutionContext(ctx)
 		effectiveEngine = asyncEngine.(consensus.Engine)
 	}
+	// After Martin the header root comes out of the verkle tree written by execution, the VerkleTrie stage only follows
+	verkleTree, verkleAt, err := executionVerkleTree(ctx, logPrefix, tx, cfg, s.BlockNumber)
+	if err != nil {
+		return err
+	}
 Loop:
 	for blockNum := stageProgress + 1; blockNum <= to; blockNum++ {
 		if stoppedErr = common.Stopped(quit); stoppedErr != nil {
@@ -458,11 +483,30 @@ Loop:
 
 		lastLogTx += uint64(block.Transactions().Len())
 
+		if verkleTree == nil && blockNum-1 == verkleAt {
+			// The tree is built out of the flat state and the changesets of tx
+			if err = batch.Commit(); err != nil {
+				return err
+			}
+			if err = s.Update(tx, blockNum-1); err != nil {
+				return err
+			}
+			if verkleTree, verkleAt, err = executionVerkleTree(ctx, logPrefix, tx, cfg, blockNum-1); err != nil {
+				return err
+			}
+		}
+		var verkleCommitted bool
+		if verkleTree != nil {
+			if verkleCommitted, err = verkleRootActive(tx, cfg.chainConfig, blockNum); err != nil {
+				return err
+			}
+		}
+
 		// Incremental move of next stages depend on fully written ChangeSets, Receipts, CallTraceSet
 		writeChangeSets := nextStagesExpectData || blockNum > cfg.prune.History.PruneTo(to)
 		writeReceipts := nextStagesExpectData || blockNum > cfg.prune.Receipts.PruneTo(to)
 		writeCallTraces := nextStagesExpectData || blockNum > cfg.prune.CallTraces.PruneTo(to)
-		if err = executeBlock(block, tx, batch, cfg, *cfg.vmConfig, writeChangeSets, writeReceipts, writeCallTraces, initialCycle, effectiveEngine); err != nil {
+		if err = executeBlock(block, tx, batch, cfg, *cfg.vmConfig, writeChangeSets, writeReceipts, writeCallTraces, initialCycle, effectiveEngine, verkleTree, verkleCommitted); err != nil {
 			if !errors.Is(err, context.Canceled) {
 				log.Warn(fmt.Sprintf("[%s] Execution failed", logPrefix), "block", blockNum, "hash", block.Hash().String(), "err", err)
 				if cfg.hd != nil {
@@ -475,6 +519,11 @@ Loop:
 			u.UnwindTo(blockNum-1, block.Hash())
 			break Loop
 		}
+		if verkleTree != nil && !verkleCommitted {
+			if err = convertVerkleTree(logPrefix, tx, batch, verkleTree, blockNum); err != nil {
+				return err
+			}
+		}
 		stageProgress = blockNum
 
 		if currentStateGas >= gasState {
@@ -496,6 +545,16 @@ Loop:
 				}
 				// TODO: This creates stacked up deferrals
 				defer tx.Rollback()
+				// Every block written into the tree has its root stored
+				if verkleTree != nil {
+					verkleRoot, err := verkledb.ReadVerkleRoot(tx, stageProgress)
+					if err != nil {
+						return err
+					}
+					if verkleTree, err = verkledb.NewVerkleTree(tx, verkleRoot); err != nil {
+						return err
+					}
+				}
 			}
 			batch = olddb.NewHashBatch(tx, quit, cfg.dirs.Tmp)
 			// TODO: This creates stacked up deferrals
@@ -717,6 +776,12 @@ func unwindExecutionStage(u *UnwindState, s *StageState, tx kv.RwTx, ctx context
 	if err := rawdb.DeleteNewerEpochs(tx, u.UnwindPoint+1); err != nil {
 		return fmt.Errorf("delete newer epochs: %w", err)
 	}
+	// Roots written by execution past the progress of the VerkleTrie stage, which has not unwound them
+	if cfg.chainConfig != nil && cfg.chainConfig.MartinBlock != nil {
+		if err := verkledb.TruncateVerkleRoots(tx, u.UnwindPoint+1); err != nil {
+			return fmt.Errorf("truncate verkle roots: %w", err)
+		}
+	}
 
 	// Truncate CallTraceSet
 	keyStart := dbutils.EncodeBlockNumber(u.UnwindPoint + 1)
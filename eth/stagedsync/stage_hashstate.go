@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
-	"github.com/ledgerwatch/erigon/params"
 	"runtime"
 	"time"
 
@@ -61,8 +60,8 @@ func SpawnHashStateStage(s *StageState, tx kv.RwTx, cfg HashStateCfg, ctx contex
 	}
 
 	// stop building Merkle Trees after PapiBlock
-	if s.BlockNumber >= params.AllCliqueProtocolChanges.PapiBlock.Uint64() {
-		return nil
+	if stopped, err := merkleTrieStopped(tx, s.BlockNumber); err != nil || stopped {
+		return err
 	}
 	if s.BlockNumber == to {
 		// we already did hash check for this block
@@ -7,8 +7,6 @@ import (
 	"fmt"
 	"math/bits"
 
-	"github.com/ledgerwatch/erigon/params"
-
 	"github.com/ledgerwatch/erigon-lib/common/length"
 	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -77,8 +75,8 @@ func SpawnIntermediateHashesStage(s *StageState, u Unwinder, tx kv.RwTx, cfg Tri
 	}
 
 	// stop building Merkle Trees after PapiBlock
-	if s.BlockNumber >= params.AllCliqueProtocolChanges.PapiBlock.Uint64() {
-		return trie.EmptyRoot, nil
+	if stopped, err := merkleTrieStopped(tx, s.BlockNumber); err != nil || stopped {
+		return trie.EmptyRoot, err
 	}
 	if s.BlockNumber == to {
 		// we already did hash check for this block
@@ -86,6 +84,13 @@ func SpawnIntermediateHashesStage(s *StageState, u Unwinder, tx kv.RwTx, cfg Tri
 		return trie.EmptyRoot, nil
 	}
 
+	// Roots are not checked across the end of a gradual verkle conversion, headers commit to the verkle tree after it
+	if cfg.checkRoot {
+		if cfg.checkRoot, err = merkleRootExpected(tx, to); err != nil {
+			return trie.EmptyRoot, err
+		}
+	}
+
 	var expectedRootHash common.Hash
 	var headerHash common.Hash
 	var syncHeadHeader *types.Header
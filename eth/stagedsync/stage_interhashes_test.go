@@ -10,6 +10,7 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/trie"
@@ -396,3 +397,55 @@ func TestHiveTrieRoot(t *testing.T) {
 
 	assert.Equal(t, regeneratedRoot, incrementalRoot)
 }
+
+func TestProofRetainer(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	hash1 := common.HexToHash("0xB000000000000000000000000000000000000000000000000000000000000000")
+	require.Nil(t, addTestAccount(tx, hash1, 3*params.Ether, 0))
+
+	incarnation := uint64(1)
+	hash2 := common.HexToHash("0xB041000000000000000000000000000000000000000000000000000000000000")
+	require.Nil(t, addTestAccount(tx, hash2, 2*params.Ether, incarnation))
+
+	hash3 := common.HexToHash("0xB1A0000000000000000000000000000000000000000000000000000000000000")
+	require.Nil(t, addTestAccount(tx, hash3, 4*params.Ether, 0))
+
+	loc1 := common.HexToHash("0x1200000000000000000000000000000000000000000000000000000000000000")
+	loc2 := common.HexToHash("0x3000000000000000000000000000000000000000000000000000000000E00000")
+	loc3 := common.HexToHash("0x3000000000000000000000000000000000000000000000000000000000E00001")
+	require.Nil(t, tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash2, incarnation, loc1), common.FromHex("0x42")))
+	require.Nil(t, tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash2, incarnation, loc2), common.FromHex("0x127a89")))
+	require.Nil(t, tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash2, incarnation, loc3), common.FromHex("0x05")))
+
+	cfg := StageTrieCfg(nil, false, true, false, t.TempDir(), snapshotsync.NewBlockReader(), nil, false, nil, nil)
+	expectedRoot, err := RegenerateIntermediateHashes("IH", tx, cfg, common.Hash{} /* expectedRootHash */, nil /* quit */)
+	require.Nil(t, err)
+
+	rl, proofRl := trie.NewRetainList(0), trie.NewRetainList(0)
+	for _, key := range [][]byte{hash2[:], dbutils.GenerateCompositeStorageKey(hash2, incarnation, loc2)} {
+		rl.AddKey(key)
+		proofRl.AddKey(key)
+	}
+	loader := trie.NewFlatDBTrieLoader("IH")
+	require.Nil(t, loader.Reset(rl, nil, nil, false))
+	loader.SetProofRetainer(proofRl)
+	root, err := loader.CalcTrieRoot(tx, nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, expectedRoot, root)
+
+	tr := trie.New(root)
+	require.Nil(t, tr.HookSubTries(loader.Result(), [][]byte{nil}))
+
+	accountProof, err := tr.Prove(hash2[:], 0, false)
+	require.Nil(t, err)
+	require.NotEmpty(t, accountProof)
+	assert.Equal(t, root, crypto.Keccak256Hash(accountProof[0]))
+
+	acc, ok := tr.GetAccount(hash2[:])
+	require.True(t, ok)
+	storageProof, err := tr.Prove(append(common.CopyBytes(hash2[:]), loc2[:]...), 64, true)
+	require.Nil(t, err)
+	require.NotEmpty(t, storageProof)
+	assert.Equal(t, acc.Root, crypto.Keccak256Hash(storageProof[0]))
+}
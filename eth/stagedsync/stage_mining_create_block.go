@@ -38,6 +38,9 @@ type MiningBlock struct {
 
 	LocalTxs  types.TransactionsStream
 	RemoteTxs types.TransactionsStream
+
+	// Verkle tree keys read while executing the block, they make up the block witness after Martin
+	WitnessKeys [][]byte
 }
 
 type MiningState struct {
@@ -73,7 +73,12 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 	remoteTxs := current.RemoteTxs
 	noempty := true
 
-	stateReader := state.NewPlainStateReader(tx)
+	var stateReader state.StateReader = state.NewPlainStateReader(tx)
+	var keyRecorder *state.VerkleKeyRecorder
+	if cfg.chainConfig.IsMartin(current.Header.Number.Uint64()) {
+		keyRecorder = state.NewVerkleKeyRecorder(stateReader)
+		stateReader = keyRecorder
+	}
 	ibs := state.New(stateReader)
 	stateWriter := state.NewPlainStateWriter(tx, tx, current.Header.Number.Uint64())
 	if cfg.chainConfig.DAOForkSupport && cfg.chainConfig.DAOForkBlock != nil && cfg.chainConfig.DAOForkBlock.Cmp(current.Header.Number) == 0 {
@@ -138,6 +143,9 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 		return err
 	}
 	log.Debug("FinalizeBlockExecution", "current txn", current.Txs.Len(), "current receipt", current.Receipts.Len())
+	if keyRecorder != nil {
+		current.WitnessKeys = keyRecorder.Keys()
+	}
 
 	/*
 		if w.isRunning() {
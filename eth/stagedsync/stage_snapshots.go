@@ -249,6 +249,12 @@ func WaitForDownloader(s *StageState, ctx context.Context, cfg SnapshotsCfg, tx
 	for i := range missingSnapshots {
 		downloadRequest = append(downloadRequest, snapshotsync.NewDownloadRequest(&missingSnapshots[i], "", ""))
 	}
+	// seeds the verkle state snapshots made locally
+	verkleRequests, err := snapshotsync.VerkleDownloadRequests(cfg.snapshots.Dir(), preverified)
+	if err != nil {
+		return err
+	}
+	downloadRequest = append(downloadRequest, verkleRequests...)
 
 	log.Info(fmt.Sprintf("[%s] Fetching torrent files metadata", s.LogPrefix()))
 	for {
@@ -326,7 +332,7 @@ Finish:
 	if err := cfg.snapshots.ReopenFolder(); err != nil {
 		return err
 	}
-	if err := rawdb.WriteSnapshots(tx, cfg.snapshots.Files()); err != nil {
+	if err := writeSnapshots(tx, cfg.snapshots); err != nil {
 		return err
 	}
 	if cfg.dbEventNotifier != nil { // can notify right here, even that write txn is not commit
@@ -392,6 +398,16 @@ func SnapshotsPrune(s *PruneState, cfg SnapshotsCfg, ctx context.Context, tx kv.
 	return nil
 }
 
+// writeSnapshots - keeps the list of snapshot files in the db, the verkle state snapshots included: preverified
+// snapshots which are not in it are not requested again
+func writeSnapshots(tx kv.RwTx, snapshots *snapshotsync.RoSnapshots) error {
+	verkleFiles, err := snapshotsync.VerkleFiles(snapshots.Dir())
+	if err != nil {
+		return err
+	}
+	return rawdb.WriteSnapshots(tx, append(snapshots.Files(), verkleFiles...))
+}
+
 // retiring blocks in a single thread in the brackground
 func retireBlocksInSingleBackgroundThread(s *PruneState, blockRetire *snapshotsync.BlockRetire, ctx context.Context, tx kv.RwTx) (err error) {
 	// if something already happens in background - noop
@@ -403,7 +419,7 @@ func retireBlocksInSingleBackgroundThread(s *PruneState, blockRetire *snapshotsy
 			return fmt.Errorf("[%s] retire blocks last error: %w, fromBlock=%d, toBlock=%d", s.LogPrefix(), res.Err, res.BlockFrom, res.BlockTo)
 		}
 
-		if err := rawdb.WriteSnapshots(tx, blockRetire.Snapshots().Files()); err != nil {
+		if err := writeSnapshots(tx, blockRetire.Snapshots()); err != nil {
 			return err
 		}
 	}
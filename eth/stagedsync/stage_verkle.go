@@ -3,46 +3,50 @@ package stagedsync
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	"github.com/ledgerwatch/erigon/cmd/verkle-transition/verkle"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
 type VerkleCfg struct {
 	db       kv.RwDB
-	coreDb   kv.RwDB
 	cfg      *params.ChainConfig
+	prune    prune.Mode
 	verkleCh chan uint64
-	tmpdir   string
 }
 
 func StageVerkleCfg(
 	db kv.RwDB,
-	coreDb kv.RwDB,
 	cfg *params.ChainConfig,
-	tmpdir string,
+	prune prune.Mode,
 	verkleCh chan uint64,
 ) VerkleCfg {
 	return VerkleCfg{
 		db:       db,
-		coreDb:   coreDb,
-		tmpdir:   tmpdir,
 		cfg:      cfg,
+		prune:    prune,
 		verkleCh: verkleCh,
 	}
 }
 
+// SpawnVerkle follows the tree written by execution, which writes the state of every block after Martin into it,
+// and checks the root of the execution progress against its header
 func SpawnVerkle(s *StageState, tx kv.RwTx, toBlock uint64, cfg VerkleCfg, ctx context.Context) (err error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
-		tx, err = cfg.coreDb.BeginRw(ctx)
+		tx, err = cfg.db.BeginRw(ctx)
 		if err != nil {
 			return err
 		}
@@ -53,55 +57,294 @@ func SpawnVerkle(s *StageState, tx kv.RwTx, toBlock uint64, cfg VerkleCfg, ctx c
 		return err
 	}
 
-	if endBlock < cfg.cfg.MartinBlock.Uint64() {
-		return s.Update(tx, endBlock)
+	if !cfg.cfg.IsMartin(endBlock) || s.BlockNumber >= endBlock {
+		return nil
 	}
 	select {
 	case cfg.verkleCh <- cfg.cfg.MartinBlock.Uint64():
 	default:
 	}
 
-	verkeDb, err := mdbx.Open("verkledb", log.Root(), false)
+	root, err := verkledb.ReadVerkleRoot(tx, endBlock)
 	if err != nil {
 		return err
 	}
-	defer verkeDb.Close()
-	vTx, err := verkeDb.BeginRw(ctx)
+	if root == (common.Hash{}) {
+		// A new node executes up to the block of a verkle snapshot before it has a tree
+		if s.BlockNumber == 0 {
+			return nil
+		}
+		return fmt.Errorf("no verkle root written by execution at block %d", endBlock)
+	}
+	// Headers keep committing to the Merkle trie until the conversion is complete
+	active, err := verkleRootActive(tx, cfg.cfg, endBlock)
 	if err != nil {
 		return err
 	}
-	verkledb.InitDB(vTx)
+	if active {
+		latestHash, err := rawdb.ReadCanonicalHash(tx, endBlock)
+		if err != nil {
+			return err
+		}
+		latestHeader := rawdb.ReadHeader(tx, latestHash, endBlock)
+		if latestHeader == nil {
+			return fmt.Errorf("header of block %d not found", endBlock)
+		}
+		if root != latestHeader.Root {
+			return fmt.Errorf("invalid verkle tree root, have %s, want %s", root, latestHeader.Root)
+		}
+	}
+	if err = s.Update(tx, endBlock); err != nil {
+		return err
+	}
+	log.Info("Verkle tree progress", "root", root, "lastStateDiff", endBlock)
 
-	defer vTx.Commit()
-	progress, err := stages.GetStageProgress(vTx, verkledb.VerkleTrie)
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	root, err := verkledb.ReadVerkleRoot(vTx, progress)
+// executionVerkleTree returns the tree which execution writes the blocks after blockNum into. When there is none, the
+// block at which execution gets one is returned instead: the block before Martin, or the block of a verkle snapshot.
+// Execution has to be at blockNum in tx, with the flat state flushed, in case the tree is built.
+func executionVerkleTree(ctx context.Context, logPrefix string, tx kv.RwTx, cfg ExecuteBlockCfg, blockNum uint64) (*verkledb.VerkleTree, uint64, error) {
+	if cfg.chainConfig.MartinBlock == nil {
+		return nil, math.MaxUint64, nil
+	}
+	if !cfg.chainConfig.IsMartin(blockNum + 1) {
+		return nil, cfg.chainConfig.MartinBlock.Uint64() - 1, nil
+	}
+	root, err := verkledb.ReadVerkleRoot(tx, blockNum)
 	if err != nil {
-		panic(err)
+		return nil, 0, err
 	}
+	if root == (common.Hash{}) {
+		var treeAt uint64
+		if root, treeAt, err = buildVerkleTree(ctx, logPrefix, tx, cfg, blockNum); err != nil || treeAt != blockNum {
+			return nil, treeAt, err
+		}
+	}
+	tree, err := verkledb.NewVerkleTree(tx, root)
+	return tree, blockNum, err
+}
 
-	verkleTree := verkle.NewVerkleTree(vTx, root)
-	var accRoot common.Hash
-	var storageRoot common.Hash
+// buildVerkleTree builds the tree at blockNum, when execution reaches the fork without one. With a gradual conversion
+// the tree starts empty, and the progress of every block of the conversion is worked out of the state before the fork.
+// Otherwise a new node loads the latest verkle snapshot, and executes up to its block without a tree if it is ahead.
+// The tree is built out of the changesets after the VerkleTrie stage progress as a last resort.
+// Returns the root and the block the tree is at.
+func buildVerkleTree(ctx context.Context, logPrefix string, tx kv.RwTx, cfg ExecuteBlockCfg, blockNum uint64) (common.Hash, uint64, error) {
+	chainConfig := cfg.chainConfig
+	martinBlock := chainConfig.MartinBlock.Uint64()
+	verkleProgress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	if chainConfig.IsVerkleConversion() {
+		if blockNum+1 != martinBlock {
+			return common.Hash{}, 0, fmt.Errorf("no verkle root at block %d", blockNum)
+		}
+		scheduled, err := verkledb.ReadConversionProgress(tx, martinBlock)
+		if err != nil {
+			return common.Hash{}, 0, err
+		}
+		if scheduled.Cursor == nil && !scheduled.Done {
+			log.Info(fmt.Sprintf("[%s] Scheduling verkle conversion", logPrefix), "block", martinBlock)
+			if err = transition.WriteConversionSchedule(tx, chainConfig.VerkleConversionStride, martinBlock); err != nil {
+				return common.Hash{}, 0, err
+			}
+		}
+		return common.Hash{}, blockNum, stages.SaveStageProgress(tx, stages.VerkleTrie, blockNum)
+	}
 
-	if err = s.Update(tx, endBlock); err != nil {
+	var snapshots *snapshotsync.RoSnapshots
+	if withSnapshots, ok := cfg.blockReader.(WithSnapshots); ok {
+		snapshots = withSnapshots.Snapshots()
+	}
+	if verkleProgress == 0 && snapshots != nil && snapshots.Cfg().Enabled {
+		path, snapshotAt, root, err := latestVerkleSnapshot(logPrefix, tx, chainConfig, snapshots, blockNum)
+		if err != nil || snapshotAt > blockNum {
+			return common.Hash{}, snapshotAt, err
+		}
+		if path != "" {
+			log.Info(fmt.Sprintf("[%s] Loading verkle snapshot", logPrefix), "file", path, "block", snapshotAt)
+			if _, _, err = snapshotsync.LoadVerkleSnapshot(ctx, tx, path, cfg.dirs.Tmp); err != nil {
+				return common.Hash{}, 0, err
+			}
+			if err = verkledb.WriteVerkleRoot(tx, snapshotAt, root); err != nil {
+				return common.Hash{}, 0, err
+			}
+			log.Info(fmt.Sprintf("[%s] Loaded verkle snapshot", logPrefix), "block", snapshotAt, "root", root)
+			return root, snapshotAt, stages.SaveStageProgress(tx, stages.VerkleTrie, snapshotAt)
+		}
+	}
+
+	if blockNum+1 != martinBlock {
+		return common.Hash{}, 0, fmt.Errorf("no verkle root at block %d", blockNum)
+	}
+	root, err := verkledb.ReadVerkleRoot(tx, verkleProgress)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	verkleTree, err := verkledb.NewVerkleTree(tx, root)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	log.Info(fmt.Sprintf("[%s] Building verkle tree", logPrefix), "from", verkleProgress, "to", blockNum)
+	if _, err = transition.ProcessAccounts(tx, tx, verkleTree, verkleProgress); err != nil {
+		return common.Hash{}, 0, err
+	}
+	// Also writes the root and saves the stage progress
+	root, err = transition.ProcessStorage(tx, tx, verkleTree, verkleProgress)
+	return root, blockNum, err
+}
+
+// latestVerkleSnapshot finds the latest verkle snapshot of a block after Martin, from blockNum on, whose root matches the
+// header. Returns its path, empty if there is none, its block and its root.
+func latestVerkleSnapshot(logPrefix string, tx kv.Tx, chainConfig *params.ChainConfig, snapshots *snapshotsync.RoSnapshots, blockNum uint64) (string, uint64, common.Hash, error) {
+	files, err := snap.VerkleSegments(snapshots.Dir())
+	if err != nil {
+		return "", 0, common.Hash{}, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		snapshotAt, root, err := snapshotsync.ReadVerkleSnapshotHeader(files[i].Path)
+		if err != nil {
+			log.Warn(fmt.Sprintf("[%s] Invalid verkle snapshot", logPrefix), "file", files[i].Path, "err", err)
+			continue
+		}
+		if snapshotAt < blockNum || !chainConfig.IsMartin(snapshotAt) {
+			continue
+		}
+		blockHash, err := rawdb.ReadCanonicalHash(tx, snapshotAt)
+		if err != nil {
+			return "", 0, common.Hash{}, err
+		}
+		header := rawdb.ReadHeader(tx, blockHash, snapshotAt)
+		if header == nil || header.Root != root {
+			log.Warn(fmt.Sprintf("[%s] Verkle snapshot root does not match the header", logPrefix), "file", files[i].Path, "root", root)
+			continue
+		}
+		return files[i].Path, snapshotAt, root, nil
+	}
+	return "", 0, common.Hash{}, nil
+}
+
+// convertVerkleTree moves the share of a gradual conversion of the block into the tree written by its execution, then
+// writes the modified nodes and the root of the block. The conversion reads the flat state from tx, so the batch is
+// flushed first.
+func convertVerkleTree(logPrefix string, tx kv.RwTx, batch ethdb.DbWithPendingMutations, verkleTree *verkledb.VerkleTree, blockNum uint64) error {
+	from, err := verkledb.ReadConversionProgress(tx, blockNum-1)
+	if err != nil {
 		return err
 	}
+	to, err := verkledb.ReadConversionProgress(tx, blockNum)
+	if err != nil {
+		return err
+	}
+	if err = batch.Commit(); err != nil {
+		return err
+	}
+	if err = transition.ConvertRange(tx, tx, verkleTree, from, to); err != nil {
+		return err
+	}
+	if to.Done {
+		log.Info(fmt.Sprintf("[%s] Verkle conversion complete", logPrefix), "block", blockNum)
+	}
+	root, err := verkleTree.Commit()
+	if err != nil {
+		return err
+	}
+	return verkledb.WriteVerkleRoot(tx, blockNum, root)
+}
 
-	if accRoot, err = verkle.ProcessAccounts(tx, vTx, verkleTree, progress); err != nil {
-		panic(err)
+func UnwindVerkle(u *UnwindState, s *StageState, tx kv.RwTx, cfg VerkleCfg, ctx context.Context) (err error) {
+	if s.BlockNumber <= u.UnwindPoint {
+		return nil
+	}
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	treeAt, err := unwindVerkleTree(s.LogPrefix(), tx, u.UnwindPoint, cfg.prune.History.Enabled())
+	if err != nil {
+		return err
 	}
 
-	if storageRoot, err = verkle.ProcessStorage(tx, vTx, verkleTree, progress, accRoot); err != nil {
-		panic(err)
+	// The conversion schedule is worked out of the state before the fork, it only goes with the fork block
+	if cfg.cfg.IsVerkleConversion() && !cfg.cfg.IsMartin(treeAt+1) {
+		if err = verkledb.TruncateConversionProgress(tx, 0); err != nil {
+			return err
+		}
 	}
-	latestHash, _ := rawdb.ReadCanonicalHash(tx, endBlock)
-	latestHeader := rawdb.ReadHeader(tx, latestHash, endBlock)
-	// TODO: end here
-	if storageRoot != latestHeader.Root {
-		return fmt.Errorf("invalid verkle tree root, have %s, want %s", latestHeader.Root, storageRoot)
+	// The blocks between the tree and the unwind point are written again by execution
+	if err = stages.SaveStageProgress(tx, stages.VerkleTrie, treeAt); err != nil {
+		return err
+	}
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unwindVerkleTree resets the tree to the last root committed at or before the unwind point, and returns the block of
+// that root. Reverting the leaves from the changesets would not give back the root of the unwind point: deleted leaves
+// are zeroed rather than removed, so the tree is never left at such a root. Tree nodes are never deleted, so a committed
+// root is still complete, and the blocks after it are written again by execution.
+func unwindVerkleTree(logPrefix string, tx kv.RwTx, unwindPoint uint64, pruned bool) (uint64, error) {
+	treeAt, prevRoot, err := verkledb.ReadLastVerkleRoot(tx, unwindPoint)
+	if err != nil {
+		return 0, err
+	}
+	if prevRoot == (common.Hash{}) {
+		// The tree is built again from the first changeset, which pruned history no longer has
+		if pruned {
+			return 0, fmt.Errorf("no verkle root to unwind to at or before block %d", unwindPoint)
+		}
+		treeAt = 0
+	}
+	if err = verkledb.TruncateVerkleRoots(tx, treeAt+1); err != nil {
+		return 0, err
+	}
+	log.Info(fmt.Sprintf("[%s] Unwound verkle tree", logPrefix), "root", prevRoot, "block", treeAt, "unwindPoint", unwindPoint)
+	return treeAt, nil
+}
+
+func PruneVerkle(s *PruneState, tx kv.RwTx, cfg VerkleCfg, ctx context.Context) (err error) {
+	if !cfg.prune.History.Enabled() {
+		return nil
+	}
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	// Roots are kept as long as the changesets needed to unwind to them
+	pruneTo := cfg.prune.History.PruneTo(s.ForwardProgress)
+	if s.PruneProgress < pruneTo {
+		if err = verkledb.PruneVerkleRoots(tx, pruneTo); err != nil {
+			return err
+		}
+		if err = verkledb.PruneConversionProgress(tx, pruneTo); err != nil {
+			return err
+		}
+		if err = s.DoneAt(tx, pruneTo); err != nil {
+			return err
+		}
 	}
-	log.Info("Verkle tree progress", "root", storageRoot, "lastStateDiff", progress)
 
 	if !useExternalTx {
 		if err = tx.Commit(); err != nil {
@@ -110,3 +353,75 @@ func SpawnVerkle(s *StageState, tx kv.RwTx, toBlock uint64, cfg VerkleCfg, ctx c
 	}
 	return nil
 }
+
+// verkleRootActive tells whether the header of the given block commits to the verkle tree:
+// from MartinBlock on, or once the block before it has completed a gradual conversion
+func verkleRootActive(tx kv.Tx, chainConfig *params.ChainConfig, blockNum uint64) (bool, error) {
+	if !chainConfig.IsMartin(blockNum) {
+		return false, nil
+	}
+	if !chainConfig.IsVerkleConversion() {
+		return true, nil
+	}
+	progress, err := verkledb.ReadConversionProgress(tx, blockNum-1)
+	return progress.Done, err
+}
+
+// commitExecutionVerkleTree checks the root of the tree written by the execution of a block against its header,
+// then writes the modified nodes and the root of the block
+func commitExecutionVerkleTree(tx kv.RwTx, verkleTree *verkledb.VerkleTree, header *types.Header) error {
+	if root := common.Hash(verkleTree.Node().ComputeCommitment().Bytes()); root != header.Root {
+		return fmt.Errorf("invalid verkle tree root, have %s, want %s", root, header.Root)
+	}
+	root, err := verkleTree.Commit()
+	if err != nil {
+		return err
+	}
+	return verkledb.WriteVerkleRoot(tx, header.Number.Uint64(), root)
+}
+
+// merkleTrieStopped tells whether hashed state and intermediate hashes are no longer built at the given stage progress:
+// past PapiBlock, once a gradual conversion is complete, or at all on chains which are verkle from genesis
+func merkleTrieStopped(tx kv.Tx, progress uint64) (bool, error) {
+	chainConfig, err := readChainConfig(tx)
+	if err != nil {
+		return false, err
+	}
+	if chainConfig != nil && chainConfig.IsVerkleConversion() {
+		conversion, err := verkledb.ReadConversionProgress(tx, progress)
+		return conversion.Done, err
+	}
+	if progress >= params.AllCliqueProtocolChanges.PapiBlock.Uint64() {
+		return true, nil
+	}
+	return chainConfig != nil && chainConfig.IsMartin(0), nil
+}
+
+// merkleRootExpected tells whether intermediate hashes can check their root against the header of the given block,
+// which commits to the verkle tree instead once the block before it has completed a gradual conversion
+func merkleRootExpected(tx kv.Tx, blockNum uint64) (bool, error) {
+	chainConfig, err := readChainConfig(tx)
+	if err != nil || chainConfig == nil || !chainConfig.IsVerkleConversion() || blockNum <= chainConfig.MartinBlock.Uint64() {
+		return true, err
+	}
+	conversion, err := verkledb.ReadConversionProgress(tx, blockNum-1)
+	return !conversion.Done, err
+}
+
+// VerkleRootExpected tells whether the header of the given block commits to the verkle tree rather than to the merkle
+// trie. During a gradual conversion the headers keep committing to the merkle trie, the tree is only partly filled.
+func VerkleRootExpected(tx kv.Tx, chainConfig *params.ChainConfig, blockNum uint64) (bool, error) {
+	if !chainConfig.IsVerkleConversion() {
+		return verkleRootActive(tx, chainConfig, blockNum)
+	}
+	merkle, err := merkleRootExpected(tx, blockNum)
+	return !merkle, err
+}
+
+func readChainConfig(tx kv.Getter) (*params.ChainConfig, error) {
+	genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return rawdb.ReadChainConfig(tx, genesisHash)
+}
@@ -5,21 +5,20 @@ import (
 	"fmt"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
-	"github.com/ledgerwatch/log/v3"
 
-	"github.com/ledgerwatch/erigon/cmd/verkle-transition/verkle"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
 // SpawnMiningExecStage
 // TODO:
 // - resubmitAdjustCh - variable is not implemented
 func SpawnMiningExecVerkleStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, ctx context.Context) error {
-	if cfg.miningState.MiningBlock.Header.Number.Uint64() < params.AllCliqueProtocolChanges.MartinBlock.Uint64() {
+	if !cfg.chainConfig.IsMartin(cfg.miningState.MiningBlock.Header.Number.Uint64()) {
 		return nil
 	}
 	select {
@@ -27,40 +26,47 @@ func SpawnMiningExecVerkleStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, ct
 		fmt.Println("lol")
 	default:
 	}
-	verkeDb, err := mdbx.Open("verkledb", log.Root(), false)
+	// Tree updates only land in the mining batch, which is never committed
+	progress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
 	if err != nil {
 		return err
 	}
-	defer verkeDb.Close()
-	vTx, err := verkeDb.BeginRw(ctx)
+
+	root, err := verkledb.ReadVerkleRoot(tx, progress)
 	if err != nil {
 		return err
 	}
-
-	verkledb.InitDB(vTx)
-
-	defer vTx.Rollback()
-	progress, err := stages.GetStageProgress(vTx, verkledb.VerkleTrie)
+	verkleTree, err := verkledb.NewVerkleTree(tx, root)
 	if err != nil {
 		return err
 	}
 
-	root, err := verkledb.ReadVerkleRoot(vTx, progress)
-	if err != nil {
-		panic(err)
+	current := cfg.miningState.MiningBlock
+	if len(current.WitnessKeys) > 0 && root != (common.Hash{}) {
+		// The witness is proven against the parent state, before the block is applied to the tree
+		if current.Header.VerkleProof, current.Header.VerkleKeyVals, err = verkleTree.Prove(current.WitnessKeys); err != nil {
+			return fmt.Errorf("verkle witness: %w", err)
+		}
+		current.Header.Verkle = true
 	}
-	from := progress
-	verkleTree := verkle.NewVerkleTree(vTx, root)
 
-	var accRoot common.Hash
-	var storageRoot common.Hash
-	if accRoot, err = verkle.ProcessAccounts(tx, vTx, verkleTree, from); err != nil {
-		panic(err)
+	if _, err = transition.ProcessAccounts(tx, tx, verkleTree, progress); err != nil {
+		return err
 	}
 
-	if storageRoot, err = verkle.ProcessStorage(tx, vTx, verkleTree, from, accRoot); err != nil {
-		panic(err)
+	storageRoot, err := transition.ProcessStorage(tx, tx, verkleTree, progress)
+	if err != nil {
+		return err
 	}
-	cfg.miningState.MiningBlock.Header.Root = storageRoot
+	current.Header.Root = storageRoot
 	return nil
 }
+
+// miningVerkleRoot tells whether the block mined on top of the current block commits to the verkle tree
+func miningVerkleRoot(tx kv.Tx, chainConfig *params.ChainConfig) (bool, error) {
+	current := *rawdb.ReadCurrentBlockNumber(tx)
+	if chainConfig.IsVerkleConversion() {
+		return verkleRootActive(tx, chainConfig, current+1)
+	}
+	return chainConfig.IsMartin(current + 2), nil
+}
@@ -0,0 +1,83 @@
+package stagedsync
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMinedBlockStateless mines a block on a chain which is verkle from genesis, then runs it again
+// with nothing but the witness in its header and the root of the parent
+func TestMinedBlockStateless(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.HexToAddress("0x1234")
+	coinbase := common.HexToAddress("0xc014ba5e")
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.MartinBlock = big.NewInt(0)
+	engine := ethash.NewFaker()
+
+	db := verkledb.NewMemDB()
+	defer db.Close()
+	_, genesis, err := core.CommitGenesisBlock(db, &core.Genesis{
+		Config: &chainConfig,
+		Alloc:  core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+	})
+	require.NoError(t, err)
+
+	// Mine block 1 with a transfer, the mined state is never committed
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   genesis.GasLimit(),
+		Time:       genesis.Time() + 10,
+		Difficulty: genesis.Difficulty(),
+		Coinbase:   coinbase,
+		BaseFee:    misc.CalcBaseFee(&chainConfig, genesis.Header()),
+	}
+	gasPrice, _ := uint256.FromBig(new(big.Int).Mul(header.BaseFee, big.NewInt(2)))
+	transfer, err := types.SignTx(types.NewTransaction(0, recipient, uint256.NewInt(1000), 50_000, gasPrice, nil), *types.LatestSignerForChainID(chainConfig.ChainID), key)
+	require.NoError(t, err)
+
+	miner := NewMiningState(&params.MiningConfig{Etherbase: coinbase})
+	miner.MiningBlock.Header = header
+	miner.MiningBlock.LocalTxs = types.NewTransactionsFixedOrder(types.Transactions{transfer})
+	miner.MiningBlock.RemoteTxs = types.NewTransactionsFixedOrder(nil)
+	cfg := StageMiningExecCfg(db, miner, nil, chainConfig, engine, &vm.Config{}, t.TempDir(), nil, nil)
+	require.NoError(t, SpawnMiningExecStage(&StageState{}, tx, cfg, nil))
+	require.NoError(t, SpawnMiningExecVerkleStage(&StageState{}, tx, cfg, context.Background()))
+	current := miner.MiningBlock
+	require.True(t, current.Header.Verkle)
+	require.Len(t, current.Txs, 1)
+	block := types.NewBlock(current.Header, current.Txs, current.Uncles, current.Receipts)
+	tx.Rollback()
+
+	blockHashFunc := func(n uint64) common.Hash { return genesis.Hash() }
+	execRs, err := core.ExecuteBlockStateless(&chainConfig, &vm.Config{}, blockHashFunc, engine, block, genesis.Header(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, block.GasUsed(), uint64(execRs.GasUsed))
+	require.Equal(t, block.ReceiptHash(), execRs.ReceiptRoot)
+	require.Len(t, execRs.Receipts, 1)
+	require.Equal(t, types.ReceiptStatusSuccessful, execRs.Receipts[0].Status)
+
+	// The witness does not prove anything against another parent
+	otherParent := types.CopyHeader(genesis.Header())
+	otherParent.Root = block.Root()
+	_, err = core.ExecuteBlockStateless(&chainConfig, &vm.Config{}, blockHashFunc, engine, block, otherParent, nil, nil)
+	require.Error(t, err)
+}
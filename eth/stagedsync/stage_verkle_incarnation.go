@@ -8,12 +8,12 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common/cmp"
 	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
 type VerkleIncarnationCfg struct {
@@ -101,6 +101,8 @@ func verkleIncarnation(logPrefix string, tx kv.RwTx, blockFrom, blockTo uint64,
 		}
 		return next(k, addressBytes, val)
 	}, etl.IdentityLoadFunc, etl.TransformArgs{
+		// Incarnations are read from the latest state, so any appearance of an address is as good as another
+		BufferType:      etl.SortableOldestAppearedBuffer,
 		Quit:            quitCh,
 		ExtractStartKey: dbutils.EncodeBlockNumber(blockFrom),
 		ExtractEndKey:   dbutils.EncodeBlockNumber(blockTo),
@@ -109,3 +111,54 @@ func verkleIncarnation(logPrefix string, tx kv.RwTx, blockFrom, blockTo uint64,
 		},
 	})
 }
+
+func UnwindVerkleIncarnation(u *UnwindState, s *StageState, tx kv.RwTx, cfg TxLookupCfg, ctx context.Context) (err error) {
+	if s.BlockNumber <= u.UnwindPoint {
+		return nil
+	}
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	// The earliest changeset entry of an account holds its incarnation at the unwind point
+	prevAccounts := map[common.Address][]byte{}
+	if err = changeset.ForRange(tx, kv.AccountChangeSet, u.UnwindPoint+1, s.BlockNumber+1, func(_ uint64, k, v []byte) error {
+		address := common.BytesToAddress(k)
+		if _, ok := prevAccounts[address]; !ok {
+			prevAccounts[address] = common.CopyBytes(v)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for address, encoded := range prevAccounts {
+		if len(encoded) == 0 {
+			if err = tx.Delete(verkledb.VerkleIncarnation, address[:]); err != nil {
+				return err
+			}
+			continue
+		}
+		var acc accounts.Account
+		if err = acc.DecodeForStorage(encoded); err != nil {
+			return err
+		}
+		if err = verkledb.WriteVerkleIncarnation(tx, address, acc.Incarnation); err != nil {
+			return err
+		}
+	}
+
+	if err = u.Done(tx); err != nil {
+		return err
+	}
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package stagedsync
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/transition"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionVerkleTree(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	ctx := context.Background()
+
+	// Nothing to write into before Martin, execution gets the tree at the block before it
+	tree, treeAt, err := executionVerkleTree(ctx, "test", tx, ExecuteBlockCfg{chainConfig: params.AllCliqueProtocolChanges}, 10)
+	require.NoError(t, err)
+	require.Nil(t, tree)
+	require.Equal(t, params.AllCliqueProtocolChanges.MartinBlock.Uint64()-1, treeAt)
+
+	// After Martin the tree of the block before is always there
+	cfg := ExecuteBlockCfg{chainConfig: params.VerkleDevChainConfig}
+	_, _, err = executionVerkleTree(ctx, "test", tx, cfg, 10)
+	require.Error(t, err)
+
+	genesisTree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+	require.NoError(t, genesisTree.Insert(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{1}, 32)))
+	root, err := genesisTree.Commit()
+	require.NoError(t, err)
+	require.NoError(t, verkledb.WriteVerkleRoot(tx, 10, root))
+	tree, treeAt, err = executionVerkleTree(ctx, "test", tx, cfg, 10)
+	require.NoError(t, err)
+	require.NotNil(t, tree)
+	require.Equal(t, uint64(10), treeAt)
+
+	// The root is only committed if the header agrees with it
+	require.NoError(t, tree.Insert(common.LeftPadBytes([]byte{2}, 32), common.LeftPadBytes([]byte{2}, 32)))
+	newRoot := common.Hash(tree.Node().ComputeCommitment().Bytes())
+	require.Error(t, commitExecutionVerkleTree(tx, tree, &types.Header{Number: big.NewInt(11), Root: root}))
+	stored, err := verkledb.ReadVerkleRoot(tx, 11)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, stored)
+	require.NoError(t, commitExecutionVerkleTree(tx, tree, &types.Header{Number: big.NewInt(11), Root: newRoot}))
+	stored, err = verkledb.ReadVerkleRoot(tx, 11)
+	require.NoError(t, err)
+	require.Equal(t, newRoot, stored)
+
+	// The tree of the next batch opens from the committed root
+	tree, _, err = executionVerkleTree(ctx, "test", tx, cfg, 11)
+	require.NoError(t, err)
+	value, err := tree.Get(common.LeftPadBytes([]byte{2}, 32))
+	require.NoError(t, err)
+	require.Equal(t, common.LeftPadBytes([]byte{2}, 32), value)
+}
+
+func TestMerkleRootExpectedDuringConversion(t *testing.T) {
+	const martinBlock, lastBlock = 3, 10
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.MartinBlock = big.NewInt(martinBlock)
+	chainConfig.VerkleConversionStride = 2
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, common.Hash{}, 0))
+	require.NoError(t, rawdb.WriteChainConfig(tx, common.Hash{}, &chainConfig))
+
+	// 6 accounts at the fork take 3 steps, MartinBlock+2 completes the conversion
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		ibs := state.New(state.NewPlainStateReader(tx))
+		if blockNum == 1 {
+			for i := byte(1); i <= 6; i++ {
+				ibs.AddBalance(common.BytesToAddress([]byte{i}), uint256.NewInt(uint64(i)))
+			}
+		} else {
+			ibs.AddBalance(common.BytesToAddress([]byte{1}), uint256.NewInt(blockNum))
+		}
+		w := state.NewPlainStateWriter(tx, tx, blockNum)
+		require.NoError(t, ibs.CommitBlock(chainConfig.Rules(blockNum), w))
+		require.NoError(t, w.WriteChangeSets())
+		require.NoError(t, w.WriteHistory())
+	}
+	check := func() {
+		for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+			expected, err := merkleRootExpected(tx, blockNum)
+			require.NoError(t, err)
+			require.Equal(t, blockNum <= martinBlock+2, expected, "block %d", blockNum)
+		}
+		for progress := uint64(martinBlock); progress <= lastBlock; progress++ {
+			stopped, err := merkleTrieStopped(tx, progress)
+			require.NoError(t, err)
+			require.Equal(t, progress >= martinBlock+2, stopped, "progress %d", progress)
+		}
+	}
+
+	// Execution works out the whole conversion when it reaches the fork, and starts with an empty tree
+	tree, treeAt, err := executionVerkleTree(context.Background(), "test", tx, ExecuteBlockCfg{chainConfig: &chainConfig}, martinBlock-1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(martinBlock-1), treeAt)
+	require.Equal(t, common.Hash{}, common.Hash(tree.Node().ComputeCommitment().Bytes()))
+	check()
+	verkleProgress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	require.NoError(t, err)
+	require.Equal(t, uint64(martinBlock-1), verkleProgress)
+}
+
+func TestUnwindVerkleTreeToCommittedRoot(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+
+	tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+	require.NoError(t, tree.Insert(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{1}, 32)))
+	root5, err := tree.Commit()
+	require.NoError(t, err)
+	require.NoError(t, verkledb.WriteVerkleRoot(tx, 5, root5))
+	require.NoError(t, tree.Insert(common.LeftPadBytes([]byte{2}, 32), common.LeftPadBytes([]byte{2}, 32)))
+	root10, err := tree.Commit()
+	require.NoError(t, err)
+	require.NoError(t, verkledb.WriteVerkleRoot(tx, 10, root10))
+
+	// Without a root at the unwind point the tree goes back to the last root before it
+	treeAt, err := unwindVerkleTree("test", tx, 8, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), treeAt)
+	blockNum, root, err := verkledb.ReadLastVerkleRoot(tx, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), blockNum)
+	require.Equal(t, root5, root)
+	tree, err = verkledb.NewVerkleTree(tx, root)
+	require.NoError(t, err)
+	value, err := tree.Get(common.LeftPadBytes([]byte{2}, 32))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	// Before the first root the tree is built again from scratch, unless the changesets are pruned
+	_, err = unwindVerkleTree("test", tx, 3, true)
+	require.Error(t, err)
+	treeAt, err = unwindVerkleTree("test", tx, 3, false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), treeAt)
+	_, root, err = verkledb.ReadLastVerkleRoot(tx, 10)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, root)
+}
+
+// TestExecutionVerkleTreeMatchesChangesets runs the same block after Martin through the tree written by execution and
+// through the tree built out of its changesets, like the one of a mined block, and compares the roots
+func TestExecutionVerkleTreeMatchesChangesets(t *testing.T) {
+	const martinBlock = 3
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.MartinBlock = big.NewInt(martinBlock)
+	var (
+		alice    = common.HexToAddress("0xa11ce")
+		bob      = common.HexToAddress("0xb0b")
+		carol    = common.HexToAddress("0xca201")
+		contract = common.HexToAddress("0xc0de")
+		doomed   = common.HexToAddress("0xdead")
+		created  = common.HexToAddress("0xc4ea7ed")
+		code     = common.FromHex("0x6001600055600160015560016002556001600355")
+	)
+	slot := func(i uint64) *common.Hash {
+		h := common.BigToHash(new(big.Int).SetUint64(i))
+		return &h
+	}
+
+	// buildFork executes the blocks before Martin, then has execution build the tree of the block before it
+	buildFork := func() (kv.RwTx, *verkledb.VerkleTree) {
+		_, tx := memdb.NewTestTx(t)
+		require.NoError(t, verkledb.InitDB(tx))
+		for blockNum := uint64(1); blockNum < martinBlock; blockNum++ {
+			ibs := state.New(state.NewPlainStateReader(tx))
+			if blockNum == 1 {
+				ibs.AddBalance(alice, uint256.NewInt(1000))
+				ibs.AddBalance(bob, uint256.NewInt(2000))
+				for _, addr := range []common.Address{contract, doomed} {
+					ibs.CreateAccount(addr, true)
+					ibs.SetCode(addr, code)
+					for i := uint64(0); i < 3; i++ {
+						ibs.SetState(addr, slot(i), *uint256.NewInt(i + 1))
+					}
+				}
+			} else {
+				ibs.AddBalance(bob, uint256.NewInt(blockNum))
+			}
+			w := state.NewPlainStateWriter(tx, tx, blockNum)
+			require.NoError(t, ibs.CommitBlock(chainConfig.Rules(blockNum), w))
+			require.NoError(t, w.WriteChangeSets())
+			require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, blockNum))
+		}
+		tree, treeAt, err := executionVerkleTree(context.Background(), "test", tx, ExecuteBlockCfg{chainConfig: &chainConfig}, martinBlock-1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(martinBlock-1), treeAt)
+		return tx, tree
+	}
+	// The block after the fork changes balances, nonces, code and storage, and destroys a contract
+	applyBlock := func(ibs *state.IntraBlockState) {
+		ibs.SubBalance(alice, uint256.NewInt(100))
+		ibs.SetNonce(alice, 1)
+		ibs.AddBalance(carol, uint256.NewInt(100))
+		ibs.SetState(contract, slot(0), *uint256.NewInt(0))
+		ibs.SetState(contract, slot(1), *uint256.NewInt(7))
+		ibs.SetState(contract, slot(5), *uint256.NewInt(5))
+		ibs.CreateAccount(created, true)
+		ibs.SetCode(created, code[:4])
+		ibs.SetState(created, slot(0), *uint256.NewInt(9))
+		ibs.Suicide(doomed)
+	}
+
+	// Execution reads the tree and writes the block into it next to the flat state
+	tx, tree := buildFork()
+	ibs := state.New(verkledb.NewStateReader(tree.Node(), tree.Resolve).WithIncarnations(state.NewPlainStateReader(tx)))
+	applyBlock(ibs)
+	verkleWriter := state.NewVerkleStateWriter(tree.Node(), tree.Resolve).WithLookups(verkledb.NewTreeKeys(tx, true))
+	require.NoError(t, ibs.CommitBlock(chainConfig.Rules(martinBlock), state.NewVerkleTeeWriter(state.NewPlainStateWriter(tx, tx, martinBlock), verkleWriter)))
+	executionRoot, err := tree.Commit()
+	require.NoError(t, err)
+
+	// The changesets of the same block go into the tree after it has been executed
+	tx, tree = buildFork()
+	ibs = state.New(state.NewPlainStateReader(tx))
+	applyBlock(ibs)
+	w := state.NewPlainStateWriter(tx, tx, martinBlock)
+	require.NoError(t, ibs.CommitBlock(chainConfig.Rules(martinBlock), w))
+	require.NoError(t, w.WriteChangeSets())
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, martinBlock))
+	_, err = transition.ProcessAccounts(tx, tx, tree, martinBlock-1)
+	require.NoError(t, err)
+	changesetRoot, err := transition.ProcessStorage(tx, tx, tree, martinBlock-1)
+	require.NoError(t, err)
+
+	require.NotEqual(t, common.Hash{}, executionRoot)
+	require.Equal(t, executionRoot, changesetRoot)
+}
@@ -5,11 +5,9 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
-	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/shards"
 )
 
@@ -66,7 +64,11 @@ func MiningStages(
 			ID:          stages.IntermediateHashes,
 			Description: "Generate intermediate hashes and computing state root",
 			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, tx kv.RwTx) error {
-				if *rawdb.ReadCurrentBlockNumber(tx) >= params.AllCliqueProtocolChanges.MartinBlock.Uint64()-2 {
+				verkleRoot, err := miningVerkleRoot(tx, &execCfg.chainConfig)
+				if err != nil {
+					return err
+				}
+				if verkleRoot {
 					return SpawnMiningExecVerkleStage(s, tx, execCfg, ctx)
 				}
 				stateRoot, err := SpawnIntermediateHashesStage(s, u, tx, trieCfg, ctx)
@@ -1,6 +1,8 @@
 package tracers
 
 import (
+	"encoding/json"
+
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 )
@@ -9,6 +11,7 @@ import (
 type TraceConfig struct {
 	*vm.LogConfig
 	Tracer         *string
+	TracerConfig   json.RawMessage // Options of the selected tracer, e.g. {"onlyTopCall": true} for the callTracer
 	Timeout        *string
 	Reexec         *uint64
 	NoRefunds      *bool // Turns off gas refunds when tracing
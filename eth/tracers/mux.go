@@ -0,0 +1,120 @@
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+func init() {
+	RegisterNativeTracer("muxTracer", newMuxTracer)
+}
+
+// muxTracer runs several tracers over a single execution of the transaction.
+// Its config maps the names of the tracers to their own config, e.g.
+// {"callTracer": {"onlyTopCall": true}, "prestateTracer": {}}, and its result
+// maps the same names to the results of the tracers.
+type muxTracer struct {
+	names   []string
+	tracers []Tracer
+}
+
+func newMuxTracer(ctx *Context, cfg json.RawMessage) (Tracer, error) {
+	var configs map[string]json.RawMessage
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &configs); err != nil {
+			return nil, fmt.Errorf("invalid tracer config: %w", err)
+		}
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("muxTracer needs at least one tracer")
+	}
+	t := &muxTracer{names: make([]string, 0, len(configs))}
+	for name := range configs {
+		t.names = append(t.names, name)
+	}
+	// Run the tracers in a stable order
+	sort.Strings(t.names)
+	for _, name := range t.names {
+		tracer, err := New(name, ctx, configs[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		t.tracers = append(t.tracers, tracer)
+	}
+	return t, nil
+}
+
+func (t *muxTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	for _, tracer := range t.tracers {
+		tracer.CaptureStart(env, depth, from, to, precompile, create, callType, input, gas, value, code)
+	}
+}
+
+func (t *muxTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for _, tracer := range t.tracers {
+		tracer.CaptureState(env, pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (t *muxTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	for _, tracer := range t.tracers {
+		tracer.CaptureFault(env, pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (t *muxTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) {
+	for _, tracer := range t.tracers {
+		tracer.CaptureEnd(depth, output, startGas, endGas, d, err)
+	}
+}
+
+func (t *muxTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	for _, tracer := range t.tracers {
+		tracer.CaptureSelfDestruct(from, to, value)
+	}
+}
+
+func (t *muxTracer) CaptureAccountRead(account common.Address) error {
+	for _, tracer := range t.tracers {
+		if err := tracer.CaptureAccountRead(account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *muxTracer) CaptureAccountWrite(account common.Address) error {
+	for _, tracer := range t.tracers {
+		if err := tracer.CaptureAccountWrite(account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetResult returns the json-encoded results of the tracers by name.
+func (t *muxTracer) GetResult() (json.RawMessage, error) {
+	results := make(map[string]json.RawMessage, len(t.tracers))
+	for i, tracer := range t.tracers {
+		res, err := tracer.GetResult()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.names[i], err)
+		}
+		results[t.names[i]] = res
+	}
+	return json.Marshal(results)
+}
+
+// Stop terminates execution of all the tracers at the first opportune moment.
+func (t *muxTracer) Stop(err error) {
+	for _, tracer := range t.tracers {
+		tracer.Stop(err)
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+// internalFailure is reported for calls that failed without an execution error,
+// e.g. for lack of balance or a contract creation collision.
+const internalFailure = "internal failure"
+
+func init() {
+	tracers.RegisterNativeTracer("callTracer", newCallTracer)
+}
+
+// callFrame is a single call of the trace, with the fields in the order of the JavaScript callTracer output.
+type callFrame struct {
+	Type    string      `json:"type"`
+	From    string      `json:"from"`
+	To      string      `json:"to,omitempty"`
+	Value   string      `json:"value,omitempty"`
+	Gas     string      `json:"gas,omitempty"`
+	GasUsed string      `json:"gasUsed,omitempty"`
+	Input   string      `json:"input,omitempty"`
+	Output  string      `json:"output,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Calls   []callFrame `json:"calls,omitempty"`
+	Logs    []callLog   `json:"logs,omitempty"`
+
+	precompile bool // precompile calls are executed, but not reported
+	entered    bool // the callee has code to execute, so the gas it was given is known
+}
+
+// callLog is a log emitted by a call, reported with withLog.
+type callLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// callTracerConfig holds the options of the callTracer.
+type callTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // Report the top-level call only, without the calls it makes
+	WithLog     bool `json:"withLog"`     // Report the logs emitted by the calls that did not fail
+}
+
+// callTracer is the native implementation of the JavaScript callTracer.
+// It reports the tree of calls made by a transaction.
+type callTracer struct {
+	config            callTracerConfig
+	callstack         []*callFrame
+	pending           *callFrame // call opcode that has not entered the callee yet
+	activePrecompiles []common.Address
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	t := &callTracer{}
+	if err := parseConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *callTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	if depth > 0 && t.config.OnlyTopCall {
+		return
+	}
+	t.pending = nil
+	frame := &callFrame{
+		Type:       callTypeOpCode(callType).String(),
+		From:       addrToHex(from),
+		To:         addrToHex(to),
+		Input:      bytesToHex(input),
+		Gas:        uintToHex(gas),
+		precompile: precompile,
+		entered:    len(code) > 0 || (create && len(input) > 0),
+	}
+	if depth == 0 {
+		t.activePrecompiles = vm.ActivePrecompiles(env.ChainRules())
+	}
+	if callType != vm.DELEGATECALLT && callType != vm.STATICCALLT {
+		frame.Value = hexutil.EncodeBig(value)
+	}
+	t.callstack = append(t.callstack, frame)
+}
+
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		env.Cancel()
+		return
+	}
+	// The top-level code runs at depth 1
+	if depth > 1 && t.config.OnlyTopCall {
+		return
+	}
+	t.flushPending()
+	if err != nil {
+		t.fault(err)
+		return
+	}
+	stack := scope.Stack
+	if t.config.WithLog && op >= vm.LOG0 && op <= vm.LOG4 {
+		log := callLog{
+			Address: scope.Contract.Address(),
+			Topics:  make([]common.Hash, int(op-vm.LOG0)),
+			Data:    scope.Memory.GetCopy(stack.Back(0).Uint64(), stack.Back(1).Uint64()),
+		}
+		for i := range log.Topics {
+			log.Topics[i] = stack.Back(2 + i).Bytes32()
+		}
+		frame := t.callstack[len(t.callstack)-1]
+		frame.Logs = append(frame.Logs, log)
+		return
+	}
+	if t.config.OnlyTopCall {
+		return
+	}
+	// Calls that fail before entering the callee, e.g. for lack of balance, are only visible here
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		to := common.Address(stack.Back(1).Bytes20())
+		if t.isPrecompiled(to) {
+			return
+		}
+		off := 0
+		if op == vm.CALL || op == vm.CALLCODE {
+			off = 1
+		}
+		t.pending = &callFrame{
+			Type:  op.String(),
+			From:  addrToHex(scope.Contract.Address()),
+			To:    addrToHex(to),
+			Input: bytesToHex(scope.Memory.GetCopy(stack.Back(2+off).Uint64(), stack.Back(3+off).Uint64())),
+			Error: internalFailure,
+		}
+		if off == 1 {
+			t.pending.Value = hexutil.EncodeBig(stack.Back(2).ToBig())
+		}
+	case vm.CREATE, vm.CREATE2:
+		t.pending = &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(scope.Contract.Address()),
+			Value:   hexutil.EncodeBig(stack.Back(0).ToBig()),
+			GasUsed: uintToHex(0),
+			Input:   bytesToHex(scope.Memory.GetCopy(stack.Back(1).Uint64(), stack.Back(2).Uint64())),
+			Error:   internalFailure,
+		}
+	}
+}
+
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if depth > 1 && t.config.OnlyTopCall {
+		return
+	}
+	t.flushPending()
+	t.fault(err)
+}
+
+// fault records the first execution error of the current call.
+func (t *callTracer) fault(err error) {
+	if frame := t.callstack[len(t.callstack)-1]; frame.Error == "" {
+		frame.Error = err.Error()
+	}
+}
+
+func (t *callTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) {
+	if depth > 0 && t.config.OnlyTopCall {
+		return
+	}
+	t.flushPending()
+	frame := t.callstack[len(t.callstack)-1]
+	if depth == 0 {
+		frame.GasUsed = uintToHex(startGas - endGas)
+		if err != nil && frame.Error == "" {
+			frame.Error = err.Error()
+		}
+		if frame.Error == "" || (frame.Error == vm.ErrExecutionReverted.Error() && len(output) > 0) {
+			frame.Output = bytesToHex(output)
+		}
+		return
+	}
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	if frame.precompile {
+		return
+	}
+	isCreate := frame.Type == vm.CREATE.String() || frame.Type == vm.CREATE2.String()
+	if !frame.entered {
+		frame.Gas = ""
+	}
+	if frame.entered || isCreate {
+		frame.GasUsed = uintToHex(startGas - endGas)
+	}
+	switch {
+	case err == nil:
+		frame.Output = bytesToHex(output)
+	case frame.Error == "":
+		frame.Error = internalFailure
+	}
+	if isCreate && err != nil {
+		frame.To = ""
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, *frame)
+}
+
+// flushPending reports the call opcode of the previous step, if it did not enter the callee.
+func (t *callTracer) flushPending() {
+	if t.pending == nil {
+		return
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, *t.pending)
+	t.pending = nil
+}
+
+func (t *callTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	if t.config.OnlyTopCall {
+		return
+	}
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, callFrame{
+		Type:  vm.SELFDESTRUCT.String(),
+		From:  addrToHex(from),
+		To:    addrToHex(to),
+		Value: hexutil.EncodeBig(value),
+	})
+}
+
+func (t *callTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *callTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+// GetResult returns the json-encoded top-level call.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	if t.config.WithLog {
+		clearFailedLogs(t.callstack[0], false)
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *callTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// clearFailedLogs drops the logs of the failed calls, and of the calls they made,
+// as they are reverted along with the rest of the state changes.
+func clearFailedLogs(frame *callFrame, parentFailed bool) {
+	failed := parentFailed || frame.Error != ""
+	if failed {
+		frame.Logs = nil
+	}
+	for i := range frame.Calls {
+		clearFailedLogs(&frame.Calls[i], failed)
+	}
+}
+
+func (t *callTracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.activePrecompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+func init() {
+	tracers.RegisterNativeTracer("prestateTracer", newPrestateTracer)
+}
+
+// prestateAccount is the state of an account before the transaction, as far as the transaction accesses it.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// postAccount is the state of an account after the transaction, as far as the transaction modified it.
+type postAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateDiff is the result of the prestateTracer in diff mode.
+type prestateDiff struct {
+	Pre  map[common.Address]*prestateAccount `json:"pre"`
+	Post map[common.Address]*postAccount     `json:"post"`
+}
+
+// prestateTracerConfig holds the options of the prestateTracer.
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // Report the modified accounts before and after the transaction
+}
+
+// prestateTracer is the native implementation of the JavaScript prestateTracer.
+// It outputs sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block.
+type prestateTracer struct {
+	config   prestateTracerConfig
+	env      *vm.EVM
+	prestate map[common.Address]*prestateAccount
+	created  map[common.Address]bool // accounts created by the transaction, tracked in diff mode
+	create   bool
+	to       common.Address
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	t := &prestateTracer{
+		prestate: make(map[common.Address]*prestateAccount),
+		created:  make(map[common.Address]bool),
+	}
+	if err := parseConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *prestateTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	if create && t.config.DiffMode {
+		t.env = env
+		t.created[to] = true
+		t.lookupAccount(to)
+	}
+	if depth != 0 {
+		return
+	}
+	t.env = env
+	t.create = create
+	t.to = to
+
+	// The value is not transferred yet, but the gas is already bought
+	rules := env.ChainRules()
+	intrinsicGas, err := core.IntrinsicGas(input, nil, create, rules.IsHomestead, rules.IsIstanbul)
+	if err != nil {
+		return
+	}
+	t.lookupAccount(from)
+	if !create {
+		t.lookupAccount(to)
+	}
+	// The miner is paid for the gas, so its balance changes
+	if t.config.DiffMode {
+		t.lookupAccount(env.Context().Coinbase)
+	}
+	sender := t.prestate[from]
+	gasCost := new(big.Int).Mul(env.TxContext().GasPrice, new(big.Int).SetUint64(intrinsicGas+gas))
+	sender.Balance = (*hexutil.Big)(new(big.Int).Add(sender.Balance.ToInt(), gasCost))
+	// The nonce of a call is incremented before execution, the one of a create during
+	if !create {
+		sender.Nonce--
+	}
+}
+
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		env.Cancel()
+		return
+	}
+	if err != nil {
+		return
+	}
+	stack := scope.Stack
+	caller := scope.Contract.Address()
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.EXTCODEHASH, vm.BALANCE, vm.SELFDESTRUCT:
+		t.lookupAccount(stack.Back(0).Bytes20())
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(stack.Back(1).Bytes20())
+	case vm.CREATE:
+		t.lookupAccount(crypto.CreateAddress(caller, env.IntraBlockState().GetNonce(caller)))
+	case vm.CREATE2:
+		init := scope.Memory.GetCopy(stack.Back(1).Uint64(), stack.Back(2).Uint64())
+		salt := common.Hash(stack.Back(3).Bytes32())
+		t.lookupAccount(crypto.CreateAddress2(caller, salt, crypto.Keccak256(init)))
+	case vm.SLOAD, vm.SSTORE:
+		t.lookupStorage(caller, stack.Back(0).Bytes32())
+	}
+}
+
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *prestateTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) {
+	if depth != 0 {
+		return
+	}
+	// Any existing state at the created address would have made the transaction invalid
+	if t.create && !t.config.DiffMode {
+		delete(t.prestate, t.to)
+	}
+}
+
+func (t *prestateTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+}
+
+func (t *prestateTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+// GetResult returns the json-encoded prestate of the accessed accounts,
+// or the state of the modified accounts before and after the transaction in diff mode.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	var result interface{} = t.prestate
+	if t.config.DiffMode {
+		result = t.diff()
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// diff compares the prestate with the current state, keeping the modified accounts and storage slots only.
+// Self-destructed accounts are reported in the pre state only, and accounts that did not exist before
+// the transaction in the post state only.
+func (t *prestateTracer) diff() *prestateDiff {
+	res := &prestateDiff{
+		Pre:  make(map[common.Address]*prestateAccount),
+		Post: make(map[common.Address]*postAccount),
+	}
+	if t.env == nil {
+		return res
+	}
+	ibs := t.env.IntraBlockState()
+	for addr, pre := range t.prestate {
+		if ibs.HasSuicided(addr) {
+			res.Pre[addr] = pre
+			continue
+		}
+		post := &postAccount{}
+		modified := false
+		if balance := ibs.GetBalance(addr).ToBig(); balance.Cmp(pre.Balance.ToInt()) != 0 {
+			post.Balance = (*hexutil.Big)(balance)
+			modified = true
+		}
+		if nonce := ibs.GetNonce(addr); nonce != pre.Nonce {
+			post.Nonce = nonce
+			modified = true
+		}
+		if code := ibs.GetCode(addr); !bytes.Equal(code, pre.Code) {
+			post.Code = common.CopyBytes(code)
+			modified = true
+		}
+		storage := make(map[common.Hash]common.Hash)
+		for key, value := range pre.Storage {
+			key := key
+			var current uint256.Int
+			ibs.GetState(addr, &key, &current)
+			if current.Bytes32() == value {
+				continue
+			}
+			modified = true
+			storage[key] = value
+			// Cleared slots are only reported in the pre state
+			if !current.IsZero() {
+				if post.Storage == nil {
+					post.Storage = make(map[common.Hash]common.Hash)
+				}
+				post.Storage[key] = current.Bytes32()
+			}
+		}
+		if !modified {
+			continue
+		}
+		res.Post[addr] = post
+		if t.created[addr] && pre.Balance.ToInt().Sign() == 0 && pre.Nonce == 0 && len(pre.Code) == 0 && len(storage) == 0 {
+			continue
+		}
+		res.Pre[addr] = &prestateAccount{
+			Balance: pre.Balance,
+			Nonce:   pre.Nonce,
+			Code:    pre.Code,
+			Storage: storage,
+		}
+	}
+	return res
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount adds the account to the prestate, the first time it is accessed.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	ibs := t.env.IntraBlockState()
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(ibs.GetBalance(addr).ToBig()),
+		Nonce:   ibs.GetNonce(addr),
+		Code:    common.CopyBytes(ibs.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage adds the storage slot to the prestate of the account, the first time it is accessed.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	storage := t.prestate[addr].Storage
+	if _, ok := storage[key]; ok {
+		return
+	}
+	var value uint256.Int
+	t.env.IntraBlockState().GetState(addr, &key, &value)
+	storage[key] = value.Bytes32()
+}
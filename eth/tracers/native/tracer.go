@@ -0,0 +1,58 @@
+// Package native contains Go implementations of the built-in JavaScript tracers.
+// They are registered under the names of the JavaScript ones, which stay
+// available with the "Js" suffix, e.g. callTracerJs.
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// parseConfig decodes the tracer config into cfg, rejecting unknown options.
+// An absent config leaves cfg at its defaults.
+func parseConfig(raw json.RawMessage, cfg interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("invalid tracer config: %w", err)
+	}
+	return nil
+}
+
+func addrToHex(a common.Address) string {
+	return hexutil.Encode(a[:])
+}
+
+func bytesToHex(b []byte) string {
+	return hexutil.Encode(b)
+}
+
+func uintToHex(n uint64) string {
+	return hexutil.EncodeUint64(n)
+}
+
+// callTypeOpCode returns the opcode that makes a call of the given type.
+func callTypeOpCode(callType vm.CallType) vm.OpCode {
+	switch callType {
+	case vm.CALLCODET:
+		return vm.CALLCODE
+	case vm.DELEGATECALLT:
+		return vm.DELEGATECALL
+	case vm.STATICCALLT:
+		return vm.STATICCALL
+	case vm.CREATET:
+		return vm.CREATE
+	case vm.CREATE2T:
+		return vm.CREATE2
+	default:
+		return vm.CALL
+	}
+}
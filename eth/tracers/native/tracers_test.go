@@ -0,0 +1,374 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/tests"
+)
+
+// callTrace is the result of a callTracer run.
+type callTrace struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []callTrace     `json:"calls,omitempty"`
+	Logs    []callLog       `json:"logs,omitempty"`
+}
+
+type callContext struct {
+	Number     math.HexOrDecimal64   `json:"number"`
+	Difficulty *math.HexOrDecimal256 `json:"difficulty"`
+	Time       math.HexOrDecimal64   `json:"timestamp"`
+	GasLimit   math.HexOrDecimal64   `json:"gasLimit"`
+	Miner      common.Address        `json:"miner"`
+}
+
+// callTracerTest defines a single test to check the call tracer against.
+type callTracerTest struct {
+	Genesis *core.Genesis `json:"genesis"`
+	Context *callContext  `json:"context"`
+	Input   string        `json:"input"`
+	Result  *callTrace    `json:"result"`
+}
+
+// callTracerTests returns the call tracer test cases shared with the JavaScript tracers, by name.
+func callTracerTests(t *testing.T) map[string]*callTracerTest {
+	t.Helper()
+	dir := filepath.Join("..", "testdata")
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	res := make(map[string]*callTracerTest)
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		blob, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, err)
+		test := new(callTracerTest)
+		require.NoError(t, json.Unmarshal(blob, test))
+		res[strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")] = test
+	}
+	return res
+}
+
+// runTracer executes the transaction of the test case with the given tracer and config, and returns its result.
+func runTracer(t *testing.T, test *callTracerTest, name string, cfg json.RawMessage) json.RawMessage {
+	t.Helper()
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(common.FromHex(test.Input)), 0))
+	require.NoError(t, err)
+	signer := types.MakeSigner(test.Genesis.Config, uint64(test.Context.Number))
+	origin, _ := signer.Sender(txn)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(int64(txn.GetPrice().Uint64())),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: uint64(test.Context.Number),
+		Time:        uint64(test.Context.Time),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+	}
+
+	_, tx := memdb.NewTestTx(t)
+	rules := &params.Rules{}
+	statedb, err := tests.MakePreState(rules, tx, test.Genesis.Alloc, uint64(test.Context.Number))
+	require.NoError(t, err)
+
+	tracer, err := tracers.New(name, new(tracers.Context), cfg)
+	require.NoError(t, err)
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := txn.AsMessage(*signer, nil, rules)
+	require.NoError(t, err)
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(txn.GetGas()))
+	_, err = st.TransitionDb(false, false)
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return res
+}
+
+func TestCallTracer(t *testing.T) {
+	for name, test := range callTracerTests(t) {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			have := new(callTrace)
+			require.NoError(t, json.Unmarshal(runTracer(t, test, "callTracer", nil), have))
+			if !jsonEqual(t, have, test.Result) {
+				haveJSON, _ := json.MarshalIndent(have, "", " ")
+				wantJSON, _ := json.MarshalIndent(test.Result, "", " ")
+				t.Fatalf("trace mismatch: \nhave %s\nwant %s", haveJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// jsonEqual is similar to reflect.DeepEqual, but does a 'bounce' via json prior to
+// comparison, so that absent and empty fields are the same
+func jsonEqual(t *testing.T, x, y *callTrace) bool {
+	t.Helper()
+	bounce := func(trace *callTrace) *callTrace {
+		blob, err := json.Marshal(trace)
+		require.NoError(t, err)
+		res := new(callTrace)
+		require.NoError(t, json.Unmarshal(blob, res))
+		return res
+	}
+	return reflect.DeepEqual(bounce(x), bounce(y))
+}
+
+// TestPrestateTracer checks that the accessed accounts are reported with their state from the genesis.
+func TestPrestateTracer(t *testing.T) {
+	for name, test := range callTracerTests(t) {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var prestate map[common.Address]*prestateAccount
+			require.NoError(t, json.Unmarshal(runTracer(t, test, "prestateTracer", nil), &prestate))
+			require.NotEmpty(t, prestate)
+			for addr, have := range prestate {
+				want := test.Genesis.Alloc[addr]
+				balance := want.Balance
+				if balance == nil {
+					balance = new(big.Int)
+				}
+				require.Zero(t, balance.Cmp(have.Balance.ToInt()), "balance of %x: have %s, want %s", addr, have.Balance.ToInt(), balance)
+				require.Equal(t, want.Nonce, have.Nonce, "nonce of %x", addr)
+				require.Equal(t, common.CopyBytes(want.Code), []byte(have.Code), "code of %x", addr)
+				for key, value := range have.Storage {
+					require.Equal(t, want.Storage[key], value, "storage %x of %x", key, addr)
+				}
+			}
+		})
+	}
+}
+
+func TestFourByteTracer(t *testing.T) {
+	test := callTracerTests(t)["deep_calls"]
+	var have, want map[string]int
+	require.NoError(t, json.Unmarshal(runTracer(t, test, "4byteTracer", nil), &have))
+	require.NoError(t, json.Unmarshal(runTracer(t, test, "4byteTracerJs", nil), &want))
+	require.Equal(t, want, have)
+	require.Equal(t, 2, have["0x51a34eb8-32"])
+}
+
+func TestJsFallback(t *testing.T) {
+	tracer, err := tracers.New("callTracerJs", new(tracers.Context), nil)
+	require.NoError(t, err)
+	require.IsType(t, &tracers.JsTracer{}, tracer)
+
+	tracer, err = tracers.New("callTracer", new(tracers.Context), nil)
+	require.NoError(t, err)
+	require.IsType(t, &callTracer{}, tracer)
+}
+
+func TestTracerConfig(t *testing.T) {
+	for _, name := range []string{"callTracer", "prestateTracer", "4byteTracer", "callTracerJs"} {
+		_, err := tracers.New(name, new(tracers.Context), json.RawMessage(`{"unknown": true}`))
+		require.Error(t, err, name)
+		_, err = tracers.New(name, new(tracers.Context), json.RawMessage(`{}`))
+		require.NoError(t, err, name)
+	}
+	_, err := tracers.New("callTracer", new(tracers.Context), json.RawMessage(`{"onlyTopCall": true, "withLog": true}`))
+	require.NoError(t, err)
+	_, err = tracers.New("callTracer", new(tracers.Context), json.RawMessage(`{"onlyTopCall": 1}`))
+	require.Error(t, err)
+	_, err = tracers.New("prestateTracer", new(tracers.Context), json.RawMessage(`{"diffMode": true}`))
+	require.NoError(t, err)
+	_, err = tracers.New("prestateTracer", new(tracers.Context), json.RawMessage(`{"onlyTopCall": true}`))
+	require.Error(t, err)
+}
+
+func TestCallTracerOnlyTopCall(t *testing.T) {
+	for name, test := range callTracerTests(t) {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			have := new(callTrace)
+			require.NoError(t, json.Unmarshal(runTracer(t, test, "callTracer", json.RawMessage(`{"onlyTopCall": true}`)), have))
+			want := *test.Result
+			want.Calls = nil
+			if !jsonEqual(t, have, &want) {
+				haveJSON, _ := json.MarshalIndent(have, "", " ")
+				wantJSON, _ := json.MarshalIndent(want, "", " ")
+				t.Fatalf("trace mismatch: \nhave %s\nwant %s", haveJSON, wantJSON)
+			}
+		})
+	}
+}
+
+var (
+	testSender   = common.HexToAddress("0x00000000000000000000000000000000000000f0")
+	testCoinbase = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	testCaller   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testLogger   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	testReverter = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+)
+
+// testAlloc has a caller that writes its storage, logs, then calls a logger that succeeds
+// and one that logs before reverting.
+func testAlloc() core.GenesisAlloc {
+	call := func(addr common.Address) []byte {
+		code := common.FromHex("0x60006000600060006000")
+		code = append(code, 0x73) // PUSH20
+		code = append(code, addr[:]...)
+		return append(code, common.FromHex("0x5af150")...) // GAS CALL POP
+	}
+	caller := common.FromHex("0x6001600055" + "6000600155" + "60006000a0") // SSTORE(0, 1) SSTORE(1, 0) LOG0
+	caller = append(caller, call(testLogger)...)
+	caller = append(caller, call(testReverter)...)
+	return core.GenesisAlloc{
+		testSender:   {Balance: big.NewInt(params.Ether)},
+		testCaller:   {Balance: new(big.Int), Code: caller, Storage: map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x05")}},
+		testLogger:   {Balance: new(big.Int), Code: common.FromHex("0x602a600052" + "600160206000a100")}, // MSTORE(0, 42) LOG1(0, 32, 1)
+		testReverter: {Balance: new(big.Int), Code: common.FromHex("0x600260006000a1" + "60006000fd")},   // LOG1(0, 0, 2) REVERT(0, 0)
+	}
+}
+
+// runMessage executes a transaction of the test sender against the given accounts with the given tracer and config,
+// and returns its result. A nil to creates a contract with data as init code.
+func runMessage(t *testing.T, alloc core.GenesisAlloc, to *common.Address, data []byte, name string, cfg json.RawMessage) json.RawMessage {
+	t.Helper()
+	_, tx := memdb.NewTestTx(t)
+	rules := params.TestChainConfig.Rules(1)
+	statedb, err := tests.MakePreState(rules, tx, alloc, 0)
+	require.NoError(t, err)
+
+	tracer, err := tracers.New(name, new(tracers.Context), cfg)
+	require.NoError(t, err)
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    testCoinbase,
+		BlockNumber: 1,
+		Difficulty:  big.NewInt(1),
+		GasLimit:    10_000_000,
+	}
+	gasPrice := uint256.NewInt(1)
+	msg := types.NewMessage(testSender, to, 0, new(uint256.Int), 1_000_000, gasPrice, gasPrice, gasPrice, data, nil, true)
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	_, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.Gas()), true, false)
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return res
+}
+
+func TestCallTracerWithLog(t *testing.T) {
+	have := new(callTrace)
+	require.NoError(t, json.Unmarshal(runMessage(t, testAlloc(), &testCaller, nil, "callTracer", json.RawMessage(`{"withLog": true}`)), have))
+	require.Equal(t, []callLog{{Address: testCaller, Topics: []common.Hash{}, Data: hexutil.Bytes{}}}, have.Logs)
+	require.Len(t, have.Calls, 2)
+	require.Equal(t, []callLog{{
+		Address: testLogger,
+		Topics:  []common.Hash{common.HexToHash("0x01")},
+		Data:    common.HexToHash("0x2a").Bytes(),
+	}}, have.Calls[0].Logs)
+	// The log of the reverted call is dropped
+	require.Equal(t, vm.ErrExecutionReverted.Error(), have.Calls[1].Error)
+	require.Empty(t, have.Calls[1].Logs)
+
+	// Logs are not reported by default
+	have = new(callTrace)
+	require.NoError(t, json.Unmarshal(runMessage(t, testAlloc(), &testCaller, nil, "callTracer", nil), have))
+	require.Empty(t, have.Logs)
+	require.Empty(t, have.Calls[0].Logs)
+}
+
+func TestPrestateTracerDiffMode(t *testing.T) {
+	var diff prestateDiff
+	require.NoError(t, json.Unmarshal(runMessage(t, testAlloc(), &testCaller, nil, "prestateTracer", json.RawMessage(`{"diffMode": true}`)), &diff))
+
+	// The callees are read, but not modified
+	require.NotContains(t, diff.Pre, testLogger)
+	require.NotContains(t, diff.Pre, testReverter)
+	require.NotContains(t, diff.Post, testLogger)
+	require.NotContains(t, diff.Post, testReverter)
+
+	// Unchanged slots are dropped, cleared slots are only in the pre state
+	require.Equal(t, map[common.Hash]common.Hash{
+		common.HexToHash("0x00"): {},
+		common.HexToHash("0x01"): common.HexToHash("0x05"),
+	}, diff.Pre[testCaller].Storage)
+	require.Equal(t, &postAccount{
+		Storage: map[common.Hash]common.Hash{common.HexToHash("0x00"): common.HexToHash("0x01")},
+	}, diff.Post[testCaller])
+
+	require.Zero(t, diff.Pre[testSender].Nonce)
+	require.Zero(t, big.NewInt(params.Ether).Cmp(diff.Pre[testSender].Balance.ToInt()))
+	require.Equal(t, uint64(1), diff.Post[testSender].Nonce)
+	require.Equal(t, -1, diff.Post[testSender].Balance.ToInt().Cmp(big.NewInt(params.Ether)))
+
+	// The miner is paid what the sender spent
+	spent := new(big.Int).Sub(big.NewInt(params.Ether), diff.Post[testSender].Balance.ToInt())
+	require.Zero(t, spent.Cmp(diff.Post[testCoinbase].Balance.ToInt()))
+}
+
+func TestPrestateTracerDiffModeCreate(t *testing.T) {
+	var diff prestateDiff
+	initCode := common.FromHex("0x60006000f3") // RETURN(0, 0)
+	require.NoError(t, json.Unmarshal(runMessage(t, testAlloc(), nil, initCode, "prestateTracer", json.RawMessage(`{"diffMode": true}`)), &diff))
+
+	// The created account did not exist before the transaction
+	created := crypto.CreateAddress(testSender, 0)
+	require.NotContains(t, diff.Pre, created)
+	require.Equal(t, &postAccount{Nonce: 1}, diff.Post[created])
+}
+
+// TestMuxTracer checks that each tracer driven by the muxTracer gives the same result as when run alone.
+func TestMuxTracer(t *testing.T) {
+	for name, test := range callTracerTests(t) {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg := json.RawMessage(`{"callTracer": {"withLog": true}, "prestateTracer": {"diffMode": true}, "4byteTracer": {}, "4byteTracerJs": {}}`)
+			var have map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(runTracer(t, test, "muxTracer", cfg), &have))
+			require.Len(t, have, 4)
+			require.JSONEq(t, string(runTracer(t, test, "callTracer", json.RawMessage(`{"withLog": true}`))), string(have["callTracer"]))
+			require.JSONEq(t, string(runTracer(t, test, "prestateTracer", json.RawMessage(`{"diffMode": true}`))), string(have["prestateTracer"]))
+			require.JSONEq(t, string(runTracer(t, test, "4byteTracer", nil)), string(have["4byteTracer"]))
+			require.JSONEq(t, string(have["4byteTracer"]), string(have["4byteTracerJs"]))
+		})
+	}
+}
+
+func TestMuxTracerConfig(t *testing.T) {
+	for _, cfg := range []string{``, `{}`, `[]`, `{"callTracer": {"unknown": true}}`, `{"noSuchTracer": {}}`} {
+		_, err := tracers.New("muxTracer", new(tracers.Context), json.RawMessage(cfg))
+		require.Error(t, err, cfg)
+	}
+}
@@ -15,18 +15,66 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 // Package tracers is a collection of JavaScript transaction tracers.
+// The most used ones also have native Go implementations in the native package,
+// which take precedence when they are registered. The muxTracer runs several
+// tracers over a single execution of the transaction.
 package tracers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"unicode"
 
+	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers/internal/tracers"
 )
 
+// jsSuffix selects the JavaScript version of a built-in tracer that has a native implementation, e.g. callTracerJs.
+const jsSuffix = "Js"
+
+// Tracer interface extends vm.Tracer and additionally
+// allows collecting the tracing result.
+type Tracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	// Stop terminates execution of the tracer at the first opportune moment.
+	Stop(err error)
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
+// native contains the constructors of the native Go tracers by name.
+var native = make(map[string]func(ctx *Context, cfg json.RawMessage) (Tracer, error))
+
+// RegisterNativeTracer makes a native Go tracer available under the given name.
+// It is meant to be called from the init function of the package implementing the tracer.
+// The constructor is responsible for validating the tracer config.
+func RegisterNativeTracer(name string, ctor func(ctx *Context, cfg json.RawMessage) (Tracer, error)) {
+	native[name] = ctor
+}
+
+// New instantiates the native tracer registered under code, or falls back to a
+// JavaScript tracer, either built in by name or given as a code snippet.
+// JavaScript tracers take no config.
+func New(code string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
+	if ctor, ok := native[code]; ok {
+		return ctor(ctx, cfg)
+	}
+	if len(cfg) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(cfg, &fields); err != nil {
+			return nil, fmt.Errorf("invalid tracer config: %w", err)
+		}
+		if len(fields) > 0 {
+			return nil, errors.New("JavaScript tracers take no tracer config")
+		}
+	}
+	return NewJsTracer(code, ctx)
+}
+
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
 	pieces := strings.Split(str, "_")
@@ -44,9 +92,9 @@ func init() {
 	}
 }
 
-// tracer retrieves a specific JavaScript tracer by name.
+// tracer retrieves a specific JavaScript tracer by name, with or without jsSuffix.
 func tracer(name string) (string, bool) {
-	if tracer, ok := all[name]; ok {
+	if tracer, ok := all[strings.TrimSuffix(name, jsSuffix)]; ok {
 		return tracer, true
 	}
 	return "", false
@@ -18,12 +18,20 @@ type AccountResult struct {
 	Nonce        hexutil.Uint64  `json:"nonce"`
 	StorageHash  common.Hash     `json:"storageHash"`
 	StorageProof []StorageResult `json:"storageProof"`
+
+	// Verkle multiproof of all account and storage leaves, set after the Martin fork instead of the merkle proofs
+	VerkleProof   hexutil.Bytes  `json:"verkleProof,omitempty"`
+	VerkleKeyVals []VerkleKeyVal `json:"verkleKeyVals,omitempty"`
 }
 type StorageResult struct {
 	Key   string       `json:"key"`
 	Value *hexutil.Big `json:"value"`
 	Proof []string     `json:"proof"`
 }
+type VerkleKeyVal struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
 
 /*TODO: to support proofs
 func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
@@ -142,6 +142,9 @@ type Config struct {
 	// eth/66, eth/67, etc
 	ProtocolVersion uint
 
+	// VerkleWitness runs the wit capability next to eth, on verkle networks
+	VerkleWitness bool
+
 	SentryAddr []string
 
 	// If set to a non-nil value, the given NAT port mapper
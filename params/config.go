@@ -159,6 +159,30 @@ var (
 		Clique:                &CliqueConfig{Period: 0, Epoch: 30000},
 	}
 
+	// VerkleDevChainConfig is AllCliqueProtocolChanges with the state committed to a verkle tree from genesis
+	VerkleDevChainConfig = &ChainConfig{
+		ChainID:               big.NewInt(1338),
+		Consensus:             CliqueConsensus,
+		HomesteadBlock:        big.NewInt(0),
+		DAOForkBlock:          nil,
+		DAOForkSupport:        false,
+		TangerineWhistleBlock: big.NewInt(0),
+		TangerineWhistleHash:  common.Hash{},
+		SpuriousDragonBlock:   big.NewInt(0),
+		ByzantiumBlock:        big.NewInt(0),
+		ConstantinopleBlock:   big.NewInt(0),
+		PetersburgBlock:       big.NewInt(0),
+		IstanbulBlock:         big.NewInt(0),
+		MuirGlacierBlock:      big.NewInt(0),
+		BerlinBlock:           big.NewInt(0),
+		LondonBlock:           big.NewInt(0),
+		ArrowGlacierBlock:     nil,
+		Ethash:                nil,
+		MartinBlock:           big.NewInt(0),
+		PapiBlock:             big.NewInt(0),
+		Clique:                &CliqueConfig{Period: 0, Epoch: 30000},
+	}
+
 	MumbaiChainConfig = readChainSpec("chainspecs/mumbai.json")
 
 	BorMainnetChainConfig = readChainSpec("chainspecs/bor-mainnet.json")
@@ -273,6 +297,11 @@ type ChainConfig struct {
 	// Verkle
 	PapiBlock   *big.Int `json:"papiBlock,omitempty"`
 	MartinBlock *big.Int `json:"martinBlock,omitempty"`
+	// VerkleProofPrecompileBlock enables the precompile that verifies verkle multiproofs of another chain's state
+	VerkleProofPrecompileBlock *big.Int `json:"verkleProofPrecompileBlock,omitempty"`
+	// VerkleConversionStride is the number of flat state leaves (accounts and storage slots) moved into the verkle tree
+	// by every block from MartinBlock on, until the whole state is converted. 0 means the state is converted at once.
+	VerkleConversionStride uint64 `json:"verkleConversionStride,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -601,6 +630,23 @@ func (c *ChainConfig) IsCancun(num uint64) bool {
 	return isForked(c.CancunBlock, num)
 }
 
+// IsMartin returns whether num is either equal to the Martin fork block or greater.
+// Since Martin the state commitment is a verkle tree.
+func (c *ChainConfig) IsMartin(num uint64) bool {
+	return isForked(c.MartinBlock, num)
+}
+
+// IsVerkleProofPrecompile returns whether num is either equal to the verkle proof precompile block or greater.
+func (c *ChainConfig) IsVerkleProofPrecompile(num uint64) bool {
+	return isForked(c.VerkleProofPrecompileBlock, num)
+}
+
+// IsVerkleConversion returns whether the state is moved into the verkle tree gradually after the Martin fork block.
+// The state root keeps committing to the Merkle trie until the conversion is complete.
+func (c *ChainConfig) IsVerkleConversion() bool {
+	return c.VerkleConversionStride > 0 && c.MartinBlock != nil && c.MartinBlock.Sign() > 0
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -733,6 +779,16 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head uint64) *ConfigC
 		return newCompatError("Cancun fork block", c.CancunBlock, newcfg.CancunBlock)
 	}
 
+	if isForkIncompatible(c.MartinBlock, newcfg.MartinBlock, head) {
+		return newCompatError("Martin fork block", c.MartinBlock, newcfg.MartinBlock)
+	}
+	if c.IsMartin(head) && c.VerkleConversionStride != newcfg.VerkleConversionStride {
+		return newCompatError("Verkle conversion stride", c.MartinBlock, newcfg.MartinBlock)
+	}
+	if isForkIncompatible(c.VerkleProofPrecompileBlock, newcfg.VerkleProofPrecompileBlock, head) {
+		return newCompatError("Verkle proof precompile block", c.VerkleProofPrecompileBlock, newcfg.VerkleProofPrecompileBlock)
+	}
+
 	// Parlia forks
 	if isForkIncompatible(c.RamanujanBlock, newcfg.RamanujanBlock, head) {
 		return newCompatError("Ramanujan fork block", c.RamanujanBlock, newcfg.RamanujanBlock)
@@ -821,6 +877,7 @@ type Rules struct {
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon, IsShanghai, IsCancun                bool
 	IsParlia, IsStarknet                                    bool
+	IsMartin, IsVerkleProofPrecompile                       bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -843,6 +900,9 @@ func (c *ChainConfig) Rules(num uint64) *Rules {
 		IsShanghai:         c.IsShanghai(num),
 		IsCancun:           c.IsCancun(num),
 		IsParlia:           c.Parlia != nil,
+		IsMartin:           c.IsMartin(num),
+
+		IsVerkleProofPrecompile: c.IsVerkleProofPrecompile(num),
 	}
 }
 
@@ -955,6 +1015,8 @@ func NetworkIDByChainName(chain string) uint64 {
 		return 97
 	case networkname.DevChainName:
 		return 1337
+	case networkname.VerkleDevChainName:
+		return VerkleDevChainConfig.ChainID.Uint64()
 	default:
 		config := ChainConfigByChainName(chain)
 		if config == nil {
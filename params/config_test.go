@@ -87,6 +87,45 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored: &ChainConfig{MartinBlock: big.NewInt(10)},
+			new:    &ChainConfig{MartinBlock: big.NewInt(20)},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Martin fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{MartinBlock: big.NewInt(10), VerkleConversionStride: 100},
+			new:     &ChainConfig{MartinBlock: big.NewInt(10), VerkleConversionStride: 200},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{MartinBlock: big.NewInt(10), VerkleConversionStride: 100},
+			new:    &ChainConfig{MartinBlock: big.NewInt(10), VerkleConversionStride: 200},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Verkle conversion stride",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{VerkleProofPrecompileBlock: big.NewInt(10)},
+			new:    &ChainConfig{},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Verkle proof precompile block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    nil,
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -7,6 +7,7 @@ const (
 	RinkebyChainName    = "rinkeby"
 	GoerliChainName     = "goerli"
 	DevChainName        = "dev"
+	VerkleDevChainName  = "verkle-dev"
 	SokolChainName      = "sokol"
 	FermionChainName    = "fermion"
 	BSCChainName        = "bsc"
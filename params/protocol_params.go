@@ -121,6 +121,13 @@ const (
 	// Introduced in Tangerine Whistle (Eip 150)
 	CreateBySelfdestructGas uint64 = 25000
 
+	// Verkle witness costs (EIP-4762), charged on the first access of a branch (stem) or a chunk (leaf) in a transaction
+	WitnessBranchReadCost  uint64 = 1900 // First read of a leaf in a stem
+	WitnessChunkReadCost   uint64 = 200  // First read of a leaf
+	WitnessBranchWriteCost uint64 = 3000 // First write of a leaf in a stem
+	WitnessChunkWriteCost  uint64 = 500  // First write of a leaf
+	WitnessChunkFillCost   uint64 = 6200 // First write of a leaf that was empty
+
 	BaseFeeChangeDenominator = 8          // Bounds the amount the base fee can change between blocks.
 	ElasticityMultiplier     = 2          // Bounds the maximum gas limit an EIP-1559 block may have.
 	InitialBaseFee           = 1000000000 // Initial base fee for EIP-1559 blocks.
@@ -132,6 +139,9 @@ const (
 	TendermintHeaderValidateGas uint64 = 3000 // Gas for validate tendermiint consensus state
 	IAVLMerkleProofValidateGas  uint64 = 3000 // Gas for validate merkle proof
 
+	VerkleProofVerifyBaseGas   uint64 = 50000 // Base price for verifying a verkle multiproof
+	VerkleProofVerifyPerKeyGas uint64 = 3000  // Per-key price for verifying a verkle multiproof
+
 	EcrecoverGas        uint64 = 3000 // Elliptic curve sender recovery gas price
 	Sha256BaseGas       uint64 = 60   // Base price for a SHA256 operation
 	Sha256PerWordGas    uint64 = 12   // Per-word price for a SHA256 operation
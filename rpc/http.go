@@ -219,6 +219,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if origin := r.Header.Get("Origin"); origin != "" {
 		ctx = context.WithValue(ctx, "Origin", origin)
 	}
+	ctx = contextWithClient(ctx, r)
 
 	w.Header().Set("content-type", contentType)
 	codec := newHTTPServerConn(r, w)
@@ -257,7 +258,9 @@ func validateRequest(r *http.Request) (int, error) {
 	return http.StatusUnsupportedMediaType, err
 }
 
-func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) bool {
+// CheckJwtSecret checks the JWT of the request. Returns the request with the subject of the token in its context,
+// which the rate limiter tells the authenticated clients apart by.
+func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) (*http.Request, bool) {
 	var tokenStr string
 	// Check if JWT signature is correct
 	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
@@ -266,7 +269,7 @@ func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) bo
 
 	if len(tokenStr) == 0 {
 		http.Error(w, "missing token", http.StatusForbidden)
-		return false
+		return r, false
 	}
 
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
@@ -294,8 +297,11 @@ func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) bo
 	case time.Until(claims.IssuedAt.Time) > jwtTokenExpiry:
 		http.Error(w, "future token", http.StatusForbidden)
 	default:
-		return true
+		if claims.Subject != "" {
+			r = r.WithContext(context.WithValue(r.Context(), jwtSubjectContextKey{}, claims.Subject))
+		}
+		return r, true
 	}
 
-	return false
+	return r, false
 }
@@ -35,3 +35,11 @@ func newRPCServingTimerMS(method string, valid bool) *metrics.Summary {
 	m := fmt.Sprintf(`rpc_duration_seconds{method="%s",success="%s"}`, method, flag)
 	return metrics.GetOrCreateSummary(m)
 }
+
+func newRPCRateLimitedCounter(method string) *metrics.Counter {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_rate_limited{method="%s"}`, method))
+}
+
+func newRPCCostCounter(method string) *metrics.FloatCounter {
+	return metrics.GetOrCreateFloatCounter(fmt.Sprintf(`rpc_cost_total{method="%s"}`, method))
+}
@@ -0,0 +1,311 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitSweepInterval is how often the buckets of idle clients are dropped.
+	rateLimitSweepInterval = time.Minute
+
+	// apiKeyHeader is the HTTP header with the API key of the client.
+	apiKeyHeader = "X-Api-Key"
+)
+
+// apiKeyContextKey is the context key of the API key of the client of a call.
+type apiKeyContextKey struct{}
+
+// jwtSubjectContextKey is the context key of the subject of the JWT of the client of a call.
+type jwtSubjectContextKey struct{}
+
+// contextWithClient returns the context with the API key and the JWT subject of the request, if any.
+// The subject is put into the context of the request once its token is checked, see CheckJwtSecret.
+func contextWithClient(ctx context.Context, r *http.Request) context.Context {
+	if subject, _ := r.Context().Value(jwtSubjectContextKey{}).(string); subject != "" {
+		ctx = context.WithValue(ctx, jwtSubjectContextKey{}, subject)
+	}
+	if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+		ctx = context.WithValue(ctx, apiKeyContextKey{}, apiKey)
+	}
+	return ctx
+}
+
+// RateBudget is a budget of cost units, refilled at Rate units per second up to
+// Burst units. A zero Burst is the same as Rate.
+type RateBudget struct {
+	Rate  float64 `json:"rate"`
+	Burst float64 `json:"burst"`
+}
+
+// MethodRateLimit is the cost of a method. Methods with a Rate also have a
+// budget of their own for every client, on top of the budget of the client.
+type MethodRateLimit struct {
+	Cost           float64 `json:"cost"`           // DefaultCost if zero
+	BlockRangeCost float64 `json:"blockRangeCost"` // extra cost per block of the fromBlock..toBlock filter
+	RateBudget
+}
+
+// RateLimitConfig configures the rate limiter of a server, e.g.
+//
+//	{
+//	  "rate": 100, "burst": 200,
+//	  "methods": {
+//	    "eth_getLogs": {"cost": 5, "blockRangeCost": 0.01},
+//	    "trace_filter": {"cost": 20, "blockRangeCost": 0.1, "rate": 10, "burst": 100}
+//	  },
+//	  "apiKeys": {"<key>": {"rate": 1000, "burst": 2000}},
+//	  "subjects": {"<subject>": {"rate": 1000, "burst": 2000}}
+//	}
+//
+// Clients are told apart by the subject of their JWT on the authenticated
+// endpoint, by the API key of the X-Api-Key header if it is one of APIKeys, and
+// by their IP address otherwise.
+type RateLimitConfig struct {
+	RateBudget
+	DefaultCost float64                    `json:"defaultCost"` // 1 if zero
+	Methods     map[string]MethodRateLimit `json:"methods"`
+	APIKeys     map[string]RateBudget      `json:"apiKeys"`  // budgets of the API keys
+	Subjects    map[string]RateBudget      `json:"subjects"` // budgets of the JWT subjects, the default budget for the others
+}
+
+// RateLimiter keeps the budgets of the clients of a server and takes the cost of
+// every call out of them, rejecting the calls that go over budget.
+type RateLimiter struct {
+	cfg  RateLimitConfig
+	now  func() time.Time
+	head func() (uint64, error) // number of the head block, nil if not known
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	budget  RateBudget
+	tokens  float64
+	updated time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.budget.Burst, b.tokens+now.Sub(b.updated).Seconds()*b.budget.Rate)
+	b.updated = now
+}
+
+// NewRateLimiter validates the config and creates a rate limiter with it.
+func NewRateLimiter(cfg RateLimitConfig) (*RateLimiter, error) {
+	if err := cfg.RateBudget.normalize(); err != nil {
+		return nil, err
+	}
+	if cfg.DefaultCost < 0 {
+		return nil, fmt.Errorf("negative default cost: %v", cfg.DefaultCost)
+	}
+	if cfg.DefaultCost == 0 {
+		cfg.DefaultCost = 1
+	}
+	methods := make(map[string]MethodRateLimit, len(cfg.Methods))
+	for method, limit := range cfg.Methods {
+		if limit.Cost < 0 || limit.BlockRangeCost < 0 {
+			return nil, fmt.Errorf("%s: negative cost", method)
+		}
+		if limit.Cost == 0 {
+			limit.Cost = cfg.DefaultCost
+		}
+		if limit.Rate != 0 || limit.Burst != 0 {
+			if err := limit.RateBudget.normalize(); err != nil {
+				return nil, fmt.Errorf("%s: %w", method, err)
+			}
+		}
+		methods[method] = limit
+	}
+	cfg.Methods = methods
+	apiKeys := make(map[string]RateBudget, len(cfg.APIKeys))
+	for apiKey, budget := range cfg.APIKeys {
+		if err := budget.normalize(); err != nil {
+			return nil, fmt.Errorf("API key %s: %w", apiKey, err)
+		}
+		apiKeys[apiKey] = budget
+	}
+	cfg.APIKeys = apiKeys
+	subjects := make(map[string]RateBudget, len(cfg.Subjects))
+	for subject, budget := range cfg.Subjects {
+		if err := budget.normalize(); err != nil {
+			return nil, fmt.Errorf("JWT subject %s: %w", subject, err)
+		}
+		subjects[subject] = budget
+	}
+	cfg.Subjects = subjects
+	return &RateLimiter{cfg: cfg, now: time.Now, buckets: make(map[string]*tokenBucket)}, nil
+}
+
+// SetHeadBlock sets the source of the number of the head block, which the block
+// tags and the missing bounds of the ranges of the calls are resolved against.
+// Without it, open ranges take the whole budget of their client.
+func (l *RateLimiter) SetHeadBlock(head func() (uint64, error)) {
+	l.head = head
+}
+
+func (b *RateBudget) normalize() error {
+	if b.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	if b.Burst < 0 {
+		return fmt.Errorf("negative burst: %v", b.Burst)
+	}
+	if b.Burst == 0 {
+		b.Burst = b.Rate
+	}
+	return nil
+}
+
+// client returns the key and the budget of the client of the call.
+func (l *RateLimiter) client(ctx context.Context, remoteAddr string) (string, RateBudget) {
+	// Subjects can be told apart whether they are configured or not, only the holders of the JWT secret sign them
+	if subject, _ := ctx.Value(jwtSubjectContextKey{}).(string); subject != "" {
+		if budget, ok := l.cfg.Subjects[subject]; ok {
+			return "jwt:" + subject, budget
+		}
+		return "jwt:" + subject, l.cfg.RateBudget
+	}
+	// Unknown API keys are ignored, or clients could dodge the limits by making up new ones
+	if key, _ := ctx.Value(apiKeyContextKey{}).(string); key != "" {
+		if budget, ok := l.cfg.APIKeys[key]; ok {
+			return "key:" + key, budget
+		}
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remoteAddr = host
+	}
+	return "ip:" + remoteAddr, l.cfg.RateBudget
+}
+
+// cost returns the cost of a call of the method with the params.
+func (l *RateLimiter) cost(method string, params json.RawMessage) float64 {
+	limit, ok := l.cfg.Methods[method]
+	if !ok {
+		return l.cfg.DefaultCost
+	}
+	cost := limit.Cost
+	if limit.BlockRangeCost > 0 {
+		blocks, ok, err := l.blockRange(method, params)
+		if err != nil {
+			// The range cannot be told, it might be the whole chain
+			return math.Inf(1)
+		}
+		if ok {
+			cost += float64(blocks) * limit.BlockRangeCost
+		}
+	}
+	return cost
+}
+
+// blockRange returns the number of blocks of the filter in the first of the params,
+// as taken by eth_getLogs and trace_filter. Block tags such as "latest" and a
+// missing toBlock are the head block. A missing fromBlock is the head block as
+// well, except for trace_filter, which starts at the genesis then.
+func (l *RateLimiter) blockRange(method string, params json.RawMessage) (uint64, bool, error) {
+	var args [1]struct {
+		FromBlock *BlockNumber    `json:"fromBlock"`
+		ToBlock   *BlockNumber    `json:"toBlock"`
+		BlockHash json.RawMessage `json:"blockHash"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil || args[0].BlockHash != nil {
+		return 0, false, nil
+	}
+	from, to := args[0].FromBlock, args[0].ToBlock
+	if from == nil && method == "trace_filter" {
+		earliest := EarliestBlockNumber
+		from = &earliest
+	}
+	var head uint64
+	if from == nil || *from < 0 || to == nil || *to < 0 {
+		if l.head == nil {
+			return 0, false, errors.New("head block is not known")
+		}
+		var err error
+		if head, err = l.head(); err != nil {
+			return 0, false, err
+		}
+	}
+	resolve := func(n *BlockNumber) uint64 {
+		if n == nil || *n < 0 {
+			return head
+		}
+		return uint64(*n)
+	}
+	first, last := resolve(from), resolve(to)
+	if last < first {
+		return 0, false, nil
+	}
+	return last - first + 1, true, nil
+}
+
+// take takes the cost of the call out of the budgets of its client. The cost is
+// capped at the burst of the budget, so that a call that costs more than its
+// client could ever save up still goes through on a full budget.
+func (l *RateLimiter) take(ctx context.Context, remoteAddr string, method string, params json.RawMessage) error {
+	client, budget := l.client(ctx, remoteAddr)
+	cost := l.cost(method, params)
+	limit := l.cfg.Methods[method]
+	now := l.now()
+
+	l.lock.Lock()
+	l.sweep(now)
+	clientBucket := l.bucket(client, budget, now)
+	clientCost := math.Min(cost, budget.Burst)
+	ok := clientBucket.tokens >= clientCost
+	var methodBucket *tokenBucket
+	var methodCost float64
+	if limit.Rate > 0 {
+		methodBucket = l.bucket(client+"/"+method, limit.RateBudget, now)
+		methodCost = math.Min(cost, limit.Burst)
+		ok = ok && methodBucket.tokens >= methodCost
+	}
+	if ok {
+		clientBucket.tokens -= clientCost
+		if methodBucket != nil {
+			methodBucket.tokens -= methodCost
+		}
+	}
+	l.lock.Unlock()
+
+	if !ok {
+		newRPCRateLimitedCounter(method).Inc()
+		return &rateLimitedError{method: method}
+	}
+	newRPCCostCounter(method).Add(clientCost)
+	return nil
+}
+
+// bucket returns the refilled bucket of the key, a full one for new keys.
+func (l *RateLimiter) bucket(key string, budget RateBudget, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{budget: budget, tokens: budget.Burst, updated: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.refill(now)
+	return b
+}
+
+// sweep drops the buckets that have filled up again, which are no different
+// from new ones, so that the clients that come and go do not pile up.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < rateLimitSweepInterval {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.refill(now); b.tokens >= b.budget.Burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitConfigUnmarshaling(t *testing.T) {
+	var cfg RateLimitConfig
+	err := json.Unmarshal([]byte(`{
+		"rate": 10,
+		"methods": {"trace_filter": {"cost": 20, "blockRangeCost": 0.5, "rate": 1, "burst": 40}},
+		"apiKeys": {"secret": {"rate": 100}},
+		"subjects": {"beacon": {"rate": 1000, "burst": 2000}}
+	}`), &cfg)
+	require.NoError(t, err)
+
+	l, err := NewRateLimiter(cfg)
+	require.NoError(t, err)
+	require.Equal(t, RateBudget{Rate: 10, Burst: 10}, l.cfg.RateBudget)
+	require.Equal(t, float64(1), l.cfg.DefaultCost)
+	require.Equal(t, MethodRateLimit{Cost: 20, BlockRangeCost: 0.5, RateBudget: RateBudget{Rate: 1, Burst: 40}}, l.cfg.Methods["trace_filter"])
+	require.Equal(t, RateBudget{Rate: 100, Burst: 100}, l.cfg.APIKeys["secret"])
+	require.Equal(t, RateBudget{Rate: 1000, Burst: 2000}, l.cfg.Subjects["beacon"])
+
+	_, err = NewRateLimiter(RateLimitConfig{})
+	require.Error(t, err)
+	_, err = NewRateLimiter(RateLimitConfig{RateBudget: RateBudget{Rate: 1}, Methods: map[string]MethodRateLimit{"eth_getLogs": {Cost: -1}}})
+	require.Error(t, err)
+}
+
+func TestRateLimitCost(t *testing.T) {
+	l, err := NewRateLimiter(RateLimitConfig{
+		RateBudget:  RateBudget{Rate: 1},
+		DefaultCost: 2,
+		Methods: map[string]MethodRateLimit{
+			"eth_getLogs":   {Cost: 5, BlockRangeCost: 0.1},
+			"trace_filter":  {Cost: 5, BlockRangeCost: 0.1},
+			"eth_getProof":  {BlockRangeCost: 1},
+			"eth_getBlocks": {Cost: 3},
+		},
+	})
+	require.NoError(t, err)
+	l.SetHeadBlock(func() (uint64, error) { return 199, nil })
+
+	for _, test := range []struct {
+		method string
+		params string
+		cost   float64
+	}{
+		{"eth_blockNumber", `[]`, 2},
+		{"eth_getBlocks", `[{"fromBlock": "0x0", "toBlock": "0x63"}]`, 3},
+		{"eth_getLogs", `[{"fromBlock": "0x0", "toBlock": "0x63"}]`, 15},
+		{"eth_getLogs", `[{"fromBlock": "earliest", "toBlock": "0x9"}]`, 6},
+		{"eth_getLogs", `[{"fromBlock": "0x0", "toBlock": "latest"}]`, 25},
+		{"eth_getLogs", `[{"fromBlock": "0x0", "toBlock": "pending"}]`, 25},
+		{"eth_getLogs", `[{"fromBlock": "0x64"}]`, 15},
+		{"eth_getLogs", `[{"fromBlock": "latest", "toBlock": "latest"}]`, 5.1},
+		{"eth_getLogs", `[{}]`, 5.1},
+		{"eth_getLogs", `[{"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000"}]`, 5},
+		{"eth_getLogs", `[{"fromBlock": "0x10", "toBlock": "0x1"}]`, 5},
+		{"eth_getLogs", `{"fromBlock": "0x0"}`, 5},
+		{"trace_filter", `[{"toBlock": "0x63"}]`, 15},
+		{"trace_filter", `[{}]`, 25},
+		{"eth_getProof", `[{"fromBlock": "0x1", "toBlock": "0x1"}]`, 3},
+	} {
+		require.InDelta(t, test.cost, l.cost(test.method, json.RawMessage(test.params)), 1e-9, "%s %s", test.method, test.params)
+	}
+
+	// Open ranges take the whole budget when the head block is not known
+	l.SetHeadBlock(func() (uint64, error) { return 0, errors.New("no head") })
+	require.True(t, math.IsInf(l.cost("eth_getLogs", json.RawMessage(`[{"fromBlock": "0x0", "toBlock": "latest"}]`)), 1))
+	require.InDelta(t, 15, l.cost("eth_getLogs", json.RawMessage(`[{"fromBlock": "0x0", "toBlock": "0x63"}]`)), 1e-9)
+	l.SetHeadBlock(nil)
+	require.True(t, math.IsInf(l.cost("trace_filter", json.RawMessage(`[{}]`)), 1))
+}
+
+func TestRateLimitTake(t *testing.T) {
+	l, err := NewRateLimiter(RateLimitConfig{
+		RateBudget: RateBudget{Rate: 1, Burst: 10},
+		Methods: map[string]MethodRateLimit{
+			"trace_filter": {Cost: 4, RateBudget: RateBudget{Rate: 1, Burst: 4}},
+			"eth_getLogs":  {Cost: 100},
+		},
+		APIKeys:  map[string]RateBudget{"secret": {Rate: 1, Burst: 100}},
+		Subjects: map[string]RateBudget{"beacon": {Rate: 1, Burst: 100}},
+	})
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// trace_filter runs out of its own budget before the client runs out of its
+	require.NoError(t, l.take(ctx, "1.2.3.4:1000", "trace_filter", nil))
+	err = l.take(ctx, "1.2.3.4:1001", "trace_filter", nil)
+	require.Error(t, err)
+	require.Equal(t, -32005, err.(Error).ErrorCode())
+	for i := 0; i < 6; i++ {
+		require.NoError(t, l.take(ctx, "1.2.3.4:1002", "eth_blockNumber", nil))
+	}
+	require.Error(t, l.take(ctx, "1.2.3.4:1003", "eth_blockNumber", nil))
+
+	// Other clients have budgets of their own
+	require.NoError(t, l.take(ctx, "5.6.7.8:1000", "trace_filter", nil))
+	require.NoError(t, l.take(ctx, "[::1]:1000", "eth_blockNumber", nil))
+
+	// The budgets refill over time
+	now = now.Add(4 * time.Second)
+	require.NoError(t, l.take(ctx, "1.2.3.4:1000", "trace_filter", nil))
+	require.Error(t, l.take(ctx, "1.2.3.4:1000", "eth_blockNumber", nil))
+
+	// Calls that cost more than the burst take all of a full budget
+	now = now.Add(10 * time.Second)
+	require.NoError(t, l.take(ctx, "1.2.3.4:1000", "eth_getLogs", nil))
+	require.Error(t, l.take(ctx, "1.2.3.4:1000", "eth_blockNumber", nil))
+
+	// Known API keys have their own budgets, unknown ones are ignored
+	keyCtx := context.WithValue(ctx, apiKeyContextKey{}, "secret")
+	require.NoError(t, l.take(keyCtx, "1.2.3.4:1000", "eth_getLogs", nil))
+	require.Error(t, l.take(keyCtx, "1.2.3.4:1000", "eth_getLogs", nil))
+	require.Error(t, l.take(context.WithValue(ctx, apiKeyContextKey{}, "made up"), "1.2.3.4:1000", "eth_blockNumber", nil))
+
+	// JWT subjects have budgets of their own, the default one if they are not configured
+	require.NoError(t, l.take(context.WithValue(ctx, jwtSubjectContextKey{}, "beacon"), "1.2.3.4:1000", "eth_getLogs", nil))
+	require.Error(t, l.take(context.WithValue(ctx, jwtSubjectContextKey{}, "beacon"), "1.2.3.4:1000", "eth_blockNumber", nil))
+	require.NoError(t, l.take(context.WithValue(ctx, jwtSubjectContextKey{}, "other"), "1.2.3.4:1000", "eth_blockNumber", nil))
+
+	// Full buckets are swept
+	now = now.Add(time.Hour)
+	require.NoError(t, l.take(ctx, "9.9.9.9:1000", "eth_blockNumber", nil))
+	require.Len(t, l.buckets, 1)
+}
+
+func rateLimitedTestServer(t *testing.T) *Server {
+	server := newTestServer()
+	l, err := NewRateLimiter(RateLimitConfig{
+		RateBudget: RateBudget{Rate: 0.001, Burst: 3},
+		Methods:    map[string]MethodRateLimit{"test_echo": {Cost: 2}},
+		APIKeys:    map[string]RateBudget{"secret": {Rate: 0.001, Burst: 4}},
+	})
+	require.NoError(t, err)
+	server.SetRateLimiter(l)
+	return server
+}
+
+func TestRateLimitHTTP(t *testing.T) {
+	server := rateLimitedTestServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var res echoResult
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+	require.Equal(t, echoResult{"hello", 10, &echoArgs{"world"}}, res)
+	err = client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"})
+	require.Error(t, err)
+	require.Equal(t, -32005, err.(Error).ErrorCode())
+	require.NoError(t, client.Call(nil, "test_noArgsRets"))
+}
+
+func TestRateLimitWebsocketAPIKey(t *testing.T) {
+	server := rateLimitedTestServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}, nil, false))
+	defer ts.Close()
+	wsURL := "ws:" + strings.TrimPrefix(ts.URL, "http:")
+	dial := func(header http.Header) *Client {
+		client, err := newClient(context.Background(), func(ctx context.Context) (ServerCodec, error) {
+			conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header) //nolint:bodyclose
+			if err != nil {
+				return nil, err
+			}
+			return newWebsocketCodec(conn), nil
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	// The API key of the websocket handshake applies to every call over the connection
+	client := dial(http.Header{"X-Api-Key": {"secret"}})
+	defer client.Close()
+	var res echoResult
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+	err := client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"})
+	require.Error(t, err)
+	require.Equal(t, -32005, err.(Error).ErrorCode())
+
+	// Without it, the client has the budget of its IP address
+	client = dial(nil)
+	defer client.Close()
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+	require.Error(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+}
+
+func TestRateLimitJWTSubject(t *testing.T) {
+	server := rateLimitedTestServer(t)
+	defer server.Stop()
+	secret := make([]byte, 32)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r, ok := CheckJwtSecret(w, r, secret); ok {
+			server.ServeHTTP(w, r)
+		}
+	}))
+	defer ts.Close()
+	dial := func(subject string) *Client {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Subject:  subject,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		client, err := DialHTTP(ts.URL)
+		require.NoError(t, err)
+		client.SetHeader("Authorization", "Bearer "+token)
+		return client
+	}
+
+	// Clients from the same address are told apart by the subjects of their tokens
+	var res echoResult
+	for _, subject := range []string{"beacon", "builder"} {
+		client := dial(subject)
+		defer client.Close()
+		require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+		err := client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"})
+		require.Error(t, err)
+		require.Equal(t, -32005, err.(Error).ErrorCode())
+	}
+
+	// Tokens without a subject fall back to the address
+	client := dial("")
+	defer client.Close()
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+	require.Error(t, client.Call(&res, "test_echo", "hello", 10, &echoArgs{"world"}))
+}
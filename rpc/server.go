@@ -45,6 +45,7 @@ const (
 type Server struct {
 	services        serviceRegistry
 	methodAllowList AllowList
+	rateLimiter     *RateLimiter
 	idgen           func() ID
 	run             int32
 	codecs          mapset.Set
@@ -69,6 +70,12 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetRateLimiter sets the rate limiter of the calls handled by this server, nil
+// for no rate limiting
+func (s *Server) SetRateLimiter(rateLimiter *RateLimiter) {
+	s.rateLimiter = rateLimiter
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -83,6 +90,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec)
+}
+
+// serveCodec serves the codec with the calls in contexts derived from ctx.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -94,7 +106,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(ctx, codec, s.idgen, &s.services, s.rateLimiter)
 	<-codec.closed()
 	c.Close()
 }
@@ -108,7 +120,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests)
+	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.rateLimiter, s.batchConcurrency, s.traceRequests)
 	h.allowSubscribe = false
 	defer h.close(io.EOF, nil)
 
@@ -55,8 +55,11 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 		CheckOrigin:       wsHandshakeValidator(allowedOrigins),
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if jwtSecret != nil && !CheckJwtSecret(w, r, jwtSecret) {
-			return
+		if jwtSecret != nil {
+			var ok bool
+			if r, ok = CheckJwtSecret(w, r, jwtSecret); !ok {
+				return
+			}
 		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
@@ -64,7 +67,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 			return
 		}
 		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
+		s.serveCodec(contextWithClient(context.Background(), r), codec)
 	})
 }
 
@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -169,20 +170,38 @@ func (t *StateTest) Run(rules *params.Rules, tx kv.RwTx, subtest StateSubtest, v
 	if err != nil {
 		return state, err
 	}
+	return state, t.verify(state, root, subtest)
+}
+
+// RunVerkle executes a specific subtest like Run, but the pre-state is built as a verkle tree as well,
+// and the verkle root of the post-state is verified and returned instead of the Merkle one
+func (t *StateTest) RunVerkle(rules *params.Rules, tx kv.RwTx, subtest StateSubtest, vmconfig vm.Config) (*state.IntraBlockState, common.Hash, error) {
+	state, root, err := t.runNoVerify(rules, tx, subtest, vmconfig, true)
+	if err != nil {
+		return state, root, err
+	}
+	return state, root, t.verify(state, root, subtest)
+}
+
+func (t *StateTest) verify(statedb *state.IntraBlockState, root common.Hash, subtest StateSubtest) error {
 	post := t.json.Post[subtest.Fork][subtest.Index]
 	// N.B: We need to do this in a two-step process, because the first Commit takes care
 	// of suicides, and we need to touch the coinbase _after_ it has potentially suicided.
 	if root != common.Hash(post.Root) {
-		return state, fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
+		return fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
 	}
-	if logs := rlpHash(state.Logs()); logs != common.Hash(post.Logs) {
-		return state, fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
+	if logs := rlpHash(statedb.Logs()); logs != common.Hash(post.Logs) {
+		return fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
 	}
-	return state, nil
+	return nil
 }
 
 // RunNoVerify runs a specific subtest and returns the statedb and post-state root
 func (t *StateTest) RunNoVerify(rules *params.Rules, tx kv.RwTx, subtest StateSubtest, vmconfig vm.Config) (*state.IntraBlockState, common.Hash, error) {
+	return t.runNoVerify(rules, tx, subtest, vmconfig, false)
+}
+
+func (t *StateTest) runNoVerify(rules *params.Rules, tx kv.RwTx, subtest StateSubtest, vmconfig vm.Config, verkleState bool) (*state.IntraBlockState, common.Hash, error) {
 	config, eips, err := GetChainConfig(subtest.Fork)
 	if err != nil {
 		return nil, common.Hash{}, UnsupportedForkError{subtest.Fork}
@@ -201,7 +220,16 @@ func (t *StateTest) RunNoVerify(rules *params.Rules, tx kv.RwTx, subtest StateSu
 		return nil, common.Hash{}, UnsupportedForkError{subtest.Fork}
 	}
 	statedb := state.New(state.NewPlainStateReader(tx))
-	w := state.NewPlainStateWriter(tx, nil, writeBlockNr)
+	var w state.StateWriter = state.NewPlainStateWriter(tx, nil, writeBlockNr)
+	var verkleWriter *state.VerkleStateWriter
+	if verkleState {
+		verkleRoot := verkle.New()
+		if err = core.WriteVerkleAlloc(verkleRoot, nil, nil, t.json.Pre); err != nil {
+			return nil, common.Hash{}, err
+		}
+		verkleWriter = state.NewVerkleStateWriter(verkleRoot, nil)
+		w = state.NewVerkleTeeWriter(state.NewPlainStateWriter(tx, nil, writeBlockNr), verkleWriter)
+	}
 
 	var baseFee *big.Int
 	if config.IsLondon(0) {
@@ -257,6 +285,9 @@ func (t *StateTest) RunNoVerify(rules *params.Rules, tx kv.RwTx, subtest StateSu
 	if err = statedb.CommitBlock(evm.ChainRules(), w); err != nil {
 		return nil, common.Hash{}, err
 	}
+	if verkleWriter != nil {
+		return statedb, verkleWriter.Root(), nil
+	}
 	// Generate hashed state
 	c, err := tx.RwCursor(kv.PlainState)
 	if err != nil {
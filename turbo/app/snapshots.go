@@ -23,11 +23,13 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/internal/debug"
 	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli"
 )
@@ -73,6 +75,16 @@ var snapshotCommand = cli.Command{
 				SnapshotEveryFlag,
 			}, debug.Flags...),
 		},
+		{
+			Name:   "verkle",
+			Action: doVerkleSnapshot,
+			Usage:  "Create a snapshot of the verkle tree at given block",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: append([]cli.Flag{
+				utils.DataDirFlag,
+				SnapshotBlockFlag,
+			}, debug.Flags...),
+		},
 		{
 			Name:   "uncompress",
 			Action: doUncompress,
@@ -110,6 +122,11 @@ var (
 		Usage: "Amount of blocks in each segment",
 		Value: snap.DEFAULT_SEGMENT_SIZE,
 	}
+	SnapshotBlockFlag = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Block number, a multiple of 1000. Zero - means the latest one the verkle tree has reached.",
+		Value: 0,
+	}
 	SnapshotRebuildFlag = cli.BoolFlag{
 		Name:  "rebuild",
 		Usage: "Force rebuild",
@@ -124,7 +141,7 @@ func doIndicesCommand(cliCtx *cli.Context) error {
 	rebuild := cliCtx.Bool(SnapshotRebuildFlag.Name)
 	from := cliCtx.Uint64(SnapshotFromFlag.Name)
 
-	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).Readonly().MustOpen()
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Readonly().MustOpen()
 	defer chainDB.Close()
 
 	if rebuild {
@@ -233,7 +250,7 @@ func doRetireCommand(cliCtx *cli.Context) error {
 	to := cliCtx.Uint64(SnapshotToFlag.Name)
 	every := cliCtx.Uint64(SnapshotEveryFlag.Name)
 
-	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).MustOpen()
+	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).MustOpen()
 	defer db.Close()
 
 	cfg := ethconfig.NewSnapCfg(true, true, true)
@@ -281,7 +298,7 @@ func doSnapshotCommand(cliCtx *cli.Context) error {
 	dir.MustExist(filepath.Join(dirs.Snap, "db")) // this folder will be checked on existance - to understand that snapshots are ready
 	dir.MustExist(dirs.Tmp)
 
-	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).MustOpen()
+	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).MustOpen()
 	defer db.Close()
 
 	if err := snapshotBlocks(ctx, db, fromBlock, toBlock, segmentSize, dirs.Snap, dirs.Tmp); err != nil {
@@ -300,6 +317,37 @@ func doSnapshotCommand(cliCtx *cli.Context) error {
 	return nil
 }
 
+func doVerkleSnapshot(cliCtx *cli.Context) error {
+	ctx, cancel := common.RootContext()
+	defer cancel()
+
+	blockNum := cliCtx.Uint64(SnapshotBlockFlag.Name)
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	dir.MustExist(dirs.Snap)
+	dir.MustExist(dirs.Tmp)
+
+	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).WithTableCfg(verkledb.ChaindataTablesCfg).Readonly().MustOpen()
+	defer db.Close()
+
+	if blockNum == 0 {
+		if err := db.View(ctx, func(tx kv.Tx) error {
+			progress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+			blockNum = progress - progress%snap.MIN_SEGMENT_SIZE
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	workers := cmp.Max(1, runtime.GOMAXPROCS(-1)-1)
+	segmentFilePath, err := snapshotsync.DumpVerkleTree(ctx, db, blockNum, dirs.Snap, dirs.Tmp, workers, log.LvlInfo)
+	if err != nil {
+		return err
+	}
+	log.Info("Verkle snapshot created", "file", segmentFilePath, "block", blockNum)
+	return nil
+}
+
 func rebuildIndices(logPrefix string, ctx context.Context, db kv.RoDB, cfg ethconfig.Snapshot, dirs datadir.Dirs, from uint64, workers int) error {
 	chainConfig := fromdb.ChainConfig(db)
 	chainID, _ := uint256.FromBig(chainConfig.ChainID)
@@ -57,6 +57,7 @@ var DefaultFlags = []cli.Flag{
 	utils.HTTPCORSDomainFlag,
 	utils.HTTPVirtualHostsFlag,
 	utils.AuthRpcVirtualHostsFlag,
+	utils.AuthRpcRateLimitFlag,
 	utils.HTTPApiFlag,
 	utils.WSEnabledFlag,
 	utils.WsCompressionFlag,
@@ -351,14 +351,15 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 			IdleTimeout:  ctx.GlobalDuration(HTTPIdleTimeoutFlag.Name),
 		},
 
-		WebsocketEnabled:     ctx.GlobalIsSet(utils.WSEnabledFlag.Name),
-		RpcBatchConcurrency:  ctx.GlobalUint(utils.RpcBatchConcurrencyFlag.Name),
-		RpcStreamingDisable:  ctx.GlobalBool(utils.RpcStreamingDisableFlag.Name),
-		DBReadConcurrency:    ctx.GlobalInt(utils.DBReadConcurrencyFlag.Name),
-		RpcAllowListFilePath: ctx.GlobalString(utils.RpcAccessListFlag.Name),
-		Gascap:               ctx.GlobalUint64(utils.RpcGasCapFlag.Name),
-		MaxTraces:            ctx.GlobalUint64(utils.TraceMaxtracesFlag.Name),
-		TraceCompatibility:   ctx.GlobalBool(utils.RpcTraceCompatFlag.Name),
+		WebsocketEnabled:         ctx.GlobalIsSet(utils.WSEnabledFlag.Name),
+		RpcBatchConcurrency:      ctx.GlobalUint(utils.RpcBatchConcurrencyFlag.Name),
+		RpcStreamingDisable:      ctx.GlobalBool(utils.RpcStreamingDisableFlag.Name),
+		DBReadConcurrency:        ctx.GlobalInt(utils.DBReadConcurrencyFlag.Name),
+		RpcAllowListFilePath:     ctx.GlobalString(utils.RpcAccessListFlag.Name),
+		AuthRpcRateLimitFilePath: ctx.GlobalString(utils.AuthRpcRateLimitFlag.Name),
+		Gascap:                   ctx.GlobalUint64(utils.RpcGasCapFlag.Name),
+		MaxTraces:                ctx.GlobalUint64(utils.TraceMaxtracesFlag.Name),
+		TraceCompatibility:       ctx.GlobalBool(utils.RpcTraceCompatFlag.Name),
 
 		TxPoolApiAddr: ctx.GlobalString(utils.TxpoolApiAddrFlag.Name),
 
@@ -48,6 +48,7 @@ type DownloadRequest struct {
 	ranges      *Range
 	path        string
 	torrentHash string
+	verkle      bool // the verkle state snapshot at ranges.from, not the block segments of ranges
 }
 
 type HeaderSegment struct {
@@ -571,6 +572,9 @@ Loop:
 			if err := sn.reopenIdxIfNeed(s.dir, optimistic); err != nil {
 				return err
 			}
+		case snap.Verkle:
+			// state snapshots are opened by the VerkleTrie stage, they don't cover blocks
+			continue Loop
 		}
 
 		if f.To > 0 {
@@ -2065,6 +2069,15 @@ func NewDownloadRequest(ranges *Range, path string, torrentHash string) Download
 	}
 }
 
+// NewVerkleDownloadRequest - requests the verkle state snapshot at blockNum, without torrentHash the local file is seeded
+func NewVerkleDownloadRequest(blockNum uint64, torrentHash string) DownloadRequest {
+	return DownloadRequest{
+		ranges:      &Range{from: blockNum, to: blockNum},
+		torrentHash: torrentHash,
+		verkle:      true,
+	}
+}
+
 // RequestSnapshotsDownload - builds the snapshots download request and downloads them
 func RequestSnapshotsDownload(ctx context.Context, downloadRequest []DownloadRequest, downloader proto_downloader.DownloaderClient) error {
 	// start seed large .seg of large size
@@ -2078,7 +2091,13 @@ func RequestSnapshotsDownload(ctx context.Context, downloadRequest []DownloadReq
 func BuildProtoRequest(downloadRequest []DownloadRequest) *proto_downloader.DownloadRequest {
 	req := &proto_downloader.DownloadRequest{Items: make([]*proto_downloader.DownloadItem, 0, len(snap.AllSnapshotTypes))}
 	for _, r := range downloadRequest {
-		if r.path != "" {
+		if r.verkle {
+			item := &proto_downloader.DownloadItem{Path: snap.VerkleFileName(r.ranges.from)}
+			if r.torrentHash != "" {
+				item.TorrentHash = downloadergrpc.String2Proto(r.torrentHash)
+			}
+			req.Items = append(req.Items, item)
+		} else if r.path != "" {
 			if r.torrentHash != "" {
 				req.Items = append(req.Items, &proto_downloader.DownloadItem{
 					TorrentHash: downloadergrpc.String2Proto(r.torrentHash),
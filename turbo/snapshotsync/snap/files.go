@@ -20,6 +20,8 @@ const (
 	Headers Type = iota
 	Bodies
 	Transactions
+	// Verkle is the state of the verkle tree at a single block, it is not part of the block segments
+	Verkle
 	NumberOfTypes
 )
 
@@ -31,6 +33,8 @@ func (ft Type) String() string {
 		return "bodies"
 	case Transactions:
 		return "transactions"
+	case Verkle:
+		return "verkle"
 	default:
 		panic(fmt.Sprintf("unknown file type: %d", ft))
 	}
@@ -44,6 +48,8 @@ func ParseFileType(s string) (Type, bool) {
 		return Bodies, true
 	case "transactions":
 		return Transactions, true
+	case "verkle":
+		return Verkle, true
 	default:
 		return NumberOfTypes, false
 	}
@@ -70,6 +76,9 @@ func SegmentFileName(from, to uint64, t Type) string   { return FileName(from, t
 func DatFileName(from, to uint64, fType string) string { return FileName(from, to, fType) + ".dat" }
 func IdxFileName(from, to uint64, fType string) string { return FileName(from, to, fType) + ".idx" }
 
+// VerkleFileName - the verkle state of a block is named after an empty range at that block, which must be a multiple of MIN_SEGMENT_SIZE
+func VerkleFileName(blockNum uint64) string { return SegmentFileName(blockNum, blockNum, Verkle) }
+
 func FilterExt(in []FileInfo, expectExt string) (out []FileInfo) {
 	for _, f := range in {
 		if f.Ext != expectExt { // filter out only compressed files
@@ -122,6 +131,8 @@ func ParseFileName(dir, fileName string) (res FileInfo, err error) {
 		snapshotType = Bodies
 	case Transactions:
 		snapshotType = Transactions
+	case Verkle:
+		snapshotType = Verkle
 	default:
 		return res, fmt.Errorf("unexpected snapshot suffix: %s,%w", parts[2], ErrInvalidFileName)
 	}
@@ -142,11 +153,25 @@ type FileInfo struct {
 }
 
 func (f FileInfo) TorrentFileExists() bool { return common.FileExist(f.Path + ".torrent") }
-func (f FileInfo) Seedable() bool          { return f.To-f.From == DEFAULT_SEGMENT_SIZE }
+func (f FileInfo) Seedable() bool          { return f.To-f.From == DEFAULT_SEGMENT_SIZE || f.T == Verkle }
 func (f FileInfo) NeedTorrentFile() bool   { return f.Seedable() && !f.TorrentFileExists() }
 
 func IdxFiles(dir string) (res []FileInfo, err error) { return FilesWithExt(dir, ".idx") }
 func Segments(dir string) (res []FileInfo, err error) { return FilesWithExt(dir, ".seg") }
+
+// VerkleSegments - verkle state snapshots of the dir, ordered by block
+func VerkleSegments(dir string) (res []FileInfo, err error) {
+	list, err := Segments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range list {
+		if f.T == Verkle {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
 func TmpFiles(dir string) (res []string, err error) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
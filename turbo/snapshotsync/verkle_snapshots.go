@@ -0,0 +1,165 @@
+package snapshotsync
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snapcfg"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Verkle snapshot format: first word is block_num_8bytes + root_32bytes, then the encoded leaf nodes ordered by stem.
+// The tree is rebuilt out of the leaf nodes alone, so the root of the file can be checked against the header.
+const verkleSnapshotHeaderLen = 8 + 32
+
+// DumpVerkleTree - writes the tree committed at blockNum into snapDir, returns the path of the snapshot
+func DumpVerkleTree(ctx context.Context, db kv.RoDB, blockNum uint64, snapDir, tmpDir string, workers int, lvl log.Lvl) (string, error) {
+	if blockNum%snap.MIN_SEGMENT_SIZE != 0 {
+		return "", fmt.Errorf("verkle snapshots are taken every %d blocks, got %d", snap.MIN_SEGMENT_SIZE, blockNum)
+	}
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	segmentFilePath := filepath.Join(snapDir, snap.VerkleFileName(blockNum))
+	f, err := compress.NewCompressor(ctx, "Snapshot Verkle", segmentFilePath, tmpDir, compress.MinPatternScore, workers, lvl)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		root, err := verkledb.ReadVerkleRoot(tx, blockNum)
+		if err != nil {
+			return err
+		}
+		if root == (common.Hash{}) {
+			return fmt.Errorf("no verkle root for block %d", blockNum)
+		}
+		header := make([]byte, verkleSnapshotHeaderLen)
+		binary.BigEndian.PutUint64(header, blockNum)
+		copy(header[8:], root[:])
+		if err := f.AddWord(header); err != nil {
+			return err
+		}
+
+		var leaves uint64
+		return verkledb.WalkLeafNodes(tx, root, func(encoded []byte) error {
+			leaves++
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-logEvery.C:
+				log.Log(lvl, "[snapshots] Dumping verkle tree", "block num", blockNum, "leaves", leaves)
+			default:
+			}
+			return f.AddWord(encoded)
+		})
+	}); err != nil {
+		return "", err
+	}
+	if err := f.Compress(); err != nil {
+		return "", fmt.Errorf("compress: %w", err)
+	}
+	return segmentFilePath, nil
+}
+
+// ReadVerkleSnapshotHeader - block and root of the tree in the snapshot, the leaves are not checked
+func ReadVerkleSnapshotHeader(segmentFilePath string) (blockNum uint64, root common.Hash, err error) {
+	d, err := compress.NewDecompressor(segmentFilePath)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	defer d.Close()
+	return readVerkleSnapshotHeader(d.MakeGetter())
+}
+
+func readVerkleSnapshotHeader(g *compress.Getter) (blockNum uint64, root common.Hash, err error) {
+	if !g.HasNext() {
+		return 0, common.Hash{}, fmt.Errorf("verkle snapshot is empty")
+	}
+	header, _ := g.Next(nil)
+	if len(header) != verkleSnapshotHeaderLen {
+		return 0, common.Hash{}, fmt.Errorf("invalid verkle snapshot header length: %d", len(header))
+	}
+	return binary.BigEndian.Uint64(header), common.BytesToHash(header[8:]), nil
+}
+
+// LoadVerkleSnapshot - rebuilds the tree of the snapshot in tx, fails if its root differs from the one of the snapshot.
+// Nothing else is written: the caller decides whether the root is trusted and records it.
+func LoadVerkleSnapshot(ctx context.Context, tx kv.RwTx, segmentFilePath, tmpDir string) (blockNum uint64, root common.Hash, err error) {
+	d, err := compress.NewDecompressor(segmentFilePath)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	defer d.Close()
+
+	g := d.MakeGetter()
+	blockNum, root, err = readVerkleSnapshotHeader(g)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	loader := verkledb.NewLeafNodeLoader(tmpDir)
+	defer loader.Close()
+	var word []byte
+	for g.HasNext() {
+		word, _ = g.Next(word[:0])
+		if err := loader.Add(word); err != nil {
+			return 0, common.Hash{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, common.Hash{}, ctx.Err()
+		default:
+		}
+	}
+	loadedRoot, err := loader.Commit(tx)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	if loadedRoot != root {
+		return 0, common.Hash{}, fmt.Errorf("verkle snapshot %s: leaves give root %x, want %x", segmentFilePath, loadedRoot, root)
+	}
+	return blockNum, root, nil
+}
+
+// VerkleDownloadRequests - requests to seed the verkle state snapshots of snapDir which are not preverified,
+// the preverified ones are requested by their hash
+func VerkleDownloadRequests(snapDir string, preverified snapcfg.Preverified) ([]DownloadRequest, error) {
+	files, err := snap.VerkleSegments(snapDir)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]struct{}, len(preverified))
+	for _, p := range preverified {
+		known[p.Name] = struct{}{}
+	}
+	var res []DownloadRequest
+	for _, f := range files {
+		if _, ok := known[snap.VerkleFileName(f.From)]; ok {
+			continue
+		}
+		res = append(res, NewVerkleDownloadRequest(f.From, ""))
+	}
+	return res, nil
+}
+
+// VerkleFiles - names of the verkle state snapshots of snapDir, RoSnapshots.Files lists the block segments only
+func VerkleFiles(snapDir string) (list []string, err error) {
+	files, err := snap.VerkleSegments(snapDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		_, fName := filepath.Split(f.Path)
+		list = append(list, fName)
+	}
+	return list, nil
+}
@@ -0,0 +1,103 @@
+package snapshotsync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/cmd/downloader/downloadergrpc"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snapcfg"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerkleSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	db := memdb.NewTestDB(t)
+	var root common.Hash
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		require.NoError(t, verkledb.InitDB(tx))
+		tree, err := verkledb.NewVerkleTree(tx, common.Hash{})
+		require.NoError(t, err)
+		for i := uint64(0); i < 300; i++ {
+			value := make([]byte, 32)
+			vtree.Int256ToVerkleFormat(uint256.NewInt(i+1), value)
+			address := common.BytesToAddress([]byte{byte(i % 7)})
+			require.NoError(t, tree.Insert(vtree.GetTreeKeyStorageSlot(address[:], uint256.NewInt(i*100)), value))
+		}
+		// Deleted leaves are kept zeroed, the snapshot has to keep them as well
+		require.NoError(t, tree.Delete(vtree.GetTreeKeyStorageSlot(common.Address{}.Bytes(), uint256.NewInt(0))))
+		root, err = tree.Commit()
+		require.NoError(t, err)
+		return verkledb.WriteVerkleRoot(tx, 2_000, root)
+	}))
+
+	_, err := DumpVerkleTree(context.Background(), db, 1_500, dir, dir, 1, log.LvlDebug)
+	require.Error(t, err)
+	segmentFilePath, err := DumpVerkleTree(context.Background(), db, 2_000, dir, dir, 1, log.LvlDebug)
+	require.NoError(t, err)
+
+	files, err := snap.VerkleSegments(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	require.Equal(t, segmentFilePath, files[0].Path)
+	require.True(t, files[0].Seedable())
+	_, name := filepath.Split(segmentFilePath)
+	require.Equal(t, "v1-000002-000002-verkle.seg", name)
+
+	blockNum, snapshotRoot, err := ReadVerkleSnapshotHeader(segmentFilePath)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000), blockNum)
+	require.Equal(t, root, snapshotRoot)
+
+	// A new node rebuilds the same tree out of the snapshot
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	blockNum, loadedRoot, err := LoadVerkleSnapshot(context.Background(), tx, segmentFilePath, dir)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000), blockNum)
+	require.Equal(t, root, loadedRoot)
+	tree, err := verkledb.NewVerkleTree(tx, loadedRoot)
+	require.NoError(t, err)
+	value, err := tree.Get(vtree.GetTreeKeyStorageSlot(common.BytesToAddress([]byte{1}).Bytes(), uint256.NewInt(100)))
+	require.NoError(t, err)
+	require.Equal(t, byte(2), value[0])
+}
+
+func TestVerkleDownloadRequest(t *testing.T) {
+	dir := t.TempDir()
+	createTestSegmentFile(t, 500_000, 1_000_000, snap.Headers, dir)
+	createTestSegmentFile(t, 2_000, 2_000, snap.Verkle, dir)
+	createTestSegmentFile(t, 4_000, 4_000, snap.Verkle, dir)
+
+	verkleFiles, err := VerkleFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1-000002-000002-verkle.seg", "v1-000004-000004-verkle.seg"}, verkleFiles)
+
+	// The preverified snapshot is requested by its hash, the other one is seeded
+	hash := "a3b1c2d4e5f60718293a4b5c6d7e8f9012345678"
+	preverified := snapcfg.Preverified{{Name: snap.VerkleFileName(2_000), Hash: hash}}
+	verkleRequests, err := VerkleDownloadRequests(dir, preverified)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(verkleRequests))
+
+	downloadRequest := []DownloadRequest{
+		NewDownloadRequest(nil, preverified[0].Name, preverified[0].Hash),
+		NewDownloadRequest(&Range{from: 1_000_000, to: 1_500_000}, "", ""),
+	}
+	req := BuildProtoRequest(append(downloadRequest, verkleRequests...))
+	require.Equal(t, 1+len(snap.AllSnapshotTypes)+1, len(req.Items))
+	require.Equal(t, snap.VerkleFileName(2_000), req.Items[0].Path)
+	require.Equal(t, downloadergrpc.String2Proto(hash), req.Items[0].TorrentHash)
+	require.Equal(t, snap.SegmentFileName(1_000_000, 1_500_000, snap.Headers), req.Items[1].Path)
+	last := req.Items[len(req.Items)-1]
+	require.Equal(t, "v1-000004-000004-verkle.seg", last.Path)
+	require.Nil(t, last.TorrentHash)
+}
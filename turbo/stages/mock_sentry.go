@@ -50,6 +50,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages/bodydownload"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -77,6 +78,7 @@ type MockSentry struct {
 	UpdateHead     func(Ctx context.Context, head uint64, hash common.Hash, td *uint256.Int)
 	streams        map[proto_sentry.MessageId][]proto_sentry.Sentry_MessagesServer
 	sentMessages   []*proto_sentry.OutboundMessageData
+	sentLock       sync.Mutex
 	StreamWg       sync.WaitGroup
 	ReceiveWg      sync.WaitGroup
 	Address        common.Address
@@ -132,25 +134,47 @@ func (ms *MockSentry) HandShake(ctx context.Context, in *emptypb.Empty) (*proto_
 	return &proto_sentry.HandShakeReply{Protocol: proto_sentry.Protocol_ETH66}, nil
 }
 func (ms *MockSentry) SendMessageByMinBlock(_ context.Context, r *proto_sentry.SendMessageByMinBlockRequest) (*proto_sentry.SentPeers, error) {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	ms.sentMessages = append(ms.sentMessages, r.Data)
-	return nil, nil
+	return &proto_sentry.SentPeers{Peers: []*ptypes.H512{ms.PeerId}}, nil
 }
 func (ms *MockSentry) SendMessageById(_ context.Context, r *proto_sentry.SendMessageByIdRequest) (*proto_sentry.SentPeers, error) {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	ms.sentMessages = append(ms.sentMessages, r.Data)
 	return nil, nil
 }
 func (ms *MockSentry) SendMessageToRandomPeers(_ context.Context, r *proto_sentry.SendMessageToRandomPeersRequest) (*proto_sentry.SentPeers, error) {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	ms.sentMessages = append(ms.sentMessages, r.Data)
 	return nil, nil
 }
 func (ms *MockSentry) SendMessageToAll(_ context.Context, r *proto_sentry.OutboundMessageData) (*proto_sentry.SentPeers, error) {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	ms.sentMessages = append(ms.sentMessages, r)
 	return nil, nil
 }
 func (ms *MockSentry) SentMessage(i int) *proto_sentry.OutboundMessageData {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
 	return ms.sentMessages[i]
 }
 
+// SentMessages is the number of messages sent so far, they may be sent by the multi-client from other goroutines
+func (ms *MockSentry) SentMessages() int {
+	ms.sentLock.Lock()
+	defer ms.sentLock.Unlock()
+	return len(ms.sentMessages)
+}
+
+// MultiClient returns the multi-client of the mock, which talks to the mock as its only sentry
+func (ms *MockSentry) MultiClient() *sentry.MultiClient {
+	return ms.sentriesClient
+}
+
 func (ms *MockSentry) Messages(req *proto_sentry.MessagesRequest, stream proto_sentry.Sentry_MessagesServer) error {
 	if ms.streams == nil {
 		ms.streams = map[proto_sentry.MessageId][]proto_sentry.Sentry_MessagesServer{}
@@ -208,7 +232,7 @@ func MockWithEverything(t *testing.T, gspec *core.Genesis, key *ecdsa.PrivateKey
 	dirs := datadir.New(tmpdir)
 	var err error
 
-	db := memdb.New()
+	db := verkledb.NewMemDB()
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	erigonGrpcServeer := remotedbserver.NewKvServer(ctx, db, nil)
@@ -266,7 +290,7 @@ func MockWithEverything(t *testing.T, gspec *core.Genesis, key *ecdsa.PrivateKey
 		mock.txNums = exec22.TxNumsFromDB(allSnapshots, db)
 	}
 
-	mock.SentryClient = direct.NewSentryClientDirect(eth.ETH66, mock)
+	mock.SentryClient = sentry.NewSentryClientDirect(eth.ETH66, mock)
 	sentries := []direct.SentryClient{mock.SentryClient}
 
 	sendBodyRequest := func(context.Context, *bodydownload.BodyRequest) ([64]byte, bool) { return [64]byte{}, false }
@@ -346,7 +370,7 @@ func MockWithEverything(t *testing.T, gspec *core.Genesis, key *ecdsa.PrivateKey
 	}
 	blockRetire := snapshotsync.NewBlockRetire(1, dirs.Tmp, allSnapshots, mock.DB, snapshotsDownloader, mock.Notifications.Events)
 	mock.Sync = stagedsync.New(
-		stagedsync.DefaultStages(mock.Ctx, nil, prune,
+		stagedsync.DefaultStages(mock.Ctx, prune,
 			stagedsync.StageSnapshotsCfg(
 				mock.DB,
 				mock.sentriesClient.Hd,
@@ -368,7 +368,6 @@ func StateStep(ctx context.Context, batch kv.RwTx, stateSync *stagedsync.Sync, h
 
 func NewStagedSync(ctx context.Context,
 	db kv.RwDB,
-	verkleDb kv.RwDB,
 	p2pCfg p2p.Config,
 	cfg *ethconfig.Config,
 	controlServer *sentry.MultiClient,
@@ -399,7 +398,7 @@ func NewStagedSync(ctx context.Context,
 	}
 
 	return stagedsync.New(
-		stagedsync.DefaultStages(ctx, verkleDb, cfg.Prune,
+		stagedsync.DefaultStages(ctx, cfg.Prune,
 			stagedsync.StageSnapshotsCfg(
 				db,
 				controlServer.Hd,
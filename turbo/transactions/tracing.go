@@ -19,6 +19,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers"
+	_ "github.com/ledgerwatch/erigon/eth/tracers/native" // register the native tracers
 	"github.com/ledgerwatch/erigon/params"
 )
 
@@ -77,6 +78,16 @@ func ComputeTxEnv(ctx context.Context, block *types.Block, cfg *params.ChainConf
 	return nil, vm.BlockContext{}, vm.TxContext{}, nil, nil, fmt.Errorf("transaction index %d out of range for block %x", txIndex, blockHash)
 }
 
+// ValidateTraceConfig checks that the tracer of the configuration exists and accepts
+// its tracer config, so that invalid requests fail before any transaction is traced.
+func ValidateTraceConfig(config *tracers.TraceConfig) error {
+	if config == nil || config.Tracer == nil {
+		return nil
+	}
+	_, err := tracers.New(*config.Tracer, new(tracers.Context), config.TracerConfig)
+	return err
+}
+
 // TraceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -106,18 +117,20 @@ func TraceTx(
 				return err
 			}
 		}
-		// Construct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer, &tracers.Context{
+		// Construct the native or JavaScript tracer to execute with
+		t, err := tracers.New(*config.Tracer, &tracers.Context{
 			TxHash: txCtx.TxHash,
-		}); err != nil {
+		}, config.TracerConfig)
+		if err != nil {
 			stream.WriteNil()
 			return err
 		}
+		tracer = t
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			t.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 		streaming = false
@@ -168,7 +181,7 @@ func TraceTx(
 		stream.WriteString(returnVal)
 		stream.WriteObjectEnd()
 	} else {
-		if r, err1 := tracer.(*tracers.Tracer).GetResult(); err1 == nil {
+		if r, err1 := tracer.(tracers.Tracer).GetResult(); err1 == nil {
 			stream.Write(r)
 		} else {
 			return err1
@@ -122,6 +122,10 @@ type RootHashAggregator struct {
 	a              accounts.Account
 	leafData       GenStructStepLeafData
 	accData        GenStructStepAccountData
+
+	proofRetainer RetainDecider // if set - nodes on the paths to retained keys are kept in memory (see Result)
+	rootNode      node
+	accNibbles    []byte
 }
 
 type StreamReceiver interface {
@@ -173,6 +177,18 @@ func (l *FlatDBTrieLoader) SetStreamReceiver(receiver StreamReceiver) {
 	l.receiver = receiver
 }
 
+// SetProofRetainer makes default receiver keep in memory all nodes on the paths to keys
+// retained by `rd`, so the result of CalcTrieRoot can be used to build merkle proofs.
+// Must be called after Reset. Accounts are retained by addrHash, storage by addrHash+incarnation+keyHash.
+func (l *FlatDBTrieLoader) SetProofRetainer(rd RetainDecider) {
+	l.defaultReceiver.proofRetainer = rd
+}
+
+// Result returns sub-tries collected by the receiver during last CalcTrieRoot
+func (l *FlatDBTrieLoader) Result() SubTries {
+	return l.receiver.Result()
+}
+
 // CalcTrieRoot algo:
 //
 //		for iterateIHOfAccounts {
@@ -327,6 +343,22 @@ func (r *RootHashAggregator) RetainNothing(_ []byte) bool {
 	return false
 }
 
+func (r *RootHashAggregator) retainAccount(prefix []byte) bool {
+	if r.proofRetainer == nil {
+		return false
+	}
+	return r.proofRetainer.Retain(prefix)
+}
+
+func (r *RootHashAggregator) retainStorage(prefix []byte) bool {
+	if r.proofRetainer == nil {
+		return false
+	}
+	hexutil.DecompressNibbles(r.currAccK, &r.accNibbles)
+	r.accNibbles = append(r.accNibbles, prefix...)
+	return r.proofRetainer.Retain(r.accNibbles)
+}
+
 func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2, trace bool) {
 	r.hc = hc
 	r.shc = shc
@@ -344,6 +376,8 @@ func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2,
 	r.valueStorage = nil
 	r.wasIHStorage = false
 	r.root = common.Hash{}
+	r.rootNode = nil
+	r.proofRetainer = nil
 	r.trace = trace
 	r.hb.trace = trace
 }
@@ -493,8 +527,10 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 		}
 		if r.hb.hasRoot() {
 			r.root = r.hb.rootHash()
+			r.rootNode = r.hb.root()
 		} else {
 			r.root = EmptyRoot
+			r.rootNode = nil
 		}
 		r.groups = r.groups[:0]
 		r.hasTree = r.hasTree[:0]
@@ -522,8 +558,13 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 // 	}
 // }
 
+// Result returns the root node built during CalcTrieRoot. Only paths retained by
+// proofRetainer are expanded, the rest of the trie is represented by hash nodes.
 func (r *RootHashAggregator) Result() SubTries {
-	panic("don't call me")
+	if r.rootNode == nil {
+		return SubTries{Hashes: []common.Hash{r.root}, roots: []node{hashNode{hash: common.CopyBytes(r.root[:])}}}
+	}
+	return SubTries{Hashes: []common.Hash{r.root}, roots: []node{r.rootNode}}
 }
 
 func (r *RootHashAggregator) Root() common.Hash {
@@ -563,7 +604,7 @@ func (r *RootHashAggregator) genStructStorage() error {
 		r.leafData.Value = rlphacks.RlpSerializableBytes(r.valueStorage)
 		data = &r.leafData
 	}
-	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.RetainNothing, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.retainStorage, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.shc == nil {
 			return nil
 		}
@@ -631,7 +672,7 @@ func (r *RootHashAggregator) genStructAccount() error {
 	r.currStorage.Reset()
 	r.succStorage.Reset()
 	var err error
-	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.RetainNothing, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.retainAccount, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.hc == nil {
 			return nil
 		}
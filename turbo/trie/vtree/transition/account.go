@@ -1,50 +1,20 @@
-package verkle
+package transition
 
 import (
 	"time"
 
-	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 	"github.com/ledgerwatch/log/v3"
 )
 
-func getVerkleCodeChunks(address, code []byte) ([][]byte, [][]byte) {
-	var chunks [][]byte
-	var chunkKeys [][]byte
-
-	// Chunkify contract code and build keys for each chunks and insert them in the tree
-	chunkedCode := vtree.ChunkifyCode(code)
-	offset := byte(0)
-	offsetOverflow := false
-	currentKey := vtree.GetTreeKeyCodeChunk(address, uint256.NewInt(0))
-	// Write code chunks
-	for i := 0; i < len(chunkedCode); i += 32 {
-		chunks = append(chunks, common.CopyBytes(chunkedCode[i:i+32]))
-		codeKey := common.CopyBytes(currentKey)
-		if currentKey[31]+offset < currentKey[31] || offsetOverflow {
-			currentKey = vtree.GetTreeKeyCodeChunk(address, uint256.NewInt(uint64(i)/32))
-			chunkKeys = append(chunkKeys, codeKey)
-			offset = 1
-			offsetOverflow = false
-		} else {
-			codeKey[31] += offset
-			chunkKeys = append(chunkKeys, codeKey)
-			offset += 1
-			// If offset overflows, handle it.
-			offsetOverflow = offset == 0
-		}
-	}
-	return chunks, chunkKeys
-}
-
-func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64) (common.Hash, error) {
+func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *verkledb.VerkleTree, from uint64) (common.Hash, error) {
 	// TODO: later logging
 	logInterval := time.NewTicker(180 * time.Second)
 	//logPrefix := "processing verkle accounts"
@@ -61,6 +31,7 @@ func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64)
 
 	marker := verkledb.NewVerkleMarker(executionProgress != from+1)
 	defer marker.Rollback()
+	keys := verkledb.NewTreeKeys(tx, true)
 
 	start := uint64(0)
 	if from != 0 {
@@ -89,6 +60,13 @@ func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64)
 		}
 
 		if len(encodedAccount) == 0 {
+			// Storage and code of the account are found through the lookups and go together with it
+			if err := deleteCode(writer, tx, addressBytes); err != nil {
+				return common.Hash{}, err
+			}
+			if err := deleteLookups(tx, addressBytes); err != nil {
+				return common.Hash{}, err
+			}
 			if err := writer.DeleteAccount(vtree.GetTreeKeyVersion(addressBytes)); err != nil {
 				return common.Hash{}, err
 			}
@@ -99,7 +77,7 @@ func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64)
 		}
 		var acc accounts.Account
 		if err := acc.DecodeForStorage(encodedAccount); err != nil {
-			return common.Hash{}, nil
+			return common.Hash{}, err
 		}
 		code, err := coreTx.GetOne(kv.Code, acc.CodeHash[:])
 		if err != nil {
@@ -111,22 +89,24 @@ func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64)
 				return common.Hash{}, err
 			}
 			if prevIncarnation != acc.Incarnation {
-				if err := writer.DeleteCode(tx, addressBytes); err != nil {
+				if err := deleteCode(writer, tx, addressBytes); err != nil {
 					return common.Hash{}, err
 				}
-			}
-			chunks, chunkKeys := getVerkleCodeChunks(addressBytes, code)
-			for i := range chunks {
-				if err := verkledb.WritePedersenCodeLookup(tx, addressBytes, uint32(i), chunkKeys[i]); err != nil {
-					return common.Hash{}, err
-				}
-				if err := writer.Insert(chunkKeys[i], chunks[i]); err != nil {
+				// The next change of the contract is not a new incarnation
+				if err := verkledb.WriteVerkleIncarnation(tx, common.BytesToAddress(addressBytes), acc.Incarnation); err != nil {
 					return common.Hash{}, err
 				}
 			}
+			if err := writeCode(writer, keys, addressBytes, code); err != nil {
+				return common.Hash{}, err
+			}
 		}
 
-		if err = writer.UpdateAccount(vtree.GetTreeKeyVersion(addressBytes), uint64(len(code)), acc); err != nil {
+		versionKey, err := keys.VersionKey(addressBytes)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err = writer.UpdateAccount(versionKey, uint64(len(code)), acc); err != nil {
 			return common.Hash{}, err
 		}
 		if err := marker.MarkAsDone(addressBytes); err != nil {
@@ -138,9 +118,5 @@ func ProcessAccounts(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64)
 		default:
 		}
 	}
-	lastRoot, err := verkledb.ReadVerkleRoot(tx, executionProgress)
-	if err != nil {
-		return common.Hash{}, err
-	}
-	return writer.CommitVerkleTree(lastRoot)
+	return writer.Commit()
 }
@@ -0,0 +1,45 @@
+package transition
+
+import (
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+)
+
+// writeCode chunkifies contract code and inserts the chunks in the tree, their keys go into the code lookups
+func writeCode(tree *verkledb.VerkleTree, keys *verkledb.TreeKeys, address, code []byte) error {
+	chunkedCode := vtree.ChunkifyCode(code)
+	chunkKeys, err := keys.CodeChunkKeys(address, len(chunkedCode)/32)
+	if err != nil {
+		return err
+	}
+	for i, chunkKey := range chunkKeys {
+		if err := tree.Insert(chunkKey, common.CopyBytes(chunkedCode[i*32:(i+1)*32])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCode removes the code chunks and the storage slots of an account from the tree
+func deleteCode(tree *verkledb.VerkleTree, tx kv.RwTx, address []byte) error {
+	badKeys, err := verkledb.ReadPedersenLookups(tx, common.BytesToAddress(address))
+	if err != nil {
+		return err
+	}
+	for _, badKey := range badKeys {
+		if err := tree.Delete(badKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLookups removes the storage and code lookups of an account
+func deleteLookups(tx kv.RwTx, address []byte) error {
+	if err := verkledb.DeletePedersenLookups(tx, verkledb.PedersenHashedStorageLookup, address); err != nil {
+		return err
+	}
+	return verkledb.DeletePedersenLookups(tx, verkledb.PedersenHashedCodeLookup, address)
+}
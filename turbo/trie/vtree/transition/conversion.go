@@ -0,0 +1,228 @@
+package transition
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// AdvanceConversion moves the conversion cursor over the flat state as it was before martinBlock, `stride` leaves per step.
+// Accounts and storage slots count as one leaf each, code chunks go along with their account.
+// The progress after each step is returned, the last one is Done if the state runs out before all the steps are taken.
+// Walking a snapshot rather than the latest state makes the block completing the conversion the same on every node.
+func AdvanceConversion(tx kv.Tx, progress verkledb.ConversionProgress, stride, steps, martinBlock uint64) ([]verkledb.ConversionProgress, error) {
+	if progress.Done || steps == 0 {
+		return nil, nil
+	}
+	var (
+		startAddress  common.Address
+		startLocation common.Hash
+		// The account of the cursor is converted already, and so is the storage up to the cursor
+		resumeAccount  = len(progress.Cursor) >= common.AddressLength
+		resumeLocation = len(progress.Cursor) > common.AddressLength
+
+		result  = make([]verkledb.ConversionProgress, 0, steps)
+		lastKey = progress.Cursor
+		pending uint64
+	)
+	if resumeAccount {
+		startAddress = common.BytesToAddress(progress.Cursor[:common.AddressLength])
+	}
+	if resumeLocation {
+		startLocation = common.BytesToHash(progress.Cursor[common.AddressLength+common.IncarnationLength:])
+	}
+	// visit counts a leaf and tells whether the walk goes on. A leaf past the last step is not counted, it only tells
+	// that the last step does not complete the conversion, however many steps are taken at once.
+	visit := func(key []byte) bool {
+		if uint64(len(result)) == steps {
+			return false
+		}
+		lastKey = key
+		if pending++; pending == stride {
+			result = append(result, verkledb.ConversionProgress{Cursor: key})
+			pending = 0
+		}
+		return true
+	}
+
+	more := true
+	if err := state.WalkAsOfAccounts(tx, startAddress, martinBlock, func(k, v []byte) (bool, error) {
+		address := common.BytesToAddress(k)
+		resumed := resumeAccount && address == startAddress
+		if !resumed && !visit(common.CopyBytes(k)) {
+			more = false
+			return false, nil
+		}
+		var acc accounts.Account
+		if err := acc.DecodeForStorage(v); err != nil {
+			return false, err
+		}
+		if acc.Incarnation == 0 {
+			return true, nil
+		}
+		var location common.Hash
+		if resumed && resumeLocation {
+			location = startLocation
+		}
+		if err := state.WalkAsOfStorage(tx, address, acc.Incarnation, location, martinBlock, func(_, loc, _ []byte) (bool, error) {
+			if resumed && resumeLocation && bytes.Equal(loc, startLocation[:]) {
+				return true, nil
+			}
+			more = visit(dbutils.PlainGenerateCompositeStorageKey(address[:], acc.Incarnation, loc))
+			return more, nil
+		}); err != nil {
+			return false, err
+		}
+		return more, nil
+	}); err != nil {
+		return nil, err
+	}
+	if !more {
+		return result, nil
+	}
+	// The state ran out, the last step completes the conversion
+	if pending == 0 && len(result) > 0 {
+		result[len(result)-1].Done = true
+	} else {
+		result = append(result, verkledb.ConversionProgress{Cursor: common.CopyBytes(lastKey), Done: true})
+	}
+	return result, nil
+}
+
+// conversionScheduleChunk is how many steps of the schedule are worked out at once
+const conversionScheduleChunk = 1 << 12
+
+// WriteConversionSchedule writes the progress of every block of a gradual conversion, from martinBlock until the block
+// completing it. The schedule only depends on the state before martinBlock, so it is worked out once, before the fork
+// block is executed.
+func WriteConversionSchedule(tx kv.RwTx, stride, martinBlock uint64) error {
+	var progress verkledb.ConversionProgress
+	for blockNum := martinBlock; !progress.Done; {
+		steps, err := AdvanceConversion(tx, progress, stride, conversionScheduleChunk, martinBlock)
+		if err != nil {
+			return err
+		}
+		for _, step := range steps {
+			if err = verkledb.WriteConversionProgress(tx, blockNum, step); err != nil {
+				return err
+			}
+			blockNum++
+		}
+		progress = steps[len(steps)-1]
+	}
+	return nil
+}
+
+// ConvertRange writes the accounts and storage slots of PlainState after `from` and up to `to` into the tree.
+// Values are taken from the latest state, leaves changed after the fork are written by execution anyway.
+func ConvertRange(coreTx kv.Tx, tx kv.RwTx, writer *verkledb.VerkleTree, from, to verkledb.ConversionProgress) error {
+	if from.Done {
+		return nil
+	}
+	logInterval := time.NewTicker(30 * time.Second)
+	defer logInterval.Stop()
+
+	c, err := coreTx.Cursor(kv.PlainState)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var k, v []byte
+	if from.Cursor == nil {
+		k, v, err = c.First()
+	} else if k, v, err = c.Seek(from.Cursor); err == nil && bytes.Equal(k, from.Cursor) {
+		k, v, err = c.Next()
+	}
+	var (
+		address     common.Address
+		incarnation uint64
+		keys        = verkledb.NewTreeKeys(tx, true)
+	)
+	for ; k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if !to.Done && bytes.Compare(k, to.Cursor) > 0 {
+			break
+		}
+		if len(k) == common.AddressLength {
+			address = common.BytesToAddress(k)
+			if incarnation, err = convertAccount(coreTx, tx, writer, keys, address, v); err != nil {
+				return err
+			}
+			continue
+		}
+		// The range may start in the middle of the storage of an account
+		if !bytes.Equal(k[:common.AddressLength], address[:]) {
+			address = common.BytesToAddress(k[:common.AddressLength])
+			var acc accounts.Account
+			has, err := rawdb.ReadAccount(coreTx, address, &acc)
+			if err != nil {
+				return err
+			}
+			incarnation = 0
+			if has {
+				incarnation = acc.Incarnation
+			}
+		}
+		// Storage of self-destructed incarnations is not converted
+		if incarnation == 0 || binary.BigEndian.Uint64(k[common.AddressLength:]) != incarnation {
+			continue
+		}
+		storageSlot := new(uint256.Int).SetBytes(k[common.AddressLength+common.IncarnationLength:])
+		var val [32]byte
+		vtree.Int256ToVerkleFormat(new(uint256.Int).SetBytes(v), val[:])
+		key, err := keys.StorageKey(address[:], storageSlot)
+		if err != nil {
+			return err
+		}
+		if err := writer.Insert(key, val[:]); err != nil {
+			return err
+		}
+		select {
+		case <-logInterval.C:
+			log.Info("Converting state into verkle tree", "key", common.Bytes2Hex(k))
+		default:
+		}
+	}
+	return err
+}
+
+// convertAccount writes the header and the code of an account into the tree and returns its incarnation
+func convertAccount(coreTx kv.Tx, tx kv.RwTx, writer *verkledb.VerkleTree, keys *verkledb.TreeKeys, address common.Address, encodedAccount []byte) (uint64, error) {
+	var acc accounts.Account
+	if err := acc.DecodeForStorage(encodedAccount); err != nil {
+		return 0, err
+	}
+	var code []byte
+	if !acc.IsEmptyCodeHash() {
+		var err error
+		if code, err = coreTx.GetOne(kv.Code, acc.CodeHash[:]); err != nil {
+			return 0, err
+		}
+		if err := writeCode(writer, keys, address[:], code); err != nil {
+			return 0, err
+		}
+		// Keeps ProcessAccounts from taking the next change of the contract for a new incarnation
+		if err := verkledb.WriteVerkleIncarnation(tx, address, acc.Incarnation); err != nil {
+			return 0, err
+		}
+	}
+	versionKey, err := keys.VersionKey(address[:])
+	if err != nil {
+		return 0, err
+	}
+	return acc.Incarnation, writer.UpdateAccount(versionKey, uint64(len(code)), acc)
+}
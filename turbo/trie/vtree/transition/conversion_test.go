@@ -0,0 +1,213 @@
+package transition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMartinBlock = 3
+	testStride      = 2
+)
+
+// executeTestBlock commits the changes of a block to PlainState together with the changesets and the history indices
+func executeTestBlock(t *testing.T, tx kv.RwTx, blockNum uint64, apply func(ibs *state.IntraBlockState)) {
+	ibs := state.New(state.NewPlainStateReader(tx))
+	apply(ibs)
+	w := state.NewPlainStateWriter(tx, tx, blockNum)
+	require.NoError(t, ibs.CommitBlock(params.AllEthashProtocolChanges.Rules(blockNum), w))
+	require.NoError(t, w.WriteChangeSets())
+	require.NoError(t, w.WriteHistory())
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, blockNum))
+}
+
+// executeConversionBlock runs the blocks of the conversion test, the fork state has 6 accounts and 3 storage slots
+func executeConversionBlock(t *testing.T, tx kv.RwTx, blockNum uint64) {
+	executeTestBlock(t, tx, blockNum, func(ibs *state.IntraBlockState) {
+		switch blockNum {
+		case 1:
+			for i := byte(1); i <= 5; i++ {
+				ibs.AddBalance(common.BytesToAddress([]byte{i}), uint256.NewInt(uint64(i)*1000))
+			}
+			ibs.CreateAccount(testContract, true)
+			ibs.SetCode(testContract, testCode)
+			for i := uint64(0); i < 3; i++ {
+				slot := common.BigToHash(uint256.NewInt(i).ToBig())
+				ibs.SetState(testContract, &slot, *uint256.NewInt(i + 1))
+			}
+		case 2:
+			ibs.AddBalance(common.BytesToAddress([]byte{2}), uint256.NewInt(1))
+		default:
+			// Changes after the fork go into the tree whether their leaves are converted or not
+			ibs.AddBalance(common.BytesToAddress([]byte{5}), uint256.NewInt(blockNum))
+			slot := common.BigToHash(uint256.NewInt(blockNum).ToBig())
+			ibs.SetState(testContract, &slot, *uint256.NewInt(blockNum))
+		}
+	})
+}
+
+// runConversion moves the tree from the VerkleTrie stage progress up to the execution progress, like the stage does
+func runConversion(t *testing.T, tx kv.RwTx) common.Hash {
+	from, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	require.NoError(t, err)
+	if from < testMartinBlock-1 {
+		from = testMartinBlock - 1
+	}
+	to, err := stages.GetStageProgress(tx, stages.Execution)
+	require.NoError(t, err)
+	root, err := verkledb.ReadVerkleRoot(tx, from)
+	require.NoError(t, err)
+	tree := openTestTree(t, tx, root)
+
+	progress, err := verkledb.ReadConversionProgress(tx, from)
+	require.NoError(t, err)
+	steps, err := AdvanceConversion(tx, progress, testStride, to-from, testMartinBlock)
+	require.NoError(t, err)
+	for i, step := range steps {
+		require.NoError(t, verkledb.WriteConversionProgress(tx, from+uint64(i)+1, step))
+	}
+	if len(steps) > 0 {
+		require.NoError(t, ConvertRange(tx, tx, tree, progress, steps[len(steps)-1]))
+	}
+	_, err = ProcessAccounts(tx, tx, tree, from)
+	require.NoError(t, err)
+	storageRoot, err := ProcessStorage(tx, tx, tree, from)
+	require.NoError(t, err)
+	return storageRoot
+}
+
+func TestGradualConversion(t *testing.T) {
+	const lastBlock = 8
+
+	// Conversion in a single batch
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		executeConversionBlock(t, tx, blockNum)
+	}
+	root := runConversion(t, tx)
+
+	// 9 leaves at 2 per block take 5 blocks from the fork
+	for blockNum := uint64(testMartinBlock); blockNum <= lastBlock; blockNum++ {
+		progress, err := verkledb.ReadConversionProgress(tx, blockNum)
+		require.NoError(t, err)
+		require.Equal(t, blockNum >= testMartinBlock+4, progress.Done, "block %d", blockNum)
+	}
+	progress, err := verkledb.ReadConversionProgress(tx, testMartinBlock)
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToAddress([]byte{2}).Bytes(), progress.Cursor)
+
+	// Converted tree is the same as the one of the whole state at once
+	fullTree := openTestTree(t, tx, common.Hash{})
+	require.NoError(t, ConvertRange(tx, tx, fullTree, verkledb.ConversionProgress{}, verkledb.ConversionProgress{Done: true}))
+	fullRoot, err := fullTree.Commit()
+	require.NoError(t, err)
+	require.Equal(t, fullRoot, root)
+
+	// Same conversion, block by block
+	db := memdb.NewTestDB(t)
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		tx, err := db.BeginRw(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, verkledb.InitDB(tx))
+		executeConversionBlock(t, tx, blockNum)
+		if blockNum >= testMartinBlock {
+			blockRoot := runConversion(t, tx)
+			if blockNum == lastBlock {
+				require.Equal(t, root, blockRoot)
+			}
+		}
+		if blockNum == testMartinBlock {
+			checkOverlayReader(t, tx)
+		}
+		require.NoError(t, tx.Commit())
+	}
+}
+
+// checkOverlayReader reads a partly converted state through the overlay, it has to match the flat state
+func checkOverlayReader(t *testing.T, tx kv.RwTx) {
+	progress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	require.NoError(t, err)
+	root, err := verkledb.ReadVerkleRoot(tx, progress)
+	require.NoError(t, err)
+	tree := openTestTree(t, tx, root)
+	flat := state.NewPlainStateReader(tx)
+	overlay := verkledb.NewOverlayReader(tree.Node(), tree.Resolve, flat)
+
+	// Account 2 is converted, account 3 only exists in the flat state
+	converted, err := tree.Get(vtree.GetTreeKeyCodeKeccak(common.BytesToAddress([]byte{2}).Bytes()))
+	require.NoError(t, err)
+	require.NotNil(t, converted)
+	unconverted, err := tree.Get(vtree.GetTreeKeyCodeKeccak(common.BytesToAddress([]byte{3}).Bytes()))
+	require.NoError(t, err)
+	require.Nil(t, unconverted)
+
+	for i := byte(1); i <= 6; i++ {
+		address := common.BytesToAddress([]byte{i})
+		expected, err := flat.ReadAccountData(address)
+		require.NoError(t, err)
+		acc, err := overlay.ReadAccountData(address)
+		require.NoError(t, err)
+		if expected == nil {
+			require.Nil(t, acc)
+			continue
+		}
+		require.Equal(t, expected.Balance, acc.Balance)
+		require.Equal(t, expected.Nonce, acc.Nonce)
+	}
+
+	expectedAcc, expectedCode, expectedStorage, _ := readTestState(t, flat, testMartinBlock)
+	acc, code, storage, absent := readTestState(t, overlay, testMartinBlock)
+	require.Equal(t, expectedAcc.Incarnation, acc.Incarnation)
+	require.Equal(t, expectedCode, code)
+	require.Equal(t, expectedStorage, storage)
+	require.Nil(t, absent)
+	for i := uint64(0); i < 3; i++ {
+		slot := common.BigToHash(uint256.NewInt(i).ToBig())
+		expected, err := flat.ReadAccountStorage(testContract, expectedAcc.Incarnation, &slot)
+		require.NoError(t, err)
+		value, err := overlay.ReadAccountStorage(testContract, acc.Incarnation, &slot)
+		require.NoError(t, err)
+		require.Equal(t, expected, value)
+	}
+}
+
+func TestAdvanceConversionBatches(t *testing.T) {
+	const lastBlock = 8
+
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		executeConversionBlock(t, tx, blockNum)
+	}
+	// The 9 leaves of the fork state fill the last step of a stride of 3
+	for _, stride := range []uint64{testStride, 3} {
+		all, err := AdvanceConversion(tx, verkledb.ConversionProgress{}, stride, 100, testMartinBlock)
+		require.NoError(t, err)
+		require.True(t, all[len(all)-1].Done)
+
+		// The steps, and the one completing the conversion, don't depend on how many of them are taken at once
+		for steps := uint64(1); steps <= uint64(len(all)); steps++ {
+			var progress verkledb.ConversionProgress
+			var batched []verkledb.ConversionProgress
+			for !progress.Done {
+				batch, err := AdvanceConversion(tx, progress, stride, steps, testMartinBlock)
+				require.NoError(t, err)
+				batched = append(batched, batch...)
+				progress = batch[len(batch)-1]
+			}
+			require.Equal(t, all, batched, "stride %d, %d steps at once", stride, steps)
+		}
+	}
+}
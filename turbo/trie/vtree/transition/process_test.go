@@ -0,0 +1,339 @@
+package transition
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testContract = common.HexToAddress("0x00000000000000000000000000000000000c0de0")
+	testCode     = common.FromHex("0x6001600055600160015560016002556001600355")
+)
+
+// applyTestBlock writes the state changes of block `blockNum` into PlainState together with the changesets
+func applyTestBlock(t *testing.T, tx kv.RwTx, blockNum uint64) {
+	writeAccount := func(addr common.Address, acc *accounts.Account) {
+		prev, err := tx.GetOne(kv.PlainState, addr[:])
+		require.NoError(t, err)
+		require.NoError(t, tx.Put(kv.AccountChangeSet, dbutils.EncodeBlockNumber(blockNum), append(common.CopyBytes(addr[:]), prev...)))
+		encoded := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(encoded)
+		require.NoError(t, tx.Put(kv.PlainState, addr[:], encoded))
+	}
+	for _, addr := range []common.Address{common.BigToAddress(common.Big1), common.BytesToAddress([]byte{byte(blockNum)})} {
+		acc := accounts.NewAccount()
+		acc.Nonce = blockNum
+		acc.Balance.SetUint64(blockNum * 1000)
+		writeAccount(addr, &acc)
+	}
+
+	contract := accounts.NewAccount()
+	contract.Incarnation = 1
+	contract.CodeHash = crypto.Keccak256Hash(testCode)
+	contract.Balance.SetUint64(blockNum)
+	writeAccount(testContract, &contract)
+	require.NoError(t, tx.Put(kv.Code, contract.CodeHash[:], testCode))
+	require.NoError(t, tx.Put(kv.PlainContractCode, dbutils.PlainGenerateStoragePrefix(testContract[:], contract.Incarnation), contract.CodeHash[:]))
+
+	slot := common.BigToHash(new(big.Int).SetUint64(blockNum))
+	storageKey := dbutils.PlainGenerateCompositeStorageKey(testContract[:], contract.Incarnation, slot[:])
+	prev, err := tx.GetOne(kv.PlainState, storageKey)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(kv.PlainState, storageKey, []byte{byte(blockNum)}))
+	changeKey := make([]byte, 8+common.AddressLength+common.IncarnationLength)
+	binary.BigEndian.PutUint64(changeKey, blockNum)
+	copy(changeKey[8:], storageKey[:common.AddressLength+common.IncarnationLength])
+	require.NoError(t, tx.Put(kv.StorageChangeSet, changeKey, append(common.CopyBytes(slot[:]), prev...)))
+
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, blockNum))
+}
+
+func openTestTree(t *testing.T, tx kv.RwTx, root common.Hash) *verkledb.VerkleTree {
+	tree, err := verkledb.NewVerkleTree(tx, root)
+	require.NoError(t, err)
+	return tree
+}
+
+// runTransition resumes the transition from the persisted progress and root
+func runTransition(t *testing.T, tx kv.RwTx) common.Hash {
+	from, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	require.NoError(t, err)
+	root, err := verkledb.ReadVerkleRoot(tx, from)
+	require.NoError(t, err)
+
+	verkleTree := openTestTree(t, tx, root)
+	_, err = ProcessAccounts(tx, tx, verkleTree, from)
+	require.NoError(t, err)
+	storageRoot, err := ProcessStorage(tx, tx, verkleTree, from)
+	require.NoError(t, err)
+
+	// VerkleTrieIncarnation stage remembers incarnations of the processed contracts
+	var incarnation [8]byte
+	binary.BigEndian.PutUint64(incarnation[:], 1)
+	require.NoError(t, tx.Put(verkledb.VerkleIncarnation, testContract[:], incarnation[:]))
+	return storageRoot
+}
+
+func TestTransitionRestart(t *testing.T) {
+	const lastBlock = 6
+
+	// Uninterrupted transition
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		applyTestBlock(t, tx, blockNum)
+	}
+	expectedRoot := runTransition(t, tx)
+
+	// Same transition, but the process is restarted after every couple of blocks
+	db := memdb.NewTestDB(t)
+	var intermediateRoots []common.Hash
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		tx, err := db.BeginRw(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, verkledb.InitDB(tx))
+		applyTestBlock(t, tx, blockNum)
+		if blockNum%2 == 0 {
+			intermediateRoots = append(intermediateRoots, runTransition(t, tx))
+		}
+		require.NoError(t, tx.Commit())
+	}
+
+	roTx, err := db.BeginRo(context.Background())
+	require.NoError(t, err)
+	defer roTx.Rollback()
+	progress, err := stages.GetStageProgress(roTx, stages.VerkleTrie)
+	require.NoError(t, err)
+	require.Equal(t, uint64(lastBlock), progress)
+	for i, root := range intermediateRoots {
+		persisted, err := verkledb.ReadVerkleRoot(roTx, uint64(i+1)*2)
+		require.NoError(t, err)
+		require.Equal(t, root, persisted)
+	}
+	require.Equal(t, expectedRoot, intermediateRoots[len(intermediateRoots)-1])
+}
+
+// readTestState reads the test contract with its code and a slot, and an absent account
+func readTestState(t *testing.T, r state.StateReader, blockNum uint64) (*accounts.Account, []byte, []byte, *accounts.Account) {
+	acc, err := r.ReadAccountData(testContract)
+	require.NoError(t, err)
+	code, err := r.ReadAccountCode(testContract, acc.Incarnation, acc.CodeHash)
+	require.NoError(t, err)
+	slot := common.BigToHash(new(big.Int).SetUint64(blockNum))
+	storage, err := r.ReadAccountStorage(testContract, acc.Incarnation, &slot)
+	require.NoError(t, err)
+	absent, err := r.ReadAccountData(common.HexToAddress("0xdead"))
+	require.NoError(t, err)
+	return acc, code, storage, absent
+}
+
+func TestWitnessProof(t *testing.T) {
+	const lastBlock = 3
+
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		applyTestBlock(t, tx, blockNum)
+	}
+	root := runTransition(t, tx)
+
+	// Read the state the way block execution does
+	recorder := state.NewVerkleKeyRecorder(state.NewPlainStateReader(tx))
+	_, code, _, _ := readTestState(t, recorder, lastBlock)
+	require.Equal(t, testCode, code)
+	keys := recorder.Keys()
+	// 2 account headers, one code chunk and one slot
+	require.Equal(t, 2*5+1+1, len(keys))
+
+	proof, keyVals, err := openTestTree(t, tx, root).Prove(keys)
+	require.NoError(t, err)
+	values := map[string][]byte{}
+	for _, kv := range keyVals {
+		values[string(kv.Key)] = kv.Value
+	}
+	require.Equal(t, crypto.Keccak256(testCode), values[string(vtree.GetTreeKeyCodeKeccak(testContract[:]))])
+	require.Equal(t, vtree.ChunkifyCode(testCode), values[string(vtree.GetTreeKeyCodeChunk(testContract[:], uint256.NewInt(0)))])
+	var slotValue [32]byte
+	slotValue[0] = lastBlock
+	require.Equal(t, slotValue[:], values[string(vtree.GetTreeKeyStorageSlot(testContract[:], uint256.NewInt(lastBlock)))])
+	absent := common.HexToAddress("0xdead")
+	require.Nil(t, values[string(vtree.GetTreeKeyBalance(absent[:]))])
+
+	// Proof is enough to rebuild a partial tree with the same root
+	rootPoint := new(verkle.Point)
+	require.NoError(t, rootPoint.SetBytes(root[:]))
+	deserialized, err := verkle.DeserializeProof(proof, keyVals)
+	require.NoError(t, err)
+	stateless, err := verkle.TreeFromProof(deserialized, rootPoint)
+	require.NoError(t, err)
+	require.Equal(t, root, common.Hash(stateless.ComputeCommitment().Bytes()))
+}
+
+func TestStatelessVerkleReader(t *testing.T) {
+	const lastBlock = 3
+
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum <= lastBlock; blockNum++ {
+		applyTestBlock(t, tx, blockNum)
+	}
+	root := runTransition(t, tx)
+
+	recorder := state.NewVerkleKeyRecorder(state.NewPlainStateReader(tx))
+	expectedAcc, expectedCode, expectedStorage, _ := readTestState(t, recorder, lastBlock)
+	proof, keyVals, err := openTestTree(t, tx, root).Prove(recorder.Keys())
+	require.NoError(t, err)
+
+	// Witness goes through the header encoding, like in a mined block
+	encoded, err := rlp.EncodeToBytes(keyVals)
+	require.NoError(t, err)
+	var decoded []verkle.KeyValuePair
+	require.NoError(t, rlp.DecodeBytes(encoded, &decoded))
+
+	reader, err := state.NewStatelessVerkleReader(root, proof, decoded)
+	require.NoError(t, err)
+	acc, code, storage, absent := readTestState(t, reader, lastBlock)
+	require.Equal(t, expectedAcc.Balance, acc.Balance)
+	require.Equal(t, expectedAcc.Nonce, acc.Nonce)
+	require.Equal(t, expectedAcc.CodeHash, acc.CodeHash)
+	require.Equal(t, expectedAcc.Incarnation, acc.Incarnation)
+	require.Equal(t, expectedCode, code)
+	require.Equal(t, expectedStorage, storage)
+	require.Nil(t, absent)
+	require.NoError(t, reader.Err())
+
+	// Reads outside of the witness fail
+	uncovered := common.BigToHash(big.NewInt(lastBlock - 1))
+	_, err = reader.ReadAccountStorage(testContract, acc.Incarnation, &uncovered)
+	require.ErrorIs(t, err, state.ErrVerkleWitnessMissingKey)
+	_, err = reader.ReadAccountData(common.BigToAddress(common.Big1))
+	require.ErrorIs(t, err, state.ErrVerkleWitnessMissingKey)
+	require.ErrorIs(t, reader.Err(), state.ErrVerkleWitnessMissingKey)
+
+	// Witness has to match the parent root
+	applyTestBlock(t, tx, lastBlock+1)
+	otherRoot := runTransition(t, tx)
+	_, err = state.NewStatelessVerkleReader(otherRoot, proof, decoded)
+	require.Error(t, err)
+
+	tampered := make([]verkle.KeyValuePair, len(decoded))
+	copy(tampered, decoded)
+	for i, kv := range tampered {
+		if bytes.Equal(kv.Key, vtree.GetTreeKeyBalance(testContract[:])) {
+			balance := common.CopyBytes(kv.Value)
+			balance[0]++
+			tampered[i].Value = balance
+		}
+	}
+	_, err = state.NewStatelessVerkleReader(root, proof, tampered)
+	require.Error(t, err)
+}
+
+func TestVerkleStateWriter(t *testing.T) {
+	const lastBlock = 3
+
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, verkledb.InitDB(tx))
+	for blockNum := uint64(1); blockNum < lastBlock; blockNum++ {
+		applyTestBlock(t, tx, blockNum)
+	}
+	parentRoot := runTransition(t, tx)
+
+	// Execute the changes of the last block directly on the tree
+	tree := openTestTree(t, tx, parentRoot)
+	ibs := state.New(verkledb.NewStateReader(tree.Node(), tree.Resolve))
+	created := common.BytesToAddress([]byte{lastBlock})
+	for _, addr := range []common.Address{common.BigToAddress(common.Big1), created} {
+		ibs.SetNonce(addr, lastBlock)
+		ibs.SetBalance(addr, uint256.NewInt(lastBlock*1000))
+	}
+	ibs.SetBalance(testContract, uint256.NewInt(lastBlock))
+	slot := common.BigToHash(big.NewInt(lastBlock))
+	ibs.SetState(testContract, &slot, *uint256.NewInt(lastBlock))
+	writer := state.NewVerkleStateWriter(tree.Node(), tree.Resolve)
+	require.NoError(t, ibs.CommitBlock(params.AllEthashProtocolChanges.Rules(lastBlock), writer))
+	executionRoot := writer.Root()
+
+	// Root matches the one built from the changesets
+	applyTestBlock(t, tx, lastBlock)
+	require.Equal(t, runTransition(t, tx), executionRoot)
+
+	flat := state.NewPlainStateReader(tx)
+	expectedAcc, expectedCode, expectedStorage, _ := readTestState(t, flat, lastBlock)
+	reader := verkledb.NewStateReader(tree.Node(), tree.Resolve).WithIncarnations(flat)
+	acc, code, storage, absent := readTestState(t, reader, lastBlock)
+	require.Equal(t, expectedAcc.Balance, acc.Balance)
+	require.Equal(t, expectedAcc.CodeHash, acc.CodeHash)
+	require.Equal(t, expectedAcc.Incarnation, acc.Incarnation)
+	require.Equal(t, expectedCode, code)
+	require.Equal(t, expectedStorage, storage)
+	require.Nil(t, absent)
+
+	// Deleted account is gone together with its code
+	require.NoError(t, writer.DeleteAccount(testContract, acc))
+	acc, err := reader.ReadAccountData(testContract)
+	require.NoError(t, err)
+	require.Nil(t, acc)
+	chunk, err := tree.Get(vtree.GetTreeKeyCodeChunk(testContract[:], uint256.NewInt(0)))
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, 32), chunk)
+}
+
+func TestVerkleStateWriterSelfdestruct(t *testing.T) {
+	const lastBlock = 3
+
+	prepare := func() (kv.RwTx, common.Hash) {
+		_, tx := memdb.NewTestTx(t)
+		require.NoError(t, verkledb.InitDB(tx))
+		for blockNum := uint64(1); blockNum < lastBlock; blockNum++ {
+			applyTestBlock(t, tx, blockNum)
+		}
+		return tx, runTransition(t, tx)
+	}
+
+	// The contract selfdestructs in the last block, executed directly on the tree
+	tx, parentRoot := prepare()
+	tree := openTestTree(t, tx, parentRoot)
+	ibs := state.New(verkledb.NewStateReader(tree.Node(), tree.Resolve))
+	require.True(t, ibs.Suicide(testContract))
+	writer := state.NewVerkleStateWriter(tree.Node(), tree.Resolve).WithLookups(verkledb.NewTreeKeys(tx, true))
+	require.NoError(t, ibs.CommitBlock(params.AllEthashProtocolChanges.Rules(lastBlock), writer))
+	executionRoot := writer.Root()
+	for blockNum := uint64(1); blockNum < lastBlock; blockNum++ {
+		slot := vtree.GetTreeKeyStorageSlot(testContract[:], uint256.NewInt(blockNum))
+		value, err := tree.Get(slot)
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, 32), value, "slot %d", blockNum)
+	}
+	chunk, err := tree.Get(vtree.GetTreeKeyCodeChunk(testContract[:], uint256.NewInt(0)))
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, 32), chunk)
+
+	// Same block built from the changesets, PlainState keeps the storage of the deleted incarnation
+	transitionTx, _ := prepare()
+	prev, err := transitionTx.GetOne(kv.PlainState, testContract[:])
+	require.NoError(t, err)
+	require.NoError(t, transitionTx.Put(kv.AccountChangeSet, dbutils.EncodeBlockNumber(lastBlock), append(common.CopyBytes(testContract[:]), prev...)))
+	require.NoError(t, transitionTx.Delete(kv.PlainState, testContract[:]))
+	require.NoError(t, stages.SaveStageProgress(transitionTx, stages.Execution, lastBlock))
+	require.Equal(t, runTransition(t, transitionTx), executionRoot)
+}
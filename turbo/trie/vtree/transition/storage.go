@@ -1,4 +1,4 @@
-package verkle
+package transition
 
 import (
 	"encoding/binary"
@@ -8,7 +8,6 @@ import (
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	verkledb "github.com/ledgerwatch/erigon/cmd/verkle/verkle-db"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
@@ -16,9 +15,10 @@ import (
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree/verkledb"
 )
 
-func ProcessStorage(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64, prevRoot common.Hash) (common.Hash, error) {
+func ProcessStorage(coreTx kv.Tx, tx kv.RwTx, writer *verkledb.VerkleTree, from uint64) (common.Hash, error) {
 	logInterval := time.NewTicker(180 * time.Second)
 
 	storageCursor, err := coreTx.CursorDupSort(kv.StorageChangeSet)
@@ -33,6 +33,7 @@ func ProcessStorage(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64, p
 
 	marker := verkledb.NewVerkleMarker(executionProgress != from+1)
 	defer marker.Rollback()
+	keys := verkledb.NewTreeKeys(tx, true)
 
 	start := uint64(0)
 	if from != 0 {
@@ -87,12 +88,12 @@ func ProcessStorage(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64, p
 			}
 		} else {
 			var val [32]byte
-			verkledb.Int256ToVerkleFormat(storageSlot, val[:])
-			key := vtree.GetTreeKeyStorageSlot(chKey[:20], storageSlot)
-			if err := writer.Insert(key, val[:]); err != nil {
+			vtree.Int256ToVerkleFormat(new(uint256.Int).SetBytes(storageValue), val[:])
+			key, err := keys.StorageKey(chKey[:20], storageSlot)
+			if err != nil {
 				return common.Hash{}, err
 			}
-			if err := verkledb.WritePedersenStorageLookup(tx, chKey[:20], storageSlot, key); err != nil {
+			if err := writer.Insert(key, val[:]); err != nil {
 				return common.Hash{}, err
 			}
 		}
@@ -105,10 +106,12 @@ func ProcessStorage(coreTx kv.Tx, tx kv.RwTx, writer *VerkleTree, from uint64, p
 			return common.Hash{}, err
 		}
 	}
-	root, err := writer.CommitVerkleTree(prevRoot)
+	root, err := writer.Commit()
 	if err != nil {
 		return common.Hash{}, err
 	}
-	stages.SaveStageProgress(tx, stages.VerkleTrie, blockNum)
+	if err := stages.SaveStageProgress(tx, stages.VerkleTrie, executionProgress); err != nil {
+		return common.Hash{}, err
+	}
 	return root, verkledb.WriteVerkleRoot(tx, executionProgress, root)
 }
@@ -0,0 +1,76 @@
+package vtree
+
+import (
+	"github.com/VictoriaMetrics/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/holiman/uint256"
+)
+
+const (
+	// StemLength is the length of the part of a tree key shared by the 256 leaves of a stem
+	StemLength = 31
+	// DefaultTreeKeyCacheSize is the number of stems kept by the shared cache, about 16MB
+	DefaultTreeKeyCacheSize = 100_000
+)
+
+var (
+	treeKeyCacheHits   = metrics.GetOrCreateCounter(`verkle_treekey_cache{result="hit"}`)
+	treeKeyCacheMisses = metrics.GetOrCreateCounter(`verkle_treekey_cache{result="miss"}`)
+
+	// stemCache is shared by everything deriving tree keys, it is safe for concurrent use
+	stemCache *lru.Cache
+)
+
+func init() {
+	var err error
+	if stemCache, err = lru.New(DefaultTreeKeyCacheSize); err != nil {
+		panic(err)
+	}
+}
+
+// stemCacheKey is the 32-byte aligned address followed by the 32-byte tree index
+type stemCacheKey [64]byte
+
+func newStemCacheKey(address []byte, treeIndex *uint256.Int) stemCacheKey {
+	var key stemCacheKey
+	copy(key[32-len(address):32], address)
+	treeIndex.WriteToSlice(key[32:])
+	return key
+}
+
+// GetTreeStem returns the stem of (address, treeIndex), it is only computed when it is not in the shared cache.
+// The returned slice is shared and must not be modified.
+func GetTreeStem(address []byte, treeIndex *uint256.Int) []byte {
+	if stem, ok := CachedTreeStem(address, treeIndex); ok {
+		return stem
+	}
+	stem := ComputeTreeStem(address, treeIndex)
+	AddTreeStem(address, treeIndex, stem)
+	return stem
+}
+
+// CachedTreeStem looks the stem of (address, treeIndex) up in the shared cache
+func CachedTreeStem(address []byte, treeIndex *uint256.Int) ([]byte, bool) {
+	stem, ok := stemCache.Get(newStemCacheKey(address, treeIndex))
+	if !ok {
+		treeKeyCacheMisses.Inc()
+		return nil, false
+	}
+	treeKeyCacheHits.Inc()
+	return stem.([]byte), true
+}
+
+// AddTreeStem puts a stem in the shared cache, e.g. one read from the persisted stem index
+func AddTreeStem(address []byte, treeIndex *uint256.Int, stem []byte) {
+	stemCache.Add(newStemCacheKey(address, treeIndex), stem[:StemLength:StemLength])
+}
+
+// SetTreeKeyCacheSize changes the number of stems kept by the shared cache
+func SetTreeKeyCacheSize(size int) {
+	stemCache.Resize(size)
+}
+
+// TreeKeyCacheStats returns the hits and misses of the shared cache since the start of the process
+func TreeKeyCacheStats() (hits, misses uint64) {
+	return treeKeyCacheHits.Get(), treeKeyCacheMisses.Get()
+}
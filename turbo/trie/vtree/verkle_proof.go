@@ -0,0 +1,47 @@
+package vtree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/gballet/go-verkle"
+)
+
+// MakeVerkleProof creates a serialized multiproof for the given keys along with the proven key/values.
+// Nodes on the path to every key are resolved with `resolver` first, because hashed nodes can't produce proof items.
+// Values of absent keys are nil.
+func MakeVerkleProof(root verkle.VerkleNode, keys [][]byte, resolver verkle.NodeResolverFn) ([]byte, []verkle.KeyValuePair, error) {
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("no keys provided for verkle proof")
+	}
+	sorted := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, nil, fmt.Errorf("invalid verkle key length %d", len(key))
+		}
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	unique := sorted[:1]
+	for _, key := range sorted[1:] {
+		if !bytes.Equal(key, unique[len(unique)-1]) {
+			unique = append(unique, key)
+		}
+	}
+
+	keyVals := make(map[string][]byte, len(unique))
+	for _, key := range unique {
+		value, err := root.Get(key, resolver)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolve verkle key %x: %w", key, err)
+		}
+		keyVals[string(key)] = value
+	}
+
+	proof, _, _, _, err := verkle.MakeVerkleMultiProof(root, unique, keyVals)
+	if err != nil {
+		return nil, nil, err
+	}
+	return verkle.SerializeProof(proof)
+}
@@ -0,0 +1,60 @@
+package vtree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gballet/go-verkle"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeVerkleProof(t *testing.T) {
+	addr1 := bytes.Repeat([]byte{0x01}, 32)
+	addr2 := bytes.Repeat([]byte{0x02}, 32)
+	absent := bytes.Repeat([]byte{0x03}, 32)
+
+	root := verkle.New()
+	var value [32]byte
+	value[0] = 0x2a
+	require.NoError(t, root.Insert(GetTreeKeyBalance(addr1), value[:], nil))
+	require.NoError(t, root.Insert(GetTreeKeyNonce(addr1), value[:], nil))
+	require.NoError(t, root.Insert(GetTreeKeyBalance(addr2), value[:], nil))
+	rootC := root.ComputeCommitment()
+
+	// Flush the tree and read it back from its root only, so that the proof has to resolve nodes
+	nodes := map[string][]byte{}
+	root.(*verkle.InternalNode).Flush(func(node verkle.VerkleNode) {
+		serialized, err := node.Serialize()
+		require.NoError(t, err)
+		comm := node.ComputeCommitment().Bytes()
+		nodes[string(comm[:])] = serialized
+	})
+	rootBytes := rootC.Bytes()
+	resolver := func(key []byte) ([]byte, error) {
+		return nodes[string(key)], nil
+	}
+	parsed, err := verkle.ParseNode(nodes[string(rootBytes[:])], 0, rootBytes[:])
+	require.NoError(t, err)
+
+	keys := [][]byte{GetTreeKeyBalance(addr1), GetTreeKeyNonce(addr1), GetTreeKeyBalance(addr1), GetTreeKeyBalance(absent)}
+	proof, keyVals, err := MakeVerkleProof(parsed, keys, resolver)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(keyVals))
+	for _, kv := range keyVals {
+		if bytes.Equal(kv.Key, GetTreeKeyBalance(absent)) {
+			require.Nil(t, kv.Value)
+		} else {
+			require.Equal(t, value[:], kv.Value)
+		}
+	}
+
+	deserialized, err := verkle.DeserializeProof(proof, keyVals)
+	require.NoError(t, err)
+	stateless, err := verkle.TreeFromProof(deserialized, rootC)
+	require.NoError(t, err)
+	statelessC := stateless.ComputeCommitment().Bytes()
+	require.Equal(t, rootBytes, statelessC)
+
+	_, _, err = MakeVerkleProof(parsed, nil, resolver)
+	require.Error(t, err)
+}
@@ -33,12 +33,21 @@ func init() {
 	}
 }
 
-// GetTreeKey performs both the work of the spec's get_tree_key function, and that
+// GetTreeKey returns the tree key of the leaf `subIndex` under the stem of (address, treeIndex).
+// Stems are taken from the shared cache when possible.
+func GetTreeKey(address []byte, treeIndex *uint256.Int, subIndex byte) []byte {
+	key := make([]byte, 32)
+	copy(key, GetTreeStem(address, treeIndex))
+	key[31] = subIndex
+	return key
+}
+
+// ComputeTreeStem performs both the work of the spec's get_tree_key function, and that
 // of pedersen_hash: it builds the polynomial in pedersen_hash without having to
 // create a mostly zero-filled buffer and "type cast" it to a 128-long 16-byte
 // array. Since at most the first 5 coefficients of the polynomial will be non-zero,
-// these 5 coefficients are created directly.
-func GetTreeKey(address []byte, treeIndex *uint256.Int, subIndex byte) []byte {
+// these 5 coefficients are created directly. The stem is the key without its last byte.
+func ComputeTreeStem(address []byte, treeIndex *uint256.Int) []byte {
 	if len(address) < 32 {
 		var aligned [32]byte
 		address = append(aligned[:32-len(address)], address...)
@@ -66,8 +75,7 @@ func GetTreeKey(address []byte, treeIndex *uint256.Int, subIndex byte) []byte {
 	// add a constant point
 	ret.Add(ret, getTreePolyIndex0Point)
 
-	return PointToHash(ret, subIndex)
-
+	return PointToHash(ret, 0)[:StemLength]
 }
 
 func GetTreeKeyAccountLeaf(address []byte, leaf byte) []byte {
@@ -95,6 +103,12 @@ func GetTreeKeyCodeSize(address []byte) []byte {
 }
 
 func GetTreeKeyCodeChunk(address []byte, chunk *uint256.Int) []byte {
+	treeIndex, subIndex := CodeChunkTreeIndex(chunk)
+	return GetTreeKey(address, treeIndex, subIndex)
+}
+
+// CodeChunkTreeIndex returns the stem index and the position in the stem of a code chunk
+func CodeChunkTreeIndex(chunk *uint256.Int) (*uint256.Int, byte) {
 	chunkOffset := new(uint256.Int).Add(CodeOffset, chunk)
 	treeIndex := new(uint256.Int).Div(chunkOffset, VerkleNodeWidth)
 	subIndexMod := new(uint256.Int).Mod(chunkOffset, VerkleNodeWidth).Bytes()
@@ -102,10 +116,33 @@ func GetTreeKeyCodeChunk(address []byte, chunk *uint256.Int) []byte {
 	if len(subIndexMod) != 0 {
 		subIndex = subIndexMod[0]
 	}
-	return GetTreeKey(address, treeIndex, subIndex)
+	return treeIndex, subIndex
+}
+
+// GetTreeKeyCodeChunks returns the keys of the first `count` code chunks. Chunks sharing a stem only differ
+// in the last byte, so the key is hashed once per stem.
+func GetTreeKeyCodeChunks(address []byte, count int) [][]byte {
+	keys := make([][]byte, count)
+	var stemKey []byte
+	for i := range keys {
+		offset := uint64(i) + CodeOffset.Uint64()
+		if stemKey == nil || offset%VerkleNodeWidth.Uint64() == 0 {
+			stemKey = GetTreeKeyCodeChunk(address, uint256.NewInt(uint64(i)))
+		}
+		keys[i] = make([]byte, 32)
+		copy(keys[i], stemKey[:31])
+		keys[i][31] = byte(offset % VerkleNodeWidth.Uint64())
+	}
+	return keys
 }
 
 func GetTreeKeyStorageSlot(address []byte, storageKey *uint256.Int) []byte {
+	treeIndex, subIndex := StorageSlotTreeIndex(storageKey)
+	return GetTreeKey(address, treeIndex, subIndex)
+}
+
+// StorageSlotTreeIndex returns the stem index and the position in the stem of a storage slot
+func StorageSlotTreeIndex(storageKey *uint256.Int) (*uint256.Int, byte) {
 	pos := storageKey.Clone()
 	if storageKey.Cmp(codeStorageDelta) < 0 {
 		pos.Add(HeaderStorageOffset, storageKey)
@@ -124,7 +161,26 @@ func GetTreeKeyStorageSlot(address []byte, storageKey *uint256.Int) []byte {
 		// significant byte.
 		subIndex = subIndexMod[0] & 0xFF
 	}
-	return GetTreeKey(address, treeIndex, subIndex)
+	return treeIndex, subIndex
+}
+
+// GetTreeKeyAccountLeaves returns the keys of all header leaves of an account, indexed by leaf, given its version key
+func GetTreeKeyAccountLeaves(versionKey []byte) [][]byte {
+	keys := make([][]byte, CodeSizeLeafKey+1)
+	for leaf := range keys {
+		keys[leaf] = make([]byte, 32)
+		copy(keys[leaf], versionKey[:31])
+		keys[leaf][31] = byte(leaf)
+	}
+	return keys
+}
+
+// Int256ToVerkleFormat writes x into buffer as a little endian number, which is how numbers are stored in leaves
+func Int256ToVerkleFormat(x *uint256.Int, buffer []byte) {
+	bbytes := x.ToBig().Bytes()
+	for i, b := range bbytes {
+		buffer[len(bbytes)-i-1] = b
+	}
 }
 
 func PointToHash(evaluated *verkle.Point, suffix byte) []byte {
@@ -1,10 +1,13 @@
 package vtree
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"math/big"
 	"math/rand"
 	"testing"
+
+	"github.com/holiman/uint256"
 )
 
 func BenchmarkPedersenHash(b *testing.B) {
@@ -44,3 +47,40 @@ func BenchmarkSha256Hash(b *testing.B) {
 		sha256GetTreeKeyCodeSize(addr[:])
 	}
 }
+
+func TestGetTreeKeyCodeChunks(t *testing.T) {
+	addr := bytes.Repeat([]byte{0x42}, 20)
+	keys := GetTreeKeyCodeChunks(addr, 400)
+	for i, key := range keys {
+		if expected := GetTreeKeyCodeChunk(addr, uint256.NewInt(uint64(i))); !bytes.Equal(key, expected) {
+			t.Fatalf("chunk %d: got %x, want %x", i, key, expected)
+		}
+	}
+}
+
+func TestTreeKeyCache(t *testing.T) {
+	addr := bytes.Repeat([]byte{0x24}, 20)
+	slot := uint256.NewInt(1000)
+	treeIndex, subIndex := StorageSlotTreeIndex(slot)
+	expected := append(ComputeTreeStem(addr, treeIndex), subIndex)
+
+	hits, misses := TreeKeyCacheStats()
+	if key := GetTreeKeyStorageSlot(addr, slot); !bytes.Equal(key, expected) {
+		t.Fatalf("got %x, want %x", key, expected)
+	}
+	// Second derivation of the same stem is served by the cache
+	key := GetTreeKeyStorageSlot(addr, slot)
+	if !bytes.Equal(key, expected) {
+		t.Fatalf("cached: got %x, want %x", key, expected)
+	}
+	newHits, newMisses := TreeKeyCacheStats()
+	if newHits != hits+1 || newMisses != misses+1 {
+		t.Fatalf("hits %d -> %d, misses %d -> %d", hits, newHits, misses, newMisses)
+	}
+
+	// Keys handed out are copies of the cached stem
+	key[0]++
+	if key := GetTreeKeyStorageSlot(addr, slot); !bytes.Equal(key, expected) {
+		t.Fatalf("modified: got %x, want %x", key, expected)
+	}
+}
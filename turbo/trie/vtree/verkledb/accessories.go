@@ -0,0 +1,168 @@
+package verkledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/log/v3"
+)
+
+func ReadVerkleIncarnation(tx kv.Tx, address common.Address) (uint64, error) {
+	inc, err := tx.GetOne(VerkleIncarnation, address[:])
+	if err != nil {
+		return 0, err
+	}
+	if len(inc) == 0 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(inc), nil
+}
+
+func WriteVerkleIncarnation(tx kv.RwTx, address common.Address, incarnation uint64) error {
+	var inc [8]byte
+	binary.BigEndian.PutUint64(inc[:], incarnation)
+	return tx.Put(VerkleIncarnation, address[:], inc[:])
+}
+
+// ReadVerkleRoot returns the verkle root committed at the given block, empty hash means no tree has been built yet
+func ReadVerkleRoot(tx kv.Getter, blockNum uint64) (common.Hash, error) {
+	root, err := tx.GetOne(VerkleRoots, dbutils.EncodeBlockNumber(blockNum))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(root), nil
+}
+
+// ReadVerkleRootByHash returns the verkle root of a canonical block
+func ReadVerkleRootByHash(tx kv.Getter, blockHash common.Hash) (common.Hash, error) {
+	blockNum := rawdb.ReadHeaderNumber(tx, blockHash)
+	if blockNum == nil {
+		return common.Hash{}, fmt.Errorf("block %x not found", blockHash)
+	}
+	canonicalHash, err := rawdb.ReadCanonicalHash(tx, *blockNum)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if canonicalHash != blockHash {
+		return common.Hash{}, fmt.Errorf("block %x is not canonical", blockHash)
+	}
+	return ReadVerkleRoot(tx, *blockNum)
+}
+
+// ReadLastVerkleRoot returns the last verkle root committed at or before the given block, and the block it was committed at.
+// Empty hash means there is none.
+func ReadLastVerkleRoot(tx kv.Tx, blockNum uint64) (uint64, common.Hash, error) {
+	c, err := tx.Cursor(VerkleRoots)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	defer c.Close()
+	k, v, err := c.Seek(dbutils.EncodeBlockNumber(blockNum + 1))
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	if k == nil {
+		k, v, err = c.Last()
+	} else {
+		k, v, err = c.Prev()
+	}
+	if err != nil || k == nil {
+		return 0, common.Hash{}, err
+	}
+	return binary.BigEndian.Uint64(k), common.BytesToHash(v), nil
+}
+
+func WriteVerkleRoot(tx kv.RwTx, blockNum uint64, root common.Hash) error {
+	log.Debug("Write Verkle root", "num", blockNum, "root", root)
+
+	return tx.Put(VerkleRoots, dbutils.EncodeBlockNumber(blockNum), root[:])
+}
+
+// PruneVerkleRoots deletes roots of all blocks before pruneTo
+func PruneVerkleRoots(tx kv.RwTx, pruneTo uint64) error {
+	c, err := tx.RwCursor(VerkleRoots)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.First(); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint64(k) >= pruneTo {
+			break
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TruncateVerkleRoots deletes roots of all blocks starting from `from`
+func TruncateVerkleRoots(tx kv.RwTx, from uint64) error {
+	c, err := tx.RwCursor(VerkleRoots)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(dbutils.EncodeBlockNumber(from)); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WritePedersenStorageLookup(tx kv.RwTx, addr []byte, storageKey *uint256.Int, treeKey []byte) error {
+	return tx.Put(PedersenHashedStorageLookup, PedersenStorageLookupKey(addr, storageKey), treeKey)
+}
+
+func WritePedersenCodeLookup(tx kv.RwTx, addr []byte, i uint32, treeKey []byte) error {
+	return tx.Put(PedersenHashedCodeLookup, PedersenCodeLookupKey(addr, i), treeKey)
+}
+
+func DeletePedersenStorageLookup(tx kv.RwTx, addr []byte, storageKey *uint256.Int) error {
+	return tx.Delete(PedersenHashedStorageLookup, PedersenStorageLookupKey(addr, storageKey))
+}
+
+// DeletePedersenLookups deletes all lookups of the given address from a Pedersen*Lookup bucket
+func DeletePedersenLookups(tx kv.RwTx, bucket string, addr []byte) error {
+	c, err := tx.RwCursor(bucket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(addr); k != nil && bytes.HasPrefix(k, addr); k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPedersenLookups returns the tree keys of all storage slots and code chunks of the address
+func ReadPedersenLookups(tx kv.Tx, address common.Address) ([][]byte, error) {
+	var treeKeys [][]byte
+	for _, bucket := range []string{PedersenHashedStorageLookup, PedersenHashedCodeLookup} {
+		if err := tx.ForPrefix(bucket, address[:], func(_, treeKey []byte) error {
+			treeKeys = append(treeKeys, common.CopyBytes(treeKey))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return treeKeys, nil
+}
@@ -0,0 +1,76 @@
+package verkledb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerkleRoots(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(tx))
+
+	root, err := ReadVerkleRoot(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, root)
+
+	for i := uint64(1); i <= 5; i++ {
+		blockHash := common.BigToHash(big.NewInt(int64(i)))
+		require.NoError(t, rawdb.WriteHeaderNumber(tx, blockHash, i))
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, blockHash, i))
+		require.NoError(t, WriteVerkleRoot(tx, i, common.BigToHash(big.NewInt(int64(100+i)))))
+	}
+
+	for i := uint64(1); i <= 5; i++ {
+		root, err = ReadVerkleRoot(tx, i)
+		require.NoError(t, err)
+		require.Equal(t, common.BigToHash(big.NewInt(int64(100+i))), root)
+	}
+
+	root, err = ReadVerkleRootByHash(tx, common.BigToHash(big.NewInt(3)))
+	require.NoError(t, err)
+	require.Equal(t, common.BigToHash(big.NewInt(103)), root)
+
+	// non-canonical block
+	forkHash := common.HexToHash("0xff")
+	require.NoError(t, rawdb.WriteHeaderNumber(tx, forkHash, 3))
+	_, err = ReadVerkleRootByHash(tx, forkHash)
+	require.Error(t, err)
+	_, err = ReadVerkleRootByHash(tx, common.HexToHash("0xee"))
+	require.Error(t, err)
+
+	blockNum, root, err := ReadLastVerkleRoot(tx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), blockNum)
+	require.Equal(t, common.BigToHash(big.NewInt(103)), root)
+	blockNum, root, err = ReadLastVerkleRoot(tx, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), blockNum)
+	require.Equal(t, common.BigToHash(big.NewInt(105)), root)
+	_, root, err = ReadLastVerkleRoot(tx, 0)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, root)
+
+	require.NoError(t, PruneVerkleRoots(tx, 4))
+	for i := uint64(1); i <= 5; i++ {
+		root, err = ReadVerkleRoot(tx, i)
+		require.NoError(t, err)
+		if i < 4 {
+			require.Equal(t, common.Hash{}, root)
+		} else {
+			require.Equal(t, common.BigToHash(big.NewInt(int64(100+i))), root)
+		}
+	}
+
+	require.NoError(t, TruncateVerkleRoots(tx, 5))
+	root, err = ReadVerkleRoot(tx, 4)
+	require.NoError(t, err)
+	require.Equal(t, common.BigToHash(big.NewInt(104)), root)
+	root, err = ReadVerkleRoot(tx, 5)
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, root)
+}
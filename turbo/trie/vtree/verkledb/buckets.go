@@ -0,0 +1,78 @@
+package verkledb
+
+import (
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	VerkleIncarnation            = "VerkleIncarnation"
+	VerkleRoots                  = "VerkleRoots"
+	PedersenHashedCodeLookup     = "PedersenHashedCodeLookup"
+	PedersenHashedAccountsLookup = "PedersenHashedAccountsLookup"
+	PedersenHashedStorageLookup  = "PedersenHashedStorageLookup"
+	VerkleTrie                   = "VerkleTrie"
+	// VerkleConversion keeps the progress of the gradual conversion of the flat state, block number -> ConversionProgress
+	VerkleConversion = "VerkleConversion"
+	// VerkleStemIndex keeps the stems derived so far, address + tree index -> stem
+	VerkleStemIndex = "VerkleStemIndex"
+)
+
+var ExtraBuckets = []string{
+	VerkleIncarnation,
+	PedersenHashedCodeLookup,
+	PedersenHashedAccountsLookup,
+	PedersenHashedStorageLookup,
+	VerkleTrie,
+	VerkleRoots,
+	VerkleConversion,
+	VerkleStemIndex,
+}
+
+// ChaindataTablesCfg adds the verkle buckets to the chaindata tables, for the
+// WithTableCfg of the databases that hold the verkle tree next to the state.
+func ChaindataTablesCfg(defaultBuckets kv.TableCfg) kv.TableCfg {
+	cfg := make(kv.TableCfg, len(defaultBuckets)+len(ExtraBuckets))
+	for name, item := range defaultBuckets {
+		cfg[name] = item
+	}
+	for _, b := range ExtraBuckets {
+		if _, ok := cfg[b]; !ok {
+			cfg[b] = kv.TableCfgItem{}
+		}
+	}
+	return cfg
+}
+
+// Open opens the chaindata at path with the verkle buckets, like mdbx.Open.
+func Open(path string, logger log.Logger, readOnly bool) (kv.RwDB, error) {
+	opts := mdbx.NewMDBX(logger).Path(path).WithTableCfg(ChaindataTablesCfg)
+	if readOnly {
+		opts = opts.Readonly()
+	}
+	return opts.Open()
+}
+
+// MustOpen opens the chaindata at path with the verkle buckets, like mdbx.MustOpen.
+func MustOpen(path string) kv.RwDB {
+	db, err := Open(path, log.New(), false)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// NewMemDB creates an in-memory chaindata with the verkle buckets, like memdb.New.
+func NewMemDB() kv.RwDB {
+	return mdbx.NewMDBX(log.New()).InMem().WithTableCfg(ChaindataTablesCfg).MustOpen()
+}
+
+func InitDB(tx kv.RwTx) error {
+	for _, b := range ExtraBuckets {
+		if err := tx.CreateBucket(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package verkledb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+)
+
+// ConversionProgress is how far the flat state of the fork block has been moved into the verkle tree.
+// Leaves are moved in PlainState order, so everything up to and including Cursor is converted.
+type ConversionProgress struct {
+	Cursor []byte // PlainState key of the last converted account or storage slot, nil when nothing is converted yet
+	Done   bool
+}
+
+// Converted tells whether the PlainState key has been moved into the tree
+func (p ConversionProgress) Converted(plainKey []byte) bool {
+	return p.Done || (p.Cursor != nil && bytes.Compare(plainKey, p.Cursor) <= 0)
+}
+
+// ReadConversionProgress returns the conversion progress after the given block.
+// Progress is only written while the conversion goes on, later blocks share the progress of the block that completed it.
+func ReadConversionProgress(tx kv.Tx, blockNum uint64) (ConversionProgress, error) {
+	c, err := tx.Cursor(VerkleConversion)
+	if err != nil {
+		return ConversionProgress{}, err
+	}
+	defer c.Close()
+	k, v, err := c.Seek(dbutils.EncodeBlockNumber(blockNum))
+	if err != nil {
+		return ConversionProgress{}, err
+	}
+	switch {
+	case k == nil:
+		k, v, err = c.Last()
+	case binary.BigEndian.Uint64(k) != blockNum:
+		k, v, err = c.Prev()
+	}
+	if err != nil {
+		return ConversionProgress{}, err
+	}
+	// Blocks before the conversion, and blocks it has not reached yet, have nothing converted
+	if k == nil || len(v) == 0 || (v[0] == 0 && binary.BigEndian.Uint64(k) != blockNum) {
+		return ConversionProgress{}, nil
+	}
+	return ConversionProgress{
+		Done:   v[0] == 1,
+		Cursor: common.CopyBytes(v[1:]),
+	}, nil
+}
+
+func WriteConversionProgress(tx kv.RwTx, blockNum uint64, progress ConversionProgress) error {
+	v := make([]byte, 1+len(progress.Cursor))
+	if progress.Done {
+		v[0] = 1
+	}
+	copy(v[1:], progress.Cursor)
+	return tx.Put(VerkleConversion, dbutils.EncodeBlockNumber(blockNum), v)
+}
+
+// TruncateConversionProgress deletes the progress of all blocks starting from `from`
+func TruncateConversionProgress(tx kv.RwTx, from uint64) error {
+	c, err := tx.RwCursor(VerkleConversion)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(dbutils.EncodeBlockNumber(from)); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneConversionProgress deletes the progress of blocks before pruneTo, the block which completed the conversion is kept
+func PruneConversionProgress(tx kv.RwTx, pruneTo uint64) error {
+	c, err := tx.RwCursor(VerkleConversion)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint64(k) >= pruneTo {
+			break
+		}
+		if len(v) > 0 && v[0] == 1 {
+			continue
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package verkledb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gballet/go-verkle"
+	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// WalkLeafNodes calls walker with the encoding of every leaf node of the tree with the given root, ordered by stem.
+// The encoding is only valid during the call.
+func WalkLeafNodes(db kv.Getter, root common.Hash, walker func(encoded []byte) error) error {
+	if root == (common.Hash{}) {
+		return nil
+	}
+	return walkLeafNodes(db, root[:], 0, walker)
+}
+
+func walkLeafNodes(db kv.Getter, commitment []byte, depth byte, walker func(encoded []byte) error) error {
+	encoded, err := db.GetOne(VerkleTrie, commitment)
+	if err != nil {
+		return err
+	}
+	if len(encoded) == 0 {
+		return fmt.Errorf("verkle node %x not found", commitment)
+	}
+	node, err := verkle.ParseNode(encoded, depth, commitment)
+	if err != nil {
+		return fmt.Errorf("parse verkle node %x: %w", commitment, err)
+	}
+	switch n := node.(type) {
+	case *verkle.LeafNode:
+		return walker(encoded)
+	case *verkle.InternalNode:
+		// Children are visited in order, which is the order of the stems
+		for _, child := range n.Children() {
+			if _, ok := child.(verkle.Empty); ok {
+				continue
+			}
+			childCommitment := child.ComputeCommitment().Bytes()
+			if err := walkLeafNodes(db, childCommitment[:], depth+1, walker); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected verkle node type %T", node)
+	}
+}
+
+// ReadLeafNode returns the leaf node of the stem in the tree with the given root, nil if the stem is not in the tree
+func ReadLeafNode(db kv.Getter, root common.Hash, stem []byte) (*verkle.LeafNode, error) {
+	if len(stem) != 31 {
+		return nil, fmt.Errorf("invalid verkle stem length %d", len(stem))
+	}
+	if root == (common.Hash{}) {
+		return nil, nil
+	}
+	commitment := root[:]
+	for depth := byte(0); int(depth) < len(stem); depth++ {
+		encoded, err := db.GetOne(VerkleTrie, commitment)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) == 0 {
+			return nil, fmt.Errorf("verkle node %x not found", commitment)
+		}
+		node, err := verkle.ParseNode(encoded, depth, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("parse verkle node %x: %w", commitment, err)
+		}
+		switch n := node.(type) {
+		case *verkle.LeafNode:
+			// The path only tells the first bytes of the stem apart
+			if !bytes.Equal(n.Key(0)[:31], stem) {
+				return nil, nil
+			}
+			return n, nil
+		case *verkle.InternalNode:
+			child := n.Children()[stem[depth]]
+			if _, ok := child.(verkle.Empty); ok {
+				return nil, nil
+			}
+			childCommitment := child.ComputeCommitment().Bytes()
+			commitment = childCommitment[:]
+		default:
+			return nil, fmt.Errorf("unexpected verkle node type %T", node)
+		}
+	}
+	return nil, fmt.Errorf("verkle stem %x is deeper than the tree", stem)
+}
+
+// ProveStems makes a multiproof of the values of the leaves of the stems in the tree with the given root.
+// Absent stems are proven by their first key, the proof is nil if the tree is not in the database.
+func ProveStems(db kv.Getter, root common.Hash, stems [][]byte) ([]byte, []verkle.KeyValuePair, error) {
+	if len(stems) == 0 || root == (common.Hash{}) {
+		return nil, nil, nil
+	}
+	encodedRoot, err := db.GetOne(VerkleTrie, root[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(encodedRoot) == 0 {
+		return nil, nil, nil
+	}
+	rootNode, err := verkle.ParseNode(encodedRoot, 0, root[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse verkle root %x: %w", root, err)
+	}
+	var keys [][]byte
+	for _, stem := range stems {
+		leaf, err := ReadLeafNode(db, root, stem)
+		if err != nil {
+			return nil, nil, err
+		}
+		if leaf == nil {
+			keys = append(keys, append(common.CopyBytes(stem), 0))
+			continue
+		}
+		for i := 0; i < verkle.NodeWidth; i++ {
+			if leaf.Value(i) != nil {
+				keys = append(keys, leaf.Key(i))
+			}
+		}
+	}
+	return vtree.MakeVerkleProof(rootNode, keys, func(key []byte) ([]byte, error) {
+		return db.GetOne(VerkleTrie, key)
+	})
+}
+
+// LeafNodeLoader builds a new tree out of encoded leaf nodes, which have to be added ordered by stem as WalkLeafNodes returns them.
+// Completed subtrees are sorted in tmpdir, nodes of other trees in the database are left untouched.
+type LeafNodeLoader struct {
+	root        *verkle.InternalNode
+	collector   *etl.Collector
+	lastStem    []byte
+	leaves      uint64
+	logInterval *time.Ticker
+}
+
+func NewLeafNodeLoader(tmpdir string) *LeafNodeLoader {
+	return &LeafNodeLoader{
+		root:        verkle.New().(*verkle.InternalNode),
+		collector:   etl.NewCollector(VerkleTrie, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize)),
+		logInterval: time.NewTicker(30 * time.Second),
+	}
+}
+
+func (l *LeafNodeLoader) Close() {
+	l.collector.Close()
+	l.logInterval.Stop()
+}
+
+// Add inserts an encoded leaf node, the encoding is copied
+func (l *LeafNodeLoader) Add(encoded []byte) error {
+	node, err := verkle.ParseNode(common.CopyBytes(encoded), 0, nil)
+	if err != nil {
+		return err
+	}
+	leaf, ok := node.(*verkle.LeafNode)
+	if !ok {
+		return fmt.Errorf("expected a verkle leaf node, got %T", node)
+	}
+	stem := leaf.Key(0)[:31]
+	if l.lastStem != nil && bytes.Compare(stem, l.lastStem) <= 0 {
+		return fmt.Errorf("verkle leaf node %x is out of order, after %x", stem, l.lastStem)
+	}
+	l.lastStem = stem
+
+	// Flush callback can't return an error
+	var flushErr error
+	if err := l.root.InsertStemOrdered(stem, leaf, func(node verkle.VerkleNode) {
+		if flushErr != nil {
+			return
+		}
+		commitment := node.ComputeCommitment().Bytes()
+		encodedNode, err := node.Serialize()
+		if err != nil {
+			flushErr = err
+			return
+		}
+		flushErr = l.collector.Collect(commitment[:], encodedNode)
+	}); err != nil {
+		return err
+	}
+	l.leaves++
+	select {
+	case <-l.logInterval.C:
+		log.Info("[Verkle] Loading leaf nodes", "leaves", l.leaves, "stem", common.Bytes2Hex(stem))
+	default:
+	}
+	return flushErr
+}
+
+// Commit writes the nodes of the tree to the database and returns its root
+func (l *LeafNodeLoader) Commit(db kv.RwTx) (common.Hash, error) {
+	if err := l.collector.Load(db, VerkleTrie, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done()}); err != nil {
+		return common.Hash{}, err
+	}
+	// InsertStemOrdered only flushes completed subtrees, the root and the last path are still in memory
+	return l.root.ComputeCommitment().Bytes(), flushVerkleNode(db, l.root, l.logInterval)
+}
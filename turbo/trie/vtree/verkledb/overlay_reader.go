@@ -0,0 +1,84 @@
+package verkledb
+
+import (
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+var _ state.StateReader = (*OverlayReader)(nil)
+
+// OverlayReader reads the state while it is converted into the verkle tree after the Martin fork:
+// the tree is read first, and the flat state of the Merkle trie serves whatever has not been moved into the tree yet.
+// The source is picked per account by its code hash leaf, so that the header and the incarnation always agree, per
+// code by its code size leaf, which execution only writes together with the code, and per storage slot by its own leaf.
+// Leaves deleted from the tree are zeroed and do not fall back.
+type OverlayReader struct {
+	verkle *StateReader
+	flat   state.StateReader
+}
+
+func NewOverlayReader(root verkle.VerkleNode, resolver verkle.NodeResolverFn, flat state.StateReader) *OverlayReader {
+	return &OverlayReader{
+		verkle: NewStateReader(root, resolver).WithIncarnations(flat),
+		flat:   flat,
+	}
+}
+
+// inTree tells whether the leaf has been written into the tree
+func (r *OverlayReader) inTree(key []byte) (bool, error) {
+	value, err := r.verkle.get(key)
+	return value != nil, err
+}
+
+func (r *OverlayReader) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	inTree, err := r.inTree(vtree.GetTreeKeyCodeKeccak(address[:]))
+	if err != nil {
+		return nil, err
+	}
+	if !inTree {
+		return r.flat.ReadAccountData(address)
+	}
+	// Incarnation comes from the flat state, storage which is not converted yet is looked up by it
+	return r.verkle.ReadAccountData(address)
+}
+
+func (r *OverlayReader) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	inTree, err := r.inTree(vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(key[:])))
+	if err != nil {
+		return nil, err
+	}
+	if !inTree {
+		return r.flat.ReadAccountStorage(address, incarnation, key)
+	}
+	return r.verkle.ReadAccountStorage(address, incarnation, key)
+}
+
+func (r *OverlayReader) ReadAccountCode(address common.Address, incarnation uint64, codeHash common.Hash) ([]byte, error) {
+	inTree, err := r.inTree(vtree.GetTreeKeyCodeSize(address[:]))
+	if err != nil {
+		return nil, err
+	}
+	if !inTree {
+		return r.flat.ReadAccountCode(address, incarnation, codeHash)
+	}
+	return r.verkle.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (r *OverlayReader) ReadAccountCodeSize(address common.Address, incarnation uint64, codeHash common.Hash) (int, error) {
+	inTree, err := r.inTree(vtree.GetTreeKeyCodeSize(address[:]))
+	if err != nil {
+		return 0, err
+	}
+	if !inTree {
+		return r.flat.ReadAccountCodeSize(address, incarnation, codeHash)
+	}
+	return r.verkle.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+func (r *OverlayReader) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	return r.flat.ReadAccountIncarnation(address)
+}
@@ -0,0 +1,77 @@
+package verkledb
+
+import (
+	"github.com/gballet/go-verkle"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+var _ state.StateReader = (*StateReader)(nil)
+
+// StateReader reads the state directly from the verkle tree, nodes that are not in memory are loaded with the resolver.
+// The tree does not keep incarnations: execution after the fork reads them from the flat state, see WithIncarnations.
+type StateReader struct {
+	root         verkle.VerkleNode
+	resolver     verkle.NodeResolverFn
+	incarnations state.StateReader // nil if the incarnations are not known
+}
+
+func NewStateReader(root verkle.VerkleNode, resolver verkle.NodeResolverFn) *StateReader {
+	return &StateReader{
+		root:     root,
+		resolver: resolver,
+	}
+}
+
+// WithIncarnations makes the reader take the incarnations of contracts from the flat state, which the changesets and
+// the storage of re-created contracts are keyed by. Without them every contract is at its first incarnation.
+func (r *StateReader) WithIncarnations(flat state.StateReader) *StateReader {
+	r.incarnations = flat
+	return r
+}
+
+func (r *StateReader) get(key []byte) ([]byte, error) {
+	return r.root.Get(key, r.resolver)
+}
+
+func (r *StateReader) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	acc, err := state.ReadVerkleAccount(r.get, address)
+	if err != nil || acc == nil || acc.Incarnation == 0 || r.incarnations == nil {
+		return acc, err
+	}
+	flatAcc, err := r.incarnations.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if flatAcc != nil && flatAcc.Incarnation > 0 {
+		acc.Incarnation = flatAcc.Incarnation
+	}
+	return acc, nil
+}
+
+func (r *StateReader) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	return state.ReadVerkleStorage(r.get, address, key)
+}
+
+func (r *StateReader) ReadAccountCode(address common.Address, incarnation uint64, codeHash common.Hash) ([]byte, error) {
+	if accounts.IsEmptyCodeHash(codeHash) {
+		return nil, nil
+	}
+	codeSize, err := r.ReadAccountCodeSize(address, incarnation, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	return state.ReadVerkleCode(r.get, address, codeSize, codeHash)
+}
+
+func (r *StateReader) ReadAccountCodeSize(address common.Address, incarnation uint64, codeHash common.Hash) (int, error) {
+	return state.ReadVerkleCodeSize(r.get, address)
+}
+
+func (r *StateReader) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	if r.incarnations == nil {
+		return 0, nil
+	}
+	return r.incarnations.ReadAccountIncarnation(address)
+}
@@ -0,0 +1,168 @@
+package verkledb
+
+import (
+	"encoding/binary"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+var (
+	stemIndexHits   = metrics.GetOrCreateCounter(`verkle_stem_index{result="hit"}`)
+	stemIndexMisses = metrics.GetOrCreateCounter(`verkle_stem_index{result="miss"}`)
+)
+
+// StemIndexKey is the key of (address, treeIndex) in VerkleStemIndex, the tree index is big endian without leading zeros
+func StemIndexKey(address []byte, treeIndex *uint256.Int) []byte {
+	return append(common.CopyBytes(address), treeIndex.Bytes()...)
+}
+
+// PedersenStorageLookupKey is the key of a storage slot in PedersenHashedStorageLookup
+func PedersenStorageLookupKey(address []byte, storageKey *uint256.Int) []byte {
+	return append(common.CopyBytes(address), storageKey.Bytes()...)
+}
+
+// PedersenCodeLookupKey is the key of a code chunk in PedersenHashedCodeLookup
+func PedersenCodeLookupKey(address []byte, chunk uint32) []byte {
+	key := make([]byte, len(address)+4)
+	copy(key, address)
+	binary.BigEndian.PutUint32(key[len(address):], chunk)
+	return key
+}
+
+// ReadStem returns the stem of (address, treeIndex) out of the shared cache or the stem index, nil if it was never stored.
+// Stems found in the index are put in the cache.
+func ReadStem(tx kv.Getter, address []byte, treeIndex *uint256.Int) ([]byte, error) {
+	if stem, ok := vtree.CachedTreeStem(address, treeIndex); ok {
+		return stem, nil
+	}
+	stem, err := tx.GetOne(VerkleStemIndex, StemIndexKey(address, treeIndex))
+	if err != nil {
+		return nil, err
+	}
+	if len(stem) != vtree.StemLength {
+		stemIndexMisses.Inc()
+		return nil, nil
+	}
+	stemIndexHits.Inc()
+	stem = common.CopyBytes(stem)
+	vtree.AddTreeStem(address, treeIndex, stem)
+	return stem, nil
+}
+
+func WriteStem(tx kv.Putter, address []byte, treeIndex *uint256.Int, stem []byte) error {
+	return tx.Put(VerkleStemIndex, StemIndexKey(address, treeIndex), stem[:vtree.StemLength])
+}
+
+// PrefetchStems puts the indexed stems of an address in the shared cache, so that workers deriving its keys find them there
+func PrefetchStems(tx kv.Getter, address []byte, treeIndices ...*uint256.Int) error {
+	for _, treeIndex := range treeIndices {
+		if _, err := ReadStem(tx, address, treeIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StemIndexStats returns the hits and misses of the stem index since the start of the process
+func StemIndexStats() (hits, misses uint64) {
+	return stemIndexHits.Get(), stemIndexMisses.Get()
+}
+
+var _ state.VerkleTreeKeys = (*TreeKeys)(nil)
+
+// TreeKeys derives the tree keys of accounts, storage slots and code chunks through the shared stem cache and the
+// stem index, and records them in the Pedersen lookups. Stems that had to be computed are added to the index.
+type TreeKeys struct {
+	tx      kv.RwTx
+	lookups bool
+}
+
+// NewTreeKeys returns a TreeKeys writing into tx, the Pedersen lookups are left untouched if lookups is false
+func NewTreeKeys(tx kv.RwTx, lookups bool) *TreeKeys {
+	return &TreeKeys{tx: tx, lookups: lookups}
+}
+
+func (t *TreeKeys) key(address []byte, treeIndex *uint256.Int, subIndex byte) ([]byte, error) {
+	stem, err := ReadStem(t.tx, address, treeIndex)
+	if err != nil {
+		return nil, err
+	}
+	if stem == nil {
+		stem = vtree.ComputeTreeStem(address, treeIndex)
+		vtree.AddTreeStem(address, treeIndex, stem)
+		if err := WriteStem(t.tx, address, treeIndex, stem); err != nil {
+			return nil, err
+		}
+	}
+	key := make([]byte, 32)
+	copy(key, stem)
+	key[31] = subIndex
+	return key, nil
+}
+
+// VersionKey returns the key of the version leaf of an account, the other header leaves share its stem
+func (t *TreeKeys) VersionKey(address []byte) ([]byte, error) {
+	key, err := t.key(address, uint256.NewInt(0), vtree.VersionLeafKey)
+	if err != nil || !t.lookups {
+		return key, err
+	}
+	return key, t.tx.Put(PedersenHashedAccountsLookup, address, key)
+}
+
+func (t *TreeKeys) StorageKey(address []byte, storageKey *uint256.Int) ([]byte, error) {
+	treeIndex, subIndex := vtree.StorageSlotTreeIndex(storageKey)
+	key, err := t.key(address, treeIndex, subIndex)
+	if err != nil || !t.lookups {
+		return key, err
+	}
+	return key, t.tx.Put(PedersenHashedStorageLookup, PedersenStorageLookupKey(address, storageKey), key)
+}
+
+// CodeChunkKeys returns the keys of the first `count` code chunks
+func (t *TreeKeys) CodeChunkKeys(address []byte, count int) ([][]byte, error) {
+	keys := make([][]byte, count)
+	for i := range keys {
+		treeIndex, subIndex := vtree.CodeChunkTreeIndex(uint256.NewInt(uint64(i)))
+		key, err := t.key(address, treeIndex, subIndex)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		if !t.lookups {
+			continue
+		}
+		if err := t.tx.Put(PedersenHashedCodeLookup, PedersenCodeLookupKey(address, uint32(i)), key); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// DeleteStorageKeys removes the Pedersen lookups of the storage slots of an address and returns their tree keys
+func (t *TreeKeys) DeleteStorageKeys(address []byte) ([][]byte, error) {
+	return t.deleteLookups(PedersenHashedStorageLookup, address)
+}
+
+// DeleteCodeKeys removes the Pedersen lookups of the code chunks of an address and returns their tree keys
+func (t *TreeKeys) DeleteCodeKeys(address []byte) ([][]byte, error) {
+	return t.deleteLookups(PedersenHashedCodeLookup, address)
+}
+
+func (t *TreeKeys) deleteLookups(bucket string, address []byte) ([][]byte, error) {
+	if !t.lookups {
+		return nil, nil
+	}
+	var treeKeys [][]byte
+	if err := t.tx.ForPrefix(bucket, address, func(_, treeKey []byte) error {
+		treeKeys = append(treeKeys, common.CopyBytes(treeKey))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return treeKeys, DeletePedersenLookups(t.tx, bucket, address)
+}
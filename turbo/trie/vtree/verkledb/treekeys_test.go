@@ -0,0 +1,75 @@
+package verkledb
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeKeys(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(tx))
+	address := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7").Bytes()
+	keys := NewTreeKeys(tx, true)
+
+	versionKey, err := keys.VersionKey(address)
+	require.NoError(t, err)
+	require.Equal(t, vtree.GetTreeKeyVersion(address), versionKey)
+	lookup, err := tx.GetOne(PedersenHashedAccountsLookup, address)
+	require.NoError(t, err)
+	require.Equal(t, versionKey, lookup)
+
+	storageKey := uint256.NewInt(300)
+	slotKey, err := keys.StorageKey(address, storageKey)
+	require.NoError(t, err)
+	require.Equal(t, vtree.GetTreeKeyStorageSlot(address, storageKey), slotKey)
+	lookup, err = tx.GetOne(PedersenHashedStorageLookup, PedersenStorageLookupKey(address, storageKey))
+	require.NoError(t, err)
+	require.Equal(t, slotKey, lookup)
+
+	chunkKeys, err := keys.CodeChunkKeys(address, 130)
+	require.NoError(t, err)
+	for i, key := range chunkKeys {
+		require.Equal(t, vtree.GetTreeKeyCodeChunk(address, uint256.NewInt(uint64(i))), key)
+		lookup, err = tx.GetOne(PedersenHashedCodeLookup, PedersenCodeLookupKey(address, uint32(i)))
+		require.NoError(t, err)
+		require.Equal(t, key, lookup)
+	}
+
+	// Every stem is in the index: the header one, which holds the first 128 chunks, the next chunks one and the slot one
+	c, err := tx.Cursor(VerkleStemIndex)
+	require.NoError(t, err)
+	defer c.Close()
+	stems, err := c.Count()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), stems)
+	stem, err := ReadStem(tx, address, uint256.NewInt(0))
+	require.NoError(t, err)
+	require.Equal(t, versionKey[:vtree.StemLength], stem)
+
+	// Without lookups only the index is written
+	other := common.HexToAddress("0x01").Bytes()
+	_, err = NewTreeKeys(tx, false).VersionKey(other)
+	require.NoError(t, err)
+	lookup, err = tx.GetOne(PedersenHashedAccountsLookup, other)
+	require.NoError(t, err)
+	require.Nil(t, lookup)
+	stem, err = tx.GetOne(VerkleStemIndex, StemIndexKey(other, uint256.NewInt(0)))
+	require.NoError(t, err)
+	require.Len(t, stem, vtree.StemLength)
+
+	// Deleting the lookups hands back the keys they pointed to
+	deleted, err := keys.DeleteStorageKeys(address)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{slotKey}, deleted)
+	deleted, err = keys.DeleteCodeKeys(address)
+	require.NoError(t, err)
+	require.Len(t, deleted, len(chunkKeys))
+	lookups, err := ReadPedersenLookups(tx, common.BytesToAddress(address))
+	require.NoError(t, err)
+	require.Empty(t, lookups)
+}
@@ -0,0 +1,427 @@
+package verkledb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gballet/go-verkle"
+	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxInsert is the amount of updates after which modified nodes are flushed to the database
+	maxInsert = 50_000
+	// maxBufferedInsert is the same for updates applied from the collector on commit
+	maxBufferedInsert = 2_000_000
+	// verkleSubtreeBuffers is the share of the optimal ETL buffer given to every subtree split off by CommitFromScratch,
+	// as long as the workers are behind, several of them are kept in memory
+	verkleSubtreeBuffers = 16
+)
+
+// VerkleTree is the verkle tree kept in the VerkleTrie bucket, nodes are addressed by their commitment.
+// Updates are either applied to the tree right away, or, for a tree created with NewBufferedVerkleTree,
+// collected with ETL and applied in key order on commit. Buffered updates are not visible to reads before commit.
+// All methods are safe for concurrent use.
+type VerkleTree struct {
+	db        kv.RwTx
+	root      verkle.VerkleNode
+	collector *etl.Collector
+	tmpdir    string
+	inserted  uint64
+	mu        sync.Mutex
+}
+
+// NewVerkleTree opens the tree with the given root, empty root means an empty tree
+func NewVerkleTree(db kv.RwTx, root common.Hash) (*VerkleTree, error) {
+	rootNode, err := readRootNode(db, root)
+	if err != nil {
+		return nil, err
+	}
+	return &VerkleTree{
+		db:   db,
+		root: rootNode,
+	}, nil
+}
+
+// NewBufferedVerkleTree opens the tree with the given root for bulk updates, which are sorted in tmpdir
+func NewBufferedVerkleTree(db kv.RwTx, root common.Hash, tmpdir string) (*VerkleTree, error) {
+	v, err := NewVerkleTree(db, root)
+	if err != nil {
+		return nil, err
+	}
+	v.collector = etl.NewCollector(VerkleTrie, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize*8))
+	v.tmpdir = tmpdir
+	return v, nil
+}
+
+func readRootNode(db kv.Getter, root common.Hash) (verkle.VerkleNode, error) {
+	if root == (common.Hash{}) {
+		return verkle.New(), nil
+	}
+	encoded, err := db.GetOne(VerkleTrie, root[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("verkle root %x not found", root)
+	}
+	rootNode, err := verkle.ParseNode(encoded, 0, root[:])
+	if err != nil {
+		return nil, fmt.Errorf("parse verkle root %x: %w", root, err)
+	}
+	return rootNode, nil
+}
+
+// Resolve loads a node by its commitment, it is the resolver of the tree
+func (v *VerkleTree) Resolve(commitment []byte) ([]byte, error) {
+	return v.db.GetOne(VerkleTrie, commitment)
+}
+
+// Node returns the root node, so that it can be read and written through core/state
+func (v *VerkleTree) Node() verkle.VerkleNode {
+	return v.root
+}
+
+// Get returns the value of a leaf, nil if it is absent
+func (v *VerkleTree) Get(key []byte) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.root.Get(key, v.Resolve)
+}
+
+func (v *VerkleTree) Insert(key, value []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.insert(key, value)
+}
+
+func (v *VerkleTree) Delete(key []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.delete(key)
+}
+
+// UpdateAccount writes the header leaves of an account
+func (v *VerkleTree) UpdateAccount(versionKey []byte, codeSize uint64, acc accounts.Account) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys, values := AccountLeaves(versionKey, codeSize, acc)
+	for i, key := range keys {
+		if err := v.insert(key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AccountLeaves returns the keys and values of the header leaves of an account
+func AccountLeaves(versionKey []byte, codeSize uint64, acc accounts.Account) ([][]byte, [][]byte) {
+	var nonce, balance, cs [32]byte
+	vtree.Int256ToVerkleFormat(&acc.Balance, balance[:])
+	binary.LittleEndian.PutUint64(nonce[:], acc.Nonce)
+	binary.LittleEndian.PutUint64(cs[:], codeSize)
+
+	values := [][]byte{
+		vtree.VersionLeafKey:    {0},
+		vtree.BalanceLeafKey:    balance[:],
+		vtree.NonceLeafKey:      nonce[:],
+		vtree.CodeKeccakLeafKey: common.CopyBytes(acc.CodeHash[:]),
+		vtree.CodeSizeLeafKey:   cs[:],
+	}
+	return vtree.GetTreeKeyAccountLeaves(versionKey), values
+}
+
+// DeleteAccount removes the header leaves of an account, code and storage are left untouched
+func (v *VerkleTree) DeleteAccount(versionKey []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range vtree.GetTreeKeyAccountLeaves(versionKey) {
+		if err := v.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *VerkleTree) WriteContractCodeChunks(codeKeys [][]byte, chunks [][]byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, codeKey := range codeKeys {
+		if err := v.insert(codeKey, chunks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prove makes a multiproof of the given keys against the current state of the tree
+func (v *VerkleTree) Prove(keys [][]byte) ([]byte, []verkle.KeyValuePair, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return vtree.MakeVerkleProof(v.root, keys, v.Resolve)
+}
+
+func (v *VerkleTree) insert(key, value []byte) error {
+	if v.collector != nil {
+		return v.collector.Collect(key, value)
+	}
+	if err := v.root.Insert(key, value, v.Resolve); err != nil {
+		return err
+	}
+	return v.maybeFlush()
+}
+
+// delete removes a leaf, absent leaves are skipped. Buffered deletions are collected as empty values.
+func (v *VerkleTree) delete(key []byte) error {
+	if v.collector != nil {
+		return v.collector.Collect(key, nil)
+	}
+	if err := v.deleteNode(key); err != nil {
+		return err
+	}
+	return v.maybeFlush()
+}
+
+func (v *VerkleTree) deleteNode(key []byte) error {
+	value, err := v.root.Get(key, v.Resolve)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	return v.root.Delete(key, v.Resolve)
+}
+
+func (v *VerkleTree) maybeFlush() error {
+	v.inserted++
+	if v.inserted < maxInsert {
+		return nil
+	}
+	v.inserted = 0
+	return flushVerkleNode(v.db, v.root, nil)
+}
+
+// Commit applies the buffered updates, writes the modified nodes to the database and returns the new root
+func (v *VerkleTree) Commit() (common.Hash, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	logInterval := time.NewTicker(30 * time.Second)
+	defer logInterval.Stop()
+	if v.collector != nil {
+		insertions := 0
+		if err := v.collector.Load(v.db, VerkleTrie, func(key []byte, value []byte, _ etl.CurrentTableReader, next etl.LoadNextFunc) error {
+			if len(value) == 0 {
+				if err := v.deleteNode(common.CopyBytes(key)); err != nil {
+					return err
+				}
+			} else if err := v.root.Insert(common.CopyBytes(key), common.CopyBytes(value), v.Resolve); err != nil {
+				return err
+			}
+			insertions++
+			if insertions > maxBufferedInsert {
+				if err := flushVerkleNode(v.db, v.root, logInterval); err != nil {
+					return err
+				}
+				insertions = 0
+			}
+			return next(key, nil, nil)
+		}, etl.TransformArgs{Quit: context.Background().Done()}); err != nil {
+			return common.Hash{}, err
+		}
+		v.resetCollector()
+	}
+	v.inserted = 0
+	return v.root.ComputeCommitment().Bytes(), flushVerkleNode(v.db, v.root, logInterval)
+}
+
+// CommitFromScratch builds a new tree out of the buffered updates, inserting them in key order, and returns its root.
+// Nodes of all other trees are removed from the database. The sorted updates are split by their first byte into the
+// subtrees of the root children, up to `workers` of them are built at once, and the subtrees are merged at the root.
+func (v *VerkleTree) CommitFromScratch(workers int) (common.Hash, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.collector == nil {
+		return common.Hash{}, fmt.Errorf("verkle tree is not buffered")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	// The config is set up lazily on first use, which is not safe for concurrent use
+	if _, err := verkle.GetConfig(); err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := v.db.ClearBucket(VerkleTrie); err != nil {
+		return common.Hash{}, err
+	}
+
+	// Every worker collects the nodes of its subtrees, the transaction is only written to once they are done
+	nodeCollectors := make([]*etl.Collector, workers)
+	for i := range nodeCollectors {
+		nodeCollectors[i] = etl.NewCollector(VerkleTrie, v.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+		defer nodeCollectors[i].Close()
+	}
+	subroots := make([]*verkle.InternalNode, 0, verkle.NodeWidth)
+	var subrootsLock sync.Mutex
+	subtrees := make(chan *etl.Collector)
+	g, ctx := errgroup.WithContext(context.Background())
+	logInterval := time.NewTicker(30 * time.Second)
+	defer logInterval.Stop()
+	for i := 0; i < workers; i++ {
+		nodeCollector := nodeCollectors[i]
+		g.Go(func() error {
+			defer debug.LogPanic()
+			for subtree := range subtrees {
+				subroot, err := buildSubtree(ctx, subtree, nodeCollector, logInterval)
+				subtree.Close()
+				if err != nil {
+					return err
+				}
+				subrootsLock.Lock()
+				subroots = append(subroots, subroot)
+				subrootsLock.Unlock()
+			}
+			return nil
+		})
+	}
+
+	// The updates are sorted, so a subtree is complete as soon as the first byte changes,
+	// and it is handed to the workers while the following ones are still being split off
+	var subtree *etl.Collector
+	var subtreeIndex byte
+	splitErr := v.collector.Load(nil, "", func(k []byte, value []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
+		if subtree != nil && k[0] != subtreeIndex {
+			select {
+			case subtrees <- subtree:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			subtree = nil
+		}
+		if subtree == nil {
+			subtree = etl.NewCollector(VerkleTrie, v.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize/verkleSubtreeBuffers))
+			subtreeIndex = k[0]
+		}
+		return subtree.Collect(k, value)
+	}, etl.TransformArgs{Quit: ctx.Done()})
+	if splitErr == nil && subtree != nil {
+		select {
+		case subtrees <- subtree:
+			subtree = nil
+		case <-ctx.Done():
+		}
+	}
+	if subtree != nil {
+		subtree.Close()
+	}
+	close(subtrees)
+	if err := g.Wait(); err != nil {
+		return common.Hash{}, err
+	}
+	if splitErr != nil {
+		return common.Hash{}, splitErr
+	}
+	v.resetCollector()
+
+	root := verkle.MergeTrees(subroots)
+	v.root = root
+
+	log.Info("Started Verkle Tree Flushing")
+	for _, nodeCollector := range nodeCollectors {
+		if err := nodeCollector.Load(v.db, VerkleTrie, etl.IdentityLoadFunc, etl.TransformArgs{Quit: context.Background().Done(),
+			LogDetailsLoad: func(k, v []byte) (additionalLogArguments []interface{}) {
+				return []interface{}{"key", common.Bytes2Hex(k)}
+			}}); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	// InsertOrdered only flushes completed subtrees, the root and the last paths are still in memory
+	return root.ComputeCommitment().Bytes(), flushVerkleNode(v.db, root, logInterval)
+}
+
+// buildSubtree inserts the sorted updates of one root child into a root of its own, completed nodes are collected.
+// The commitment of the child is computed before returning, so that merging the subtrees only has to commit the root.
+func buildSubtree(ctx context.Context, updates *etl.Collector, nodeCollector *etl.Collector, logInterval *time.Ticker) (*verkle.InternalNode, error) {
+	subroot := verkle.New().(*verkle.InternalNode)
+	var child byte
+	if err := updates.Load(nil, "", func(k []byte, value []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
+		child = k[0]
+		// Flush callback can't return an error
+		var flushErr error
+		if err := subroot.InsertOrdered(common.CopyBytes(k), common.CopyBytes(value), func(node verkle.VerkleNode) {
+			if flushErr != nil {
+				return
+			}
+			commitment := node.ComputeCommitment().Bytes()
+			encodedNode, err := node.Serialize()
+			if err != nil {
+				flushErr = err
+				return
+			}
+			flushErr = nodeCollector.Collect(commitment[:], encodedNode)
+			select {
+			case <-logInterval.C:
+				log.Info("[Verkle] Assembling Verkle Tree", "key", common.Bytes2Hex(k))
+			default:
+			}
+		}); err != nil {
+			return err
+		}
+		return flushErr
+	}, etl.TransformArgs{Quit: ctx.Done()}); err != nil {
+		return nil, err
+	}
+	subroot.Children()[child].ComputeCommitment()
+	return subroot, nil
+}
+
+// resetCollector replaces the loaded collector, so that the tree can be updated and committed again
+func (v *VerkleTree) resetCollector() {
+	v.collector.Close()
+	v.collector = etl.NewCollector(VerkleTrie, v.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize*8))
+}
+
+// flushVerkleNode writes the nodes modified under `node` to the VerkleTrie bucket, logInterval is optional
+func flushVerkleNode(db kv.RwTx, node verkle.VerkleNode, logInterval *time.Ticker) error {
+	internal, ok := node.(*verkle.InternalNode)
+	if !ok {
+		return fmt.Errorf("verkle root is not an internal node: %T", node)
+	}
+	var err error
+	totalInserted := 0
+	internal.Flush(func(node verkle.VerkleNode) {
+		if err != nil {
+			return
+		}
+		var encodedNode []byte
+
+		commitment := node.ComputeCommitment().Bytes()
+		encodedNode, err = node.Serialize()
+		if err != nil {
+			return
+		}
+		err = db.Put(VerkleTrie, commitment[:], encodedNode)
+		totalInserted++
+		if logInterval == nil {
+			return
+		}
+		select {
+		case <-logInterval.C:
+			log.Info("Flushing Verkle nodes", "inserted", totalInserted)
+		default:
+		}
+	})
+	return err
+}
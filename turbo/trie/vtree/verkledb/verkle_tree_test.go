@@ -0,0 +1,200 @@
+package verkledb
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testContract = common.HexToAddress("0xc0de")
+	testCode     = common.FromHex("0x6001600055600160015560016002556001600355")
+	// testRoot is the root of the tree written by writeTestState
+	testRoot = common.HexToHash("0x3257f778805353942971630a4366ab9213b91c8c1398f60f8826a40fc9dccc27")
+)
+
+func writeTestState(t *testing.T, tree *VerkleTree) {
+	for i := 1; i <= 10; i++ {
+		address := common.BytesToAddress([]byte{byte(i)})
+		acc := accounts.NewAccount()
+		acc.Nonce = uint64(i)
+		acc.Balance.SetUint64(uint64(i) * 1_000_000_000)
+		require.NoError(t, tree.UpdateAccount(vtree.GetTreeKeyVersion(address[:]), 0, acc))
+	}
+
+	acc := accounts.NewAccount()
+	acc.CodeHash = crypto.Keccak256Hash(testCode)
+	require.NoError(t, tree.UpdateAccount(vtree.GetTreeKeyVersion(testContract[:]), uint64(len(testCode)), acc))
+	chunkedCode := vtree.ChunkifyCode(testCode)
+	var chunks [][]byte
+	for i := 0; i < len(chunkedCode); i += 32 {
+		chunks = append(chunks, chunkedCode[i:i+32])
+	}
+	require.NoError(t, tree.WriteContractCodeChunks(vtree.GetTreeKeyCodeChunks(testContract[:], len(chunks)), chunks))
+
+	for i := uint64(0); i < 4; i++ {
+		value := make([]byte, 32)
+		vtree.Int256ToVerkleFormat(uint256.NewInt(i+1), value)
+		require.NoError(t, tree.Insert(vtree.GetTreeKeyStorageSlot(testContract[:], uint256.NewInt(i)), value))
+	}
+}
+
+func TestVerkleTreeCommit(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		open   func(tx kv.RwTx) (*VerkleTree, error)
+		commit func(tree *VerkleTree) (common.Hash, error)
+	}{
+		{
+			name:   "direct",
+			open:   func(tx kv.RwTx) (*VerkleTree, error) { return NewVerkleTree(tx, common.Hash{}) },
+			commit: (*VerkleTree).Commit,
+		},
+		{
+			name:   "buffered",
+			open:   func(tx kv.RwTx) (*VerkleTree, error) { return NewBufferedVerkleTree(tx, common.Hash{}, t.TempDir()) },
+			commit: (*VerkleTree).Commit,
+		},
+		{
+			name:   "from scratch",
+			open:   func(tx kv.RwTx) (*VerkleTree, error) { return NewBufferedVerkleTree(tx, common.Hash{}, t.TempDir()) },
+			commit: func(tree *VerkleTree) (common.Hash, error) { return tree.CommitFromScratch(1) },
+		},
+		{
+			name:   "from scratch in parallel",
+			open:   func(tx kv.RwTx) (*VerkleTree, error) { return NewBufferedVerkleTree(tx, common.Hash{}, t.TempDir()) },
+			commit: func(tree *VerkleTree) (common.Hash, error) { return tree.CommitFromScratch(4) },
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tx := memdb.NewTestTx(t)
+			require.NoError(t, InitDB(tx))
+
+			tree, err := tt.open(tx)
+			require.NoError(t, err)
+			writeTestState(t, tree)
+			root, err := tt.commit(tree)
+			require.NoError(t, err)
+			require.Equal(t, testRoot, root)
+
+			// The committed tree can be reopened and read back
+			reopened, err := NewVerkleTree(tx, root)
+			require.NoError(t, err)
+			nonce, err := reopened.Get(vtree.GetTreeKeyNonce(common.BytesToAddress([]byte{3}).Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, uint64(3), binary.LittleEndian.Uint64(nonce))
+		})
+	}
+}
+
+func TestVerkleTreeCommitFromScratch(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(tx))
+	direct, err := NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+
+	_, scratchTx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(scratchTx))
+	scratch, err := NewBufferedVerkleTree(scratchTx, common.Hash{}, t.TempDir())
+	require.NoError(t, err)
+
+	// Enough keys for every root child to have internal nodes below it
+	var keys [][]byte
+	for i := uint64(0); i < 2_000; i++ {
+		value := make([]byte, 32)
+		vtree.Int256ToVerkleFormat(uint256.NewInt(i+1), value)
+		key := vtree.GetTreeKeyStorageSlot(testContract[:], uint256.NewInt(i*1_000))
+		keys = append(keys, key)
+		require.NoError(t, direct.Insert(key, value))
+		require.NoError(t, scratch.Insert(key, value))
+	}
+	want, err := direct.Commit()
+	require.NoError(t, err)
+	root, err := scratch.CommitFromScratch(8)
+	require.NoError(t, err)
+	require.Equal(t, want, root)
+
+	reopened, err := NewVerkleTree(scratchTx, root)
+	require.NoError(t, err)
+	for i, key := range keys {
+		value, err := reopened.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, byte(i+1), value[0])
+	}
+}
+
+func TestVerkleTreeDelete(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(tx))
+
+	tree, err := NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+	writeTestState(t, tree)
+
+	// Deleting a leaf that was never written is a no-op
+	absent := common.HexToAddress("0xdead")
+	require.NoError(t, tree.DeleteAccount(vtree.GetTreeKeyVersion(absent[:])))
+	root, err := tree.Commit()
+	require.NoError(t, err)
+	require.Equal(t, testRoot, root)
+
+	slotKey := vtree.GetTreeKeyStorageSlot(testContract[:], uint256.NewInt(0))
+	require.NoError(t, tree.Delete(slotKey))
+	value, err := tree.Get(slotKey)
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, 32), value)
+	root, err = tree.Commit()
+	require.NoError(t, err)
+	require.NotEqual(t, testRoot, root)
+
+	_, err = NewVerkleTree(tx, common.HexToHash("0x01"))
+	require.Error(t, err)
+}
+
+func TestLeafNodeLoader(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(tx))
+	tree, err := NewVerkleTree(tx, common.Hash{})
+	require.NoError(t, err)
+	writeTestState(t, tree)
+	root, err := tree.Commit()
+	require.NoError(t, err)
+
+	var leafNodes [][]byte
+	require.NoError(t, WalkLeafNodes(tx, root, func(encoded []byte) error {
+		leafNodes = append(leafNodes, common.CopyBytes(encoded))
+		return nil
+	}))
+	// 11 accounts, the code and the first slots are in the header stem of the contract
+	require.Equal(t, 11, len(leafNodes))
+
+	_, loadTx := memdb.NewTestTx(t)
+	require.NoError(t, InitDB(loadTx))
+	loader := NewLeafNodeLoader(t.TempDir())
+	defer loader.Close()
+	for _, encoded := range leafNodes {
+		require.NoError(t, loader.Add(encoded))
+	}
+	loadedRoot, err := loader.Commit(loadTx)
+	require.NoError(t, err)
+	require.Equal(t, testRoot, loadedRoot)
+
+	loaded, err := NewVerkleTree(loadTx, loadedRoot)
+	require.NoError(t, err)
+	nonce, err := loaded.Get(vtree.GetTreeKeyNonce(common.BytesToAddress([]byte{3}).Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), binary.LittleEndian.Uint64(nonce))
+
+	unordered := NewLeafNodeLoader(t.TempDir())
+	defer unordered.Close()
+	require.NoError(t, unordered.Add(leafNodes[1]))
+	require.Error(t, unordered.Add(leafNodes[0]))
+}
@@ -0,0 +1,95 @@
+package visual
+
+import (
+	"fmt"
+	"io"
+)
+
+// Primitives for drawing 256-ary verkle nodes in graphviz dot format
+
+// Colors of the leaf suffixes, by the part of the account they belong to
+const (
+	VerkleAccountColor = "#FF6403" // version, balance, nonce, code hash and code size of the header stem
+	VerkleStorageColor = "#02ABEA" // storage slots
+	VerkleCodeColor    = "#1FB714" // code chunks
+	VerkleOtherColor   = "#C0C0C0" // leaves of stems whose layout is unknown
+)
+
+// VerkleSuffix is a populated position of a leaf node
+type VerkleSuffix struct {
+	Index byte
+	Color string
+}
+
+// ShortCommitment shortens a commitment to its first and last bytes
+func ShortCommitment(commitment []byte) string {
+	if len(commitment) <= 6 {
+		return fmt.Sprintf("%x", commitment)
+	}
+	return fmt.Sprintf("%x..%x", commitment[:4], commitment[len(commitment)-2:])
+}
+
+// VerkleInternal produces an internal node as a row of its non-empty children, each of them with the port `c<index>`
+// name - name of the compontent (to be connected to others)
+func VerkleInternal(w io.Writer, name string, depth int, commitment []byte, children []byte) {
+	fmt.Fprintf(w,
+		`
+	%s [label=<
+	<table border="0" color="#000000" cellborder="1" cellspacing="0">
+	<tr><td colspan="%d" bgcolor="#E0E0E0">depth %d: %s</td></tr>
+	<tr>`, name, max(len(children), 1), depth, ShortCommitment(commitment))
+	if len(children) == 0 {
+		fmt.Fprintf(w, "<td></td>")
+	}
+	for _, child := range children {
+		fmt.Fprintf(w,
+			`		<td port="c%02x">%02x</td>
+	`, child, child)
+	}
+	fmt.Fprintf(w,
+		`
+	</tr></table>
+	>];
+	`)
+}
+
+// VerkleLeaf produces a leaf node: its stem, its commitment and its populated suffixes, 16 per row
+// name - name of the compontent (to be connected to others)
+func VerkleLeaf(w io.Writer, name string, stem []byte, commitment []byte, suffixes []VerkleSuffix) {
+	columns := 16
+	if len(suffixes) < columns {
+		columns = max(len(suffixes), 1)
+	}
+	fmt.Fprintf(w,
+		`
+	%s [label=<
+	<table border="0" color="#000000" cellborder="1" cellspacing="0">
+	<tr><td colspan="%d" bgcolor="#FBF305">stem %x</td></tr>
+	<tr><td colspan="%d" bgcolor="#E0E0E0">%s</td></tr>
+	`, name, columns, stem, columns, ShortCommitment(commitment))
+	for rowStart := 0; rowStart < len(suffixes); rowStart += columns {
+		fmt.Fprintf(w, "		<tr>")
+		col := 0
+		for ; rowStart+col < len(suffixes) && col < columns; col++ {
+			suffix := suffixes[rowStart+col]
+			fmt.Fprintf(w, `<td bgcolor="%s">%02x</td>`, suffix.Color, suffix.Index)
+		}
+		if col < columns {
+			fmt.Fprintf(w, `<td colspan="%d" border="0"></td>`, columns-col)
+		}
+		fmt.Fprintf(w, `</tr>
+	`)
+	}
+	fmt.Fprintf(w,
+		`
+	</table>
+	>];
+	`)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}